@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/doom-coding/doom-coding/internal/config"
+	"github.com/doom-coding/doom-coding/internal/executor"
+	"github.com/doom-coding/doom-coding/internal/secrets"
+	"github.com/spf13/cobra"
+)
+
+// Apply command flags
+var (
+	applyFile          string
+	applyMode          string
+	applyTailscaleKey  string
+	applyCodePassword  string
+	applySudoPassword  string
+	applyAnthropicKey  string
+	applySkipDocker    bool
+	applySkipTailscale bool
+	applySkipTerminal  bool
+	applySkipHardening bool
+	applySkipSecrets   bool
+	applyDryRun        bool
+	applyVerbose       bool
+	applyResume        bool
+)
+
+func newApplyCmd() *cobra.Command {
+	applyCmd := &cobra.Command{
+		Use:   "apply -f FILE",
+		Short: "Install from a configuration file without prompts",
+		Long: `Install from a configuration file without prompts.
+
+Values are merged in this order, later ones winning:
+
+  1. built-in defaults
+  2. the configuration file given with -f
+  3. DOOM_* environment variables (e.g. DOOM_CODE_PASSWORD, DOOM_TIMEZONE)
+  4. command line flags
+
+Credentials may be references such as env:TS_AUTHKEY, file:PATH,
+cmd:COMMAND, age:PATH or keyring:SERVICE/ACCOUNT, which are resolved
+before the merged configuration is validated. .env and the compose secrets
+in secrets/ are then written to the project root, removing secret files the
+deployment mode does not use, and install.sh is run unattended.
+
+Every step outcome is recorded in ` + executor.JournalFile + ` in the project root.
+With --resume, steps that completed in a previous run with the same
+configuration are skipped and only the failed tail is run again.`,
+		Args:          cobra.NoArgs,
+		RunE:          runApply,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	flags := applyCmd.Flags()
+	flags.StringVarP(&applyFile, "file", "f", "", "Configuration file to apply")
+	flags.StringVar(&applyMode, "mode", "", "Deployment mode (tailscale, local, native-tailscale, terminal-only)")
+	flags.StringVar(&applyTailscaleKey, "tailscale-key", "", "Tailscale auth key")
+	flags.StringVar(&applyCodePassword, "code-password", "", "code-server password")
+	flags.StringVar(&applySudoPassword, "sudo-password", "", "Container sudo password")
+	flags.StringVar(&applyAnthropicKey, "anthropic-key", "", "Anthropic API key")
+	flags.BoolVar(&applySkipDocker, "skip-docker", false, "Skip Docker installation")
+	flags.BoolVar(&applySkipTailscale, "skip-tailscale", false, "Skip Tailscale")
+	flags.BoolVar(&applySkipTerminal, "skip-terminal", false, "Skip terminal tools setup")
+	flags.BoolVar(&applySkipHardening, "skip-hardening", false, "Skip SSH hardening")
+	flags.BoolVar(&applySkipSecrets, "skip-secrets", false, "Skip secrets management setup")
+	flags.BoolVar(&applyDryRun, "dry-run", false, "Validate and run install.sh --dry-run without writing files")
+	flags.BoolVar(&applyVerbose, "verbose", false, "Enable verbose output")
+	flags.BoolVar(&applyResume, "resume", false, "Skip steps completed by a previous run")
+	applyCmd.MarkFlagRequired("file")
+	return applyCmd
+}
+
+// loadApplyConfig loads the configuration file and applies the DOOM_*
+// variables returned by lookup and the flags set on cmd, in that order
+func loadApplyConfig(cmd *cobra.Command, lookup func(string) (string, bool)) (*config.Config, error) {
+	cfg, err := loadConfig(cmd, applyFile)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.ApplyEnv(lookup); err != nil {
+		return nil, err
+	}
+
+	flags := cmd.Flags()
+	if flags.Changed("mode") {
+		cfg.DeploymentMode = applyMode
+	}
+	if flags.Changed("tailscale-key") {
+		cfg.Credentials.TailscaleKey = applyTailscaleKey
+	}
+	if flags.Changed("code-password") {
+		cfg.Credentials.CodePassword = applyCodePassword
+	}
+	if flags.Changed("sudo-password") {
+		cfg.Credentials.SudoPassword = applySudoPassword
+	}
+	if flags.Changed("anthropic-key") {
+		cfg.Credentials.AnthropicKey = applyAnthropicKey
+	}
+	if flags.Changed("skip-docker") {
+		cfg.Components.Docker = !applySkipDocker
+	}
+	if flags.Changed("skip-tailscale") {
+		cfg.Components.Tailscale = !applySkipTailscale
+	}
+	if flags.Changed("skip-terminal") {
+		cfg.Components.TerminalTools = !applySkipTerminal
+	}
+	if flags.Changed("skip-hardening") {
+		cfg.Components.SSHHardening = !applySkipHardening
+	}
+	if flags.Changed("skip-secrets") {
+		cfg.Components.SecretsManager = !applySkipSecrets
+	}
+	return cfg, nil
+}
+
+func runApply(cmd *cobra.Command, args []string) error {
+	cfg, err := loadApplyConfig(cmd, os.LookupEnv)
+	if err != nil {
+		return err
+	}
+
+	projectRoot, err := findProjectRoot()
+	if err != nil {
+		return fmt.Errorf("could not find project root: %w", err)
+	}
+	cfg, err = cfg.ResolveSecrets(cmd.Context(), config.NewSecretResolvers(projectRoot, nil))
+	if err != nil {
+		return err
+	}
+
+	problems := cfg.ValidateIn(projectRoot)
+	for _, warning := range problems.Warnings() {
+		fmt.Fprintf(cmd.ErrOrStderr(), "warning: %s\n", warning)
+	}
+	if problems.HasErrors() {
+		var lines []string
+		for _, problem := range problems.Errors() {
+			lines = append(lines, problem.Error())
+		}
+		return errors.New("invalid configuration:\n  - " + strings.Join(lines, "\n  - "))
+	}
+
+	out := cmd.OutOrStdout()
+	if applyDryRun {
+		fmt.Fprintln(out, "Dry run: not writing .env and secrets")
+	} else {
+		// The secrets come first, they fail before writing anything when
+		// PUID and PGID cannot own them
+		stale, err := cfg.WriteSecrets(projectRoot)
+		if err != nil {
+			return err
+		}
+		if err := cfg.WriteEnvFile(projectRoot); err != nil {
+			return err
+		}
+		for _, name := range stale {
+			fmt.Fprintf(out, "Removed stale secret %s/%s.txt\n", secrets.Dir, name)
+		}
+	}
+
+	exec := executor.NewExecutor(projectRoot)
+	exec.DryRun = applyDryRun
+	exec.Verbose = applyVerbose
+	if !applyDryRun {
+		exec.Journal, err = loadInstallJournal(projectRoot, applyResume)
+		if err != nil {
+			return err
+		}
+		exec.Resume = applyResume
+		exec.Inputs = []string{filepath.Join(projectRoot, ".env"), applyFile}
+	}
+
+	flags, cleanup, err := installFlags(cfg)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	events := exec.Subscribe()
+	printed := make(chan struct{})
+	go func() {
+		defer close(printed)
+		for ev := range events {
+			printEvent(out, ev)
+		}
+	}()
+
+	err = exec.RunInstallScript(ctx, flags)
+	<-printed
+
+	fmt.Fprintln(out)
+	printStepResults(out, exec.GetResults())
+
+	if err != nil && exec.Journal != nil {
+		fmt.Fprintf(out, "\nFix the problem and continue with: %s apply -f %s --resume\n", AppName, applyFile)
+	}
+	return err
+}
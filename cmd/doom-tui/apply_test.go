@@ -0,0 +1,115 @@
+package main
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/doom-coding/doom-coding/internal/config"
+)
+
+// writeConfig saves cfg to a temporary file and returns its path
+func writeConfig(t *testing.T, cfg *config.Config) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := cfg.SaveToFile(path); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestApplyPrecedence(t *testing.T) {
+	file := config.NewDefaultConfig()
+	file.Credentials.CodePassword = "from-file"
+	file.Credentials.SudoPassword = "from-file"
+	file.Environment.Timezone = "Asia/Tokyo"
+	file.Environment.WorkspacePath = "/srv/file"
+	path := writeConfig(t, file)
+
+	env := map[string]string{
+		"DOOM_CODE_PASSWORD":  "from-env",
+		"DOOM_SUDO_PASSWORD":  "from-env",
+		"DOOM_WORKSPACE_PATH": "/srv/env",
+	}
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+
+	cmd := newApplyCmd()
+	if err := cmd.ParseFlags([]string{"-f", path, "--code-password", "from-flag", "--skip-secrets", "--sudo-password", "from-flag"}); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := loadApplyConfig(cmd, lookup)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Environment.Timezone != "Asia/Tokyo" {
+		t.Errorf("File values should override defaults, got timezone %q", cfg.Environment.Timezone)
+	}
+	if cfg.Environment.WorkspacePath != "/srv/env" {
+		t.Errorf("Environment should override the file, got workspace %q", cfg.Environment.WorkspacePath)
+	}
+	if cfg.Credentials.CodePassword != "from-flag" || cfg.Credentials.SudoPassword != "from-flag" {
+		t.Errorf("Flags should override the environment, got %+v", cfg.Credentials)
+	}
+	if cfg.Components.SecretsManager || !cfg.Components.Docker {
+		t.Errorf("Only the skip flags that were set should change components, got %+v", cfg.Components)
+	}
+}
+
+func TestApplyModeFlag(t *testing.T) {
+	file := config.NewDefaultConfig()
+	file.Credentials.CodePassword = "Correct-Horse-9"
+	file.Credentials.SudoPassword = "Battery-Staple-7"
+
+	cmd := newApplyCmd()
+	if err := cmd.ParseFlags([]string{"-f", writeConfig(t, file), "--mode", "local"}); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := loadApplyConfig(cmd, func(string) (string, bool) { return "", false })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := cfg.Validate().Errors(); len(errs) > 0 {
+		t.Errorf("Local mode with the default components should be valid, got %v", errs)
+	}
+}
+
+func TestApplyRejectsInvalidConfig(t *testing.T) {
+	cfg := config.NewDefaultConfig()
+	cfg.Environment.WorkspacePath = ""
+
+	var stderr strings.Builder
+	cmd := newApplyCmd()
+	cmd.SetErr(&stderr)
+	cmd.SetArgs([]string{"-f", writeConfig(t, cfg), "--dry-run"})
+	err := cmd.Execute()
+	if err == nil {
+		t.Fatal("Invalid configuration should not be applied")
+	}
+	if stderr.Len() > 0 {
+		t.Errorf("The error is printed by main and should not be printed by the command:\n%s", stderr.String())
+	}
+	for _, want := range []string{"invalid configuration", "workspace path cannot be empty"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Error should contain %q, got %v", want, err)
+		}
+	}
+}
+
+func TestApplyResolvesSecrets(t *testing.T) {
+	cfg := config.NewDefaultConfig()
+	cfg.Credentials.CodePassword = "env:DOOM_TEST_UNSET_PASSWORD"
+
+	cmd := newApplyCmd()
+	cmd.SetErr(io.Discard)
+	cmd.SetArgs([]string{"-f", writeConfig(t, cfg), "--dry-run"})
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "credentials.code_password") || !strings.Contains(err.Error(), "DOOM_TEST_UNSET_PASSWORD is not set") {
+		t.Errorf("Expected the unresolved reference to be reported, got %v", err)
+	}
+}
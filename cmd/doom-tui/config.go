@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/doom-coding/doom-coding/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// Config command flags
+var (
+	convertForce   bool
+	convertAge     []string
+	migrateDryRun  bool
+	importEnvForce bool
+)
+
+func newConfigCmd() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Work with configuration files",
+	}
+
+	convertCmd := &cobra.Command{
+		Use:   "convert INPUT OUTPUT",
+		Short: "Convert a configuration file between JSON, YAML and TOML",
+		Long: `Convert a configuration file between JSON, YAML and TOML.
+
+The formats are chosen by the file extensions: .json, .yaml or .yml, and
+.toml. Fields missing from INPUT are written with their default values.
+
+A SOPS encrypted INPUT is decrypted with the age key in SOPS_AGE_KEY_FILE
+or ~/.config/sops/age/keys.txt and OUTPUT is encrypted for the same
+recipients. --age encrypts the credentials of OUTPUT for other recipients.`,
+		Args:          cobra.ExactArgs(2),
+		RunE:          runConfigConvert,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	convertCmd.Flags().BoolVar(&convertForce, "force", false, "Overwrite OUTPUT if it exists")
+	convertCmd.Flags().StringSliceVar(&convertAge, "age", nil, "Encrypt the credentials in OUTPUT for this age recipient (repeatable)")
+	configCmd.AddCommand(convertCmd)
+
+	migrateCmd := &cobra.Command{
+		Use:   "migrate FILE...",
+		Short: "Rewrite configuration files in the current schema version",
+		Long: fmt.Sprintf(`Rewrite configuration files in the current schema version (%d).
+
+Older files are still read by every command, which prints a warning. migrate
+lists the fields added with their defaults and the unknown fields dropped,
+writes the migrated file in place and keeps the original next to it as
+FILE.v<version>.bak.`, config.SchemaVersion),
+		Args:          cobra.MinimumNArgs(1),
+		RunE:          runConfigMigrate,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	migrateCmd.Flags().BoolVar(&migrateDryRun, "dry-run", false, "Show the changes without writing any files")
+	configCmd.AddCommand(migrateCmd)
+
+	importEnvCmd := &cobra.Command{
+		Use:   "import-env ENV_FILE OUTPUT",
+		Short: "Create a configuration file from an existing .env",
+		Long: `Create a configuration file from an existing .env, e.g. on a host
+installed with install.sh.
+
+Known variables such as TS_AUTHKEY, CODE_SERVER_PASSWORD or TZ set their
+fields, other variables are kept under extra_env and written back to .env.
+The deployment mode is taken from the header written by doom-tui, or else is
+tailscale when TS_AUTHKEY is set and local otherwise.`,
+		Args:          cobra.ExactArgs(2),
+		RunE:          runConfigImportEnv,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	importEnvCmd.Flags().BoolVar(&importEnvForce, "force", false, "Overwrite OUTPUT if it exists")
+	configCmd.AddCommand(importEnvCmd)
+
+	return configCmd
+}
+
+// loadConfig loads a configuration file and warns on the command's error
+// output when the file had to be migrated. The changes are listed by
+// config migrate, which rewrites the file, so they are not repeated on
+// every load.
+func loadConfig(cmd *cobra.Command, path string) (*config.Config, error) {
+	cfg, report, err := config.LoadAndMigrate(path)
+	if err != nil {
+		return nil, err
+	}
+	if report.Changed() {
+		w := cmd.ErrOrStderr()
+		if report.FromVersion != config.SchemaVersion {
+			fmt.Fprintf(w, "warning: %s uses config version %d, run '%s config migrate %s' to update it to %d\n",
+				path, report.FromVersion, AppName, path, config.SchemaVersion)
+		} else {
+			fmt.Fprintf(w, "warning: %s has fields that are ignored, run '%s config migrate --dry-run %s' to list them\n",
+				path, AppName, path)
+		}
+	}
+	return cfg, nil
+}
+
+// printChanges lists the changes of a migration report
+func printChanges(w io.Writer, report *config.MigrationReport) {
+	for _, change := range report.Changes {
+		fmt.Fprintf(w, "  - %s\n", change)
+	}
+}
+
+func runConfigConvert(cmd *cobra.Command, args []string) error {
+	input, output := args[0], args[1]
+
+	if !convertForce {
+		if _, err := os.Stat(output); err == nil {
+			return fmt.Errorf("%s already exists, use --force to overwrite it", output)
+		}
+	}
+
+	cfg, err := loadConfig(cmd, input)
+	if err != nil {
+		return err
+	}
+	if len(convertAge) > 0 {
+		if err := cfg.SetAgeRecipients(convertAge...); err != nil {
+			return err
+		}
+	}
+	if err := cfg.SaveToFile(output); err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "Converted %s (%s) to %s (%s)\n",
+		input, config.FormatFromPath(input), output, config.FormatFromPath(output))
+	if recipients := cfg.AgeRecipients(); len(recipients) > 0 {
+		fmt.Fprintf(out, "Credentials encrypted for %s\n", strings.Join(recipients, ", "))
+	}
+	return nil
+}
+
+func runConfigMigrate(cmd *cobra.Command, args []string) error {
+	out := cmd.OutOrStdout()
+	for _, path := range args {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read config file: %w", err)
+		}
+		cfg, report, err := config.Decode(data, config.FormatFromPath(path))
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		if !report.Changed() {
+			fmt.Fprintf(out, "%s: already at version %d\n", path, config.SchemaVersion)
+			continue
+		}
+		fmt.Fprintf(out, "%s: version %d -> %d\n", path, report.FromVersion, config.SchemaVersion)
+		printChanges(out, report)
+		if migrateDryRun {
+			continue
+		}
+
+		backup := fmt.Sprintf("%s.v%d.bak", path, report.FromVersion)
+		if err := os.WriteFile(backup, data, 0600); err != nil {
+			return fmt.Errorf("failed to write backup: %w", err)
+		}
+		if err := cfg.SaveToFile(path); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "  backup written to %s\n", backup)
+	}
+	return nil
+}
+
+func runConfigImportEnv(cmd *cobra.Command, args []string) error {
+	input, output := args[0], args[1]
+
+	if !importEnvForce {
+		if _, err := os.Stat(output); err == nil {
+			return fmt.Errorf("%s already exists, use --force to overwrite it", output)
+		}
+	}
+
+	cfg, err := config.LoadDotEnv(input)
+	if err != nil {
+		return err
+	}
+	if err := cfg.SaveToFile(output); err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "Imported %s into %s (deployment mode %s)\n", input, output, cfg.DeploymentMode)
+	if len(cfg.ExtraEnv) > 0 {
+		fmt.Fprintf(out, "Kept %d unknown variables under extra_env\n", len(cfg.ExtraEnv))
+	}
+	for _, problem := range cfg.Validate() {
+		fmt.Fprintf(cmd.ErrOrStderr(), "%s: %s\n", problem.Severity, problem)
+	}
+	return nil
+}
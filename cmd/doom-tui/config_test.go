@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/doom-coding/doom-coding/internal/config"
+)
+
+// runConfigCmd runs the config command with args and returns its output
+func runConfigCmd(args ...string) (string, error) {
+	var out bytes.Buffer
+	cmd := newConfigCmd()
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestConfigConvert(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.NewDefaultConfig()
+	cfg.Environment.Timezone = "Asia/Tokyo"
+	input := writeConfig(t, cfg)
+	output := filepath.Join(dir, "devbox.yaml")
+
+	out, err := runConfigCmd("convert", input, output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "(yaml)") {
+		t.Errorf("Output should name the target format, got %q", out)
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "timezone: Asia/Tokyo") {
+		t.Errorf("Converted file should be YAML:\n%s", data)
+	}
+
+	if _, err := runConfigCmd("convert", input, output); err == nil {
+		t.Error("Existing output should not be overwritten without --force")
+	}
+	if _, err := runConfigCmd("convert", "--force", input, output); err != nil {
+		t.Errorf("--force should overwrite the output: %v", err)
+	}
+}
+
+func TestConfigConvertEncrypt(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "keys.txt")
+	if err := os.WriteFile(keyFile, []byte(identity.String()), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SOPS_AGE_KEY_FILE", keyFile)
+
+	cfg := config.NewDefaultConfig()
+	cfg.Credentials.CodePassword = "code-secret"
+	input := writeConfig(t, cfg)
+	output := filepath.Join(dir, "devbox.yaml")
+
+	recipient := identity.Recipient().String()
+	out, err := runConfigCmd("convert", "--age", recipient, input, output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, recipient) {
+		t.Errorf("Output should name the recipient, got %q", out)
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "code-secret") || !strings.Contains(string(data), "ENC[AES256_GCM,") {
+		t.Errorf("Credentials should be encrypted:\n%s", data)
+	}
+
+	// Converting the encrypted file keeps it encrypted
+	converted := filepath.Join(dir, "devbox.json")
+	if _, err := runConfigCmd("convert", output, converted); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := config.LoadFromFile(converted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Credentials.CodePassword != "code-secret" || len(loaded.AgeRecipients()) != 1 {
+		t.Errorf("Expected the encrypted password back, got %+v %v", loaded.Credentials, loaded.AgeRecipients())
+	}
+}
+
+func TestConfigMigrate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "devbox.yaml")
+	doc := "# Office dev box\ndeployment_mode: local\nskill_level: expert\n"
+	if err := os.WriteFile(path, []byte(doc), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := runConfigCmd("migrate", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"version 1 -> 2", "skill_level: dropped", ".v1.bak"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Output should contain %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "added") {
+		t.Errorf("Fields of version 1 should not be reported as added:\n%s", out)
+	}
+
+	backup, err := os.ReadFile(path + ".v1.bak")
+	if err != nil || string(backup) != doc {
+		t.Errorf("Backup should hold the original file, got %q (%v)", backup, err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"# Office dev box", "version: 2"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Migrated file should contain %q:\n%s", want, data)
+		}
+	}
+
+	out, err = runConfigCmd("migrate", path)
+	if err != nil || !strings.Contains(out, "already at version 2") {
+		t.Errorf("Migrated file should be current, got %q (%v)", out, err)
+	}
+}
+
+func TestLoadConfigWarnsOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "devbox.yaml")
+	if err := os.WriteFile(path, []byte("deployment_mode: local\nskill_level: expert\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	cmd := newConfigCmd()
+	cmd.SetErr(&out)
+	if _, err := loadConfig(cmd, path); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(out.String(), "\n"); lines != 1 || !strings.Contains(out.String(), "config migrate") {
+		t.Errorf("Expected one warning pointing to config migrate, got:\n%s", out.String())
+	}
+}
+
+func TestConfigImportEnv(t *testing.T) {
+	dir := t.TempDir()
+	env := filepath.Join(dir, ".env")
+	data := "CODE_SERVER_PASSWORD=code-secret\nSUDO_PASSWORD=sudo\nTZ=Asia/Tokyo\nHTTP_PROXY=http://proxy:3128\n"
+	if err := os.WriteFile(env, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(dir, "devbox.yaml")
+
+	out, err := runConfigCmd("import-env", env, output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"deployment mode local", "Kept 1 unknown variables", "error: credentials.sudo_password"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Output should contain %q:\n%s", want, out)
+		}
+	}
+
+	cfg, err := config.LoadFromFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Environment.Timezone != "Asia/Tokyo" || cfg.ExtraEnv["HTTP_PROXY"] != "http://proxy:3128" {
+		t.Errorf("Imported config is missing values: %+v", cfg)
+	}
+}
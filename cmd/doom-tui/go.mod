@@ -1,11 +0,0 @@
-module github.com/doom-coding/doom-tui
-
-go 1.22
-
-require (
-	github.com/charmbracelet/bubbles v0.20.0
-	github.com/charmbracelet/bubbletea v1.2.4
-	github.com/charmbracelet/lipgloss v1.0.0
-	github.com/spf13/cobra v1.8.1
-	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
-)
\ No newline at end of file
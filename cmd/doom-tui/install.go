@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/doom-coding/doom-coding/internal/config"
+	"github.com/doom-coding/doom-coding/internal/executor"
+	"github.com/spf13/cobra"
+)
+
+// Install command flags
+var (
+	installResume bool
+	installJSON   bool
+)
+
+func newInstallCmd() *cobra.Command {
+	installCmd := &cobra.Command{
+		Use:   "install",
+		Short: "Run install.sh again with the saved configuration",
+		Long: `Run install.sh unattended with the configuration saved in .env and
+secrets/ by doom-tui or apply.
+
+Every step outcome is recorded in ` + executor.JournalFile + ` in the project root.
+With --resume, steps that completed in a previous run with the same script,
+flags and .env are skipped and only the failed tail is run again.
+
+With --json, progress is written as one JSON event per line instead.`,
+		Args:          cobra.NoArgs,
+		RunE:          runInstall,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	installCmd.Flags().BoolVar(&installResume, "resume", false, "Skip steps completed by a previous run")
+	installCmd.Flags().BoolVar(&installJSON, "json", false, "Print progress events as JSON lines")
+	return installCmd
+}
+
+func runInstall(cmd *cobra.Command, args []string) error {
+	projectRoot, err := findProjectRoot()
+	if err != nil {
+		return fmt.Errorf("could not find project root: %w", err)
+	}
+
+	envFile := filepath.Join(projectRoot, ".env")
+	if _, err := os.Stat(envFile); os.IsNotExist(err) {
+		return fmt.Errorf("no configuration in %s, run %s or %s apply first", projectRoot, AppName, AppName)
+	}
+	cfg, err := config.LoadDotEnv(envFile)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cfg, err = cfg.ResolveSecrets(ctx, config.NewSecretResolvers(projectRoot, nil))
+	if err != nil {
+		return err
+	}
+	flags, cleanup, err := installFlags(cfg)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	exec := executor.NewExecutor(projectRoot)
+	exec.Journal, err = loadInstallJournal(projectRoot, installResume)
+	if err != nil {
+		return err
+	}
+	exec.Resume = installResume
+	exec.Inputs = []string{envFile}
+
+	out := cmd.OutOrStdout()
+	events := exec.Subscribe()
+	printed := make(chan error)
+	go func() {
+		if installJSON {
+			printed <- executor.WriteJSONLines(out, events)
+			return
+		}
+		for ev := range events {
+			printEvent(out, ev)
+		}
+		printed <- nil
+	}()
+
+	err = exec.RunInstallScript(ctx, flags)
+	if printErr := <-printed; printErr != nil && err == nil {
+		err = printErr
+	}
+	if installJSON {
+		return err
+	}
+
+	fmt.Fprintln(out)
+	printStepResults(out, exec.GetResults())
+
+	if err != nil {
+		fmt.Fprintf(out, "\nFix the problem and continue with: %s install --resume\n", AppName)
+	}
+	return err
+}
+
+// loadInstallJournal loads the journal of the project, emptied unless a
+// previous run is resumed
+func loadInstallJournal(projectRoot string, resume bool) (*executor.Journal, error) {
+	journal, err := executor.LoadJournal(filepath.Join(projectRoot, executor.JournalFile))
+	if err != nil {
+		return nil, err
+	}
+	if !resume {
+		if err := journal.Reset(); err != nil {
+			return nil, err
+		}
+	}
+	return journal, nil
+}
+
+// printEvent writes a human-readable line for an executor event
+func printEvent(w io.Writer, ev executor.Event) {
+	switch ev := ev.(type) {
+	case executor.StepStarted:
+		fmt.Fprintf(w, "[%d/%d] %s: %s\n", ev.Index, ev.Total, ev.Step.Name, ev.Step.Description)
+	case executor.StepOutput:
+		if ev.Step == nil {
+			// install.sh output before its first step
+			fmt.Fprintln(w, ev.Line)
+			return
+		}
+		fmt.Fprintf(w, "[%d/%d] %s: %s\n", ev.Index, ev.Total, ev.Step.Name, ev.Line)
+	case executor.StepRetried:
+		fmt.Fprintf(w, "[%d/%d] %s: retrying (attempt %d/%d) in %s: %v\n", ev.Index, ev.Total, ev.Step.Name,
+			ev.Attempt+1, ev.MaxAttempts, ev.Backoff.Round(time.Millisecond), ev.Err)
+	case executor.StepFinished:
+		fmt.Fprintf(w, "[%d/%d] %s: %s\n", ev.Index, ev.Total, ev.Step.Name, ev.Result.Status)
+	}
+}
+
+// printStepResults writes a per-step outcome table
+func printStepResults(w io.Writer, results []executor.StepResult) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, result := range results {
+		duration := "-"
+		if result.Duration > 0 {
+			duration = result.Duration.Round(100 * time.Millisecond).String()
+		}
+		status := string(result.Status)
+		if n := len(result.Attempts); n > 1 {
+			status += fmt.Sprintf(" (%d attempts)", n)
+		}
+		line := fmt.Sprintf("  %s\t%s\t%s", result.Step.Name, status, duration)
+		if result.Error != nil {
+			line += "\t" + result.Error.Error()
+		}
+		fmt.Fprintln(tw, line)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/doom-coding/doom-coding/internal/config"
+	"github.com/doom-coding/doom-coding/internal/executor"
+	"github.com/doom-coding/doom-coding/tui/screens"
+)
+
+// programRef lets commands send messages to the running program. The
+// program is attached after the model is created, so the model holds a
+// pointer that all of its copies share.
+type programRef struct {
+	mu      sync.Mutex
+	program *tea.Program
+}
+
+// Attach sets the program that receives messages
+func (r *programRef) Attach(p *tea.Program) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.program = p
+}
+
+// Send delivers msg to the program, dropping it when none is attached
+func (r *programRef) Send(msg tea.Msg) {
+	r.mu.Lock()
+	p := r.program
+	r.mu.Unlock()
+	if p != nil {
+		p.Send(msg)
+	}
+}
+
+// installFlags returns the install.sh flags for cfg. The credentials are
+// written to a temporary file passed with --secrets-file, so that they are
+// not visible in the process list; cleanup removes it once install.sh has
+// finished.
+func installFlags(cfg *config.Config) (flags []string, cleanup func(), err error) {
+	secretsFlag, cleanup, err := cfg.InstallSecrets().WriteFile("")
+	if err != nil {
+		return nil, nil, err
+	}
+	flags = cfg.GenerateBashFlags()
+	if secretsFlag != "" {
+		flags = append(flags, secretsFlag)
+	}
+	return flags, cleanup, nil
+}
+
+// installer runs install.sh through the executor for the TUI
+type installer struct {
+	exec *executor.Executor
+}
+
+func newInstaller(projectRoot string) *installer {
+	return &installer{exec: executor.NewExecutor(projectRoot)}
+}
+
+// run executes the install script and passes every event to send. It
+// returns once the last event has been delivered.
+func (inst *installer) run(flags []string, send func(tea.Msg)) error {
+	events := inst.exec.Subscribe()
+	forwarded := make(chan struct{})
+	go func() {
+		defer close(forwarded)
+		for ev := range events {
+			send(screens.InstallEventMsg{Event: ev})
+		}
+	}()
+
+	err := inst.exec.RunInstallScript(context.Background(), flags)
+	<-forwarded
+	return err
+}
+
+// Cancel stops the executor, which terminates the running script
+func (inst *installer) Cancel() {
+	inst.exec.Cancel()
+}
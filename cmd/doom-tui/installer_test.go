@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/doom-coding/doom-coding/internal/executor"
+	"github.com/doom-coding/doom-coding/internal/runner"
+	"github.com/doom-coding/doom-coding/internal/runner/runnertest"
+	"github.com/doom-coding/doom-coding/tui/screens"
+)
+
+const installTranscript = `::doom-step name=system_check status=start index=1 total=10
+Pre-flight checks passed
+::doom-step name=system_check status=done index=1 total=10
+::doom-step name=ssh_hardening status=skip index=6 total=10
+::doom-step name=docker_install status=start index=3 total=10
+Installing Docker...
+::doom-step name=docker_install status=fail index=3 total=10
+`
+
+// fakeInstaller returns an installer whose install.sh prints transcript
+func fakeInstaller(t *testing.T, transcript string, exitCode int) *installer {
+	fake := runnertest.New()
+	fake.On("bash").Returns(transcript).Exit(exitCode)
+
+	inst := newInstaller("/test/project")
+	inst.exec.Runner = fake
+	inst.exec.LogFile = filepath.Join(t.TempDir(), "install.log")
+	// A failed step ends the run instead of being retried
+	for i := range inst.exec.Steps {
+		inst.exec.Steps[i].Retry = executor.RetryPolicy{}
+	}
+	return inst
+}
+
+// toProgress walks a model through a terminal-only setup and starts the
+// installation with inst
+func toProgress(t *testing.T, inst *installer) *Model {
+	m := advancedSetup(t, nil)
+	m.newInstaller = func() *installer { return inst }
+	press(m, "down", "down", "enter", "enter", "enter", "enter", "enter")
+	expectRoute(t, m, RouteProgress)
+	return m
+}
+
+// deliver passes the messages sent by the installer to the model
+func deliver(m *Model, msgs []tea.Msg) {
+	for _, msg := range msgs {
+		m.Update(msg)
+	}
+}
+
+func TestInstallerDrivesProgress(t *testing.T) {
+	m := toProgress(t, fakeInstaller(t, installTranscript, 1))
+
+	var msgs []tea.Msg
+	err := m.installer.run([]string{"--unattended"}, func(msg tea.Msg) { msgs = append(msgs, msg) })
+	if err == nil {
+		t.Fatal("Expected the failed install to return an error")
+	}
+	deliver(m, msgs)
+	m.Update(screens.InstallDoneMsg{Error: err})
+
+	view := m.View()
+	for _, want := range []string{"Installation Failed", "Pre-flight checks passed", "Installing Docker..."} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Progress view should contain %q", want)
+		}
+	}
+	if m.state.InstallErr != err {
+		t.Errorf("Install error should be shared with the results screen, got %v", m.state.InstallErr)
+	}
+
+	press(m, "enter")
+	expectRoute(t, m, RouteResults)
+	if !strings.Contains(m.View(), "Installation Failed") {
+		t.Error("Results should report the failure")
+	}
+}
+
+// blockingRunner starts a step and blocks until its context is cancelled
+type blockingRunner struct {
+	started chan struct{}
+}
+
+func (r blockingRunner) Run(ctx context.Context, cmd runner.Command) error {
+	io.WriteString(cmd.Stdout, "::doom-step name=docker_install status=start index=3 total=10\n")
+	close(r.started)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (r blockingRunner) LookPath(file string) (string, error) {
+	return "/usr/bin/" + file, nil
+}
+
+func TestCancelInstallation(t *testing.T) {
+	started := make(chan struct{})
+	inst := newInstaller("/test/project")
+	inst.exec.Runner = blockingRunner{started: started}
+	inst.exec.LogFile = filepath.Join(t.TempDir(), "install.log")
+	m := toProgress(t, inst)
+
+	var mu sync.Mutex
+	var msgs []tea.Msg
+	done := make(chan error)
+	go func() {
+		done <- inst.run(nil, func(msg tea.Msg) {
+			mu.Lock()
+			defer mu.Unlock()
+			msgs = append(msgs, msg)
+		})
+	}()
+	<-started
+
+	press(m, "c")
+	if !strings.Contains(m.View(), "Cancelling") {
+		t.Error("Progress view should show that the installer is stopping")
+	}
+
+	var err error
+	select {
+	case err = <-done:
+		if err == nil {
+			t.Error("Cancelled install should return an error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Cancel key did not stop the running script")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	deliver(m, msgs)
+	m.Update(screens.InstallDoneMsg{Error: err})
+	expectRoute(t, m, RouteProgress)
+	if !strings.Contains(m.View(), "✗ Docker Setup") {
+		t.Error("Cancelled step should be shown as failed")
+	}
+}
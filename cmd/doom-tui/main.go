@@ -1,13 +1,16 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/doom-coding/doom-coding/internal/config"
 	"github.com/spf13/cobra"
 )
 
@@ -33,15 +36,26 @@ var (
 	skipHardening  bool
 	skipSecrets    bool
 	verbose        bool
+
+	statusJSON     bool
+	statusWatch    bool
+	statusInterval int
 )
 
 func main() {
+	os.Exit(execute(newRootCmd(), os.Stderr))
+}
+
+// newRootCmd creates the command tree. Errors are printed by execute, so
+// cobra is told not to print them as well.
+func newRootCmd() *cobra.Command {
 	rootCmd := &cobra.Command{
-		Use:     AppName,
-		Short:   "Doom Coding - Interactive TUI Setup",
-		Long:    `An interactive TUI for setting up the Doom Coding development environment.`,
-		Version: Version,
-		RunE:    runTUI,
+		Use:           AppName,
+		Short:         "Doom Coding - Interactive TUI Setup",
+		Long:          `An interactive TUI for setting up the Doom Coding development environment.`,
+		Version:       Version,
+		RunE:          runTUI,
+		SilenceErrors: true,
 	}
 
 	// CLI flags for automation
@@ -50,7 +64,7 @@ func main() {
 	rootCmd.Flags().StringVar(&codePassword, "code-password", "", "code-server password")
 	rootCmd.Flags().StringVar(&anthropicKey, "anthropic-key", "", "Anthropic API key")
 	rootCmd.Flags().StringVar(&sudoPassword, "sudo-password", "", "Container sudo password")
-	rootCmd.Flags().StringVar(&configFile, "config", "", "Load configuration from JSON file")
+	rootCmd.Flags().StringVar(&configFile, "config", "", "Load a JSON, YAML or TOML config file into the TUI")
 	rootCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be executed without running")
 	rootCmd.Flags().BoolVar(&showCommands, "show-commands", false, "Show equivalent bash commands")
 	rootCmd.Flags().BoolVar(&skipDocker, "skip-docker", false, "Skip Docker installation")
@@ -68,23 +82,54 @@ func main() {
 	}
 	rootCmd.AddCommand(cliCmd)
 
+	rootCmd.AddCommand(newInstallCmd())
+	rootCmd.AddCommand(newApplyCmd())
+	rootCmd.AddCommand(newConfigCmd())
+	rootCmd.AddCommand(newRotateCmd())
+
 	// Status subcommand
 	statusCmd := &cobra.Command{
 		Use:   "status",
 		Short: "Check installation status",
-		RunE:  runStatus,
+		Long: `Check the state of the doom-coding services and health probes.
+
+Exit codes: 0 healthy, 1 degraded, 2 down, 3 unknown.`,
+		RunE:          runStatus,
+		SilenceUsage:  true,
+		SilenceErrors: true,
 	}
+	statusCmd.Flags().BoolVar(&statusJSON, "json", false, "Print the versioned health document as JSON")
+	statusCmd.Flags().BoolVar(&statusWatch, "watch", false, "Keep refreshing and print state changes")
+	statusCmd.Flags().IntVar(&statusInterval, "interval", 5, "Refresh interval in seconds for --watch")
 	rootCmd.AddCommand(statusCmd)
 
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+	return rootCmd
+}
+
+// execute runs the command, prints its error to stderr and returns the
+// process exit code
+func execute(rootCmd *cobra.Command, stderr io.Writer) int {
+	err := rootCmd.Execute()
+	if err == nil {
+		return 0
+	}
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		if exitErr.err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", exitErr.err)
+		}
+		return exitErr.code
 	}
+	fmt.Fprintf(stderr, "Error: %v\n", err)
+	return 1
 }
 
 func runTUI(cmd *cobra.Command, args []string) error {
 	// If unattended mode, run CLI instead
 	if unattended {
+		if configFile != "" {
+			return fmt.Errorf("--config is not read in unattended mode, use '%s apply -f %s'", AppName, configFile)
+		}
 		return runCLI(cmd, args)
 	}
 
@@ -96,9 +141,17 @@ func runTUI(cmd *cobra.Command, args []string) error {
 
 	// Initialize the TUI model
 	model := NewModel(projectRoot)
+	if configFile != "" {
+		cfg, err := loadConfig(cmd, configFile)
+		if err != nil {
+			return err
+		}
+		model.useConfig(cfg)
+	}
 
 	// Run the TUI
 	p := tea.NewProgram(model, tea.WithAltScreen())
+	model.program.Attach(p)
 	_, err = p.Run()
 	return err
 }
@@ -115,15 +168,6 @@ func runCLI(cmd *cobra.Command, args []string) error {
 	if unattended {
 		installArgs = append(installArgs, "--unattended")
 	}
-	if tailscaleKey != "" {
-		installArgs = append(installArgs, fmt.Sprintf("--tailscale-key=%s", tailscaleKey))
-	}
-	if codePassword != "" {
-		installArgs = append(installArgs, fmt.Sprintf("--code-password=%s", codePassword))
-	}
-	if anthropicKey != "" {
-		installArgs = append(installArgs, fmt.Sprintf("--anthropic-key=%s", anthropicKey))
-	}
 	if skipDocker {
 		installArgs = append(installArgs, "--skip-docker")
 	}
@@ -146,31 +190,46 @@ func runCLI(cmd *cobra.Command, args []string) error {
 		installArgs = append(installArgs, "--verbose")
 	}
 
+	// Credentials go to install.sh in a file, not on the command line
+	cfg := &config.Config{Credentials: config.Credentials{
+		TailscaleKey: tailscaleKey,
+		CodePassword: codePassword,
+		SudoPassword: sudoPassword,
+		AnthropicKey: anthropicKey,
+	}}
+	secrets := cfg.InstallSecrets()
+
 	if showCommands {
+		names := secrets.Names()
+		if len(names) > 0 {
+			installArgs = append(installArgs, config.SecretsFileFlag+"=SECRETS_FILE")
+		}
 		fmt.Println("Equivalent bash command:")
 		fmt.Println(strings.Join(installArgs, " "))
+		if len(names) > 0 {
+			fmt.Printf("SECRETS_FILE: a mode 0600 file of NUL terminated KEY=value records for %s\n", strings.Join(names, ", "))
+		}
 		return nil
 	}
 
-	// Execute install script
-	execCmd := exec.Command("bash", installArgs...)
-	execCmd.Stdout = os.Stdout
-	execCmd.Stderr = os.Stderr
-	execCmd.Stdin = os.Stdin
-
-	return execCmd.Run()
-}
-
-func runStatus(cmd *cobra.Command, args []string) error {
-	projectRoot, err := findProjectRoot()
+	cfg, err = cfg.ResolveSecrets(cmd.Context(), config.NewSecretResolvers(projectRoot, nil))
 	if err != nil {
-		return fmt.Errorf("could not find project root: %w", err)
+		return err
+	}
+	secretsFlag, cleanup, err := cfg.InstallSecrets().WriteFile("")
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	if secretsFlag != "" {
+		installArgs = append(installArgs, secretsFlag)
 	}
 
-	healthCheck := filepath.Join(projectRoot, "scripts", "health-check.sh")
-	execCmd := exec.Command("bash", healthCheck)
+	// Execute install script
+	execCmd := exec.Command("bash", installArgs...)
 	execCmd.Stdout = os.Stdout
 	execCmd.Stderr = os.Stderr
+	execCmd.Stdin = os.Stdin
 
 	return execCmd.Run()
 }
@@ -1,9 +1,13 @@
 package main
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/spf13/cobra"
 )
 
 func TestVersion(t *testing.T) {
@@ -293,3 +297,33 @@ func BenchmarkFindProjectRoot(b *testing.B) {
 		findProjectRoot()
 	}
 }
+
+func TestCommandErrorsPrintedOnce(t *testing.T) {
+	var paths [][]string
+	var walk func(cmd *cobra.Command, path []string)
+	walk = func(cmd *cobra.Command, path []string) {
+		if cmd.Runnable() {
+			paths = append(paths, path)
+		}
+		for _, sub := range cmd.Commands() {
+			walk(sub, append(append([]string(nil), path...), sub.Name()))
+		}
+	}
+	walk(newRootCmd(), nil)
+
+	for _, path := range paths {
+		var stdout, stderr bytes.Buffer
+		root := newRootCmd()
+		root.SetArgs(append(append([]string(nil), path...), "--no-such-flag"))
+		root.SetOut(&stdout)
+		root.SetErr(&stderr)
+
+		name := strings.Join(append([]string{AppName}, path...), " ")
+		if code := execute(root, &stderr); code == 0 {
+			t.Errorf("%s: expected a non-zero exit code", name)
+		}
+		if n := strings.Count(stderr.String(), "Error:"); n != 1 {
+			t.Errorf("%s: expected the error once on stderr, got %d times:\n%s", name, n, stderr.String())
+		}
+	}
+}
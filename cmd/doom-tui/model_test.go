@@ -0,0 +1,165 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/doom-coding/doom-coding/internal/config"
+	"github.com/doom-coding/doom-coding/internal/system"
+	"github.com/doom-coding/doom-coding/tui/screens"
+)
+
+// press sends keys to the model
+func press(m *Model, keys ...string) {
+	for _, key := range keys {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+		switch key {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "down":
+			msg = tea.KeyMsg{Type: tea.KeyDown}
+		case "tab":
+			msg = tea.KeyMsg{Type: tea.KeyTab}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		case " ":
+			msg = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}
+		}
+		m.Update(msg)
+	}
+}
+
+// expectRoute fails the test unless the model shows the named route
+func expectRoute(t *testing.T, m *Model, name string) {
+	t.Helper()
+	if got := m.router.Current(); got != name {
+		t.Fatalf("Expected the %s screen, got %s", name, got)
+	}
+}
+
+// advancedSetup returns a model on the deployment screen, reached by
+// skipping the skill questions on a system with a TUN device
+func advancedSetup(t *testing.T, cfg *config.Config) *Model {
+	t.Helper()
+	m := NewModel(t.TempDir())
+	if cfg != nil {
+		m.useConfig(cfg)
+	}
+	m.Init()
+
+	press(m, "s")
+	expectRoute(t, m, RouteDetection)
+	m.Update(screens.DetectionMsg{Info: &system.SystemInfo{HasTUN: true}})
+	press(m, "enter")
+	expectRoute(t, m, RouteDeployment)
+	return m
+}
+
+func TestTUIConfigMatchesLoadedConfig(t *testing.T) {
+	m := advancedSetup(t, nil)
+	press(m, "down", "enter") // Docker + Local Network
+	expectRoute(t, m, RouteComponents)
+
+	press(m, "down", "down", "down", "down", " ", "enter") // Deselect secrets management
+	expectRoute(t, m, RouteConfig)
+
+	press(m, "code-pass1", "tab", "sudo-pass1", "tab", "sk-ant-test", "tab", "tab", "enter")
+	expectRoute(t, m, RoutePreview)
+
+	cfg := m.state.Config
+	if cfg.DeploymentMode != config.ModeLocal || cfg.Components.SecretsManager {
+		t.Errorf("Selections were not stored in the config: %+v", cfg)
+	}
+	if cfg.Credentials.CodePassword != "code-pass1" || cfg.Credentials.AnthropicKey != "sk-ant-test" {
+		t.Errorf("Credentials were not stored in the config: %+v", cfg.Credentials)
+	}
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := cfg.SaveToFile(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := config.LoadFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := cfg.GenerateEnvFile()
+	want, _ := loaded.GenerateEnvFile()
+	if got != want {
+		t.Errorf("TUI and loaded config produce different .env files:\n%s\n---\n%s", got, want)
+	}
+}
+
+func TestUseConfigPrefillsModel(t *testing.T) {
+	cfg := config.NewDefaultConfig()
+	cfg.Environment.Timezone = "Asia/Tokyo"
+
+	m := advancedSetup(t, cfg)
+	press(m, "down", "down", "enter", "enter") // Terminal Tools Only
+	expectRoute(t, m, RouteConfig)
+	if !strings.Contains(m.View(), "Asia/Tokyo") {
+		t.Error("Inputs should be filled from the loaded config")
+	}
+
+	press(m, "enter", "enter")
+	expectRoute(t, m, RoutePreview)
+	if m.state.Config.Components.Docker {
+		t.Error("Docker should be unavailable in terminal-only mode")
+	}
+	if !strings.Contains(m.View(), "--skip-docker") {
+		t.Error("Flags should follow the config")
+	}
+}
+
+func TestInvalidConfigurationBlocksPreview(t *testing.T) {
+	cfg := config.NewDefaultConfig()
+	cfg.Environment.WorkspacePath = ""
+
+	m := advancedSetup(t, cfg)
+	press(m, "down", "down", "enter", "enter", "enter", "enter")
+
+	expectRoute(t, m, RouteConfig)
+	if !strings.Contains(m.View(), "workspace path cannot be empty") {
+		t.Error("Configuration view should list the validation errors")
+	}
+}
+
+func TestPreviewMasksCredentials(t *testing.T) {
+	m := advancedSetup(t, nil)
+	press(m, "enter", "enter") // Docker + Tailscale
+	press(m, "tskey-auth-secret", "tab", "code-pass1", "tab", "sudo-pass1", "tab", "tab", "tab", "enter")
+	expectRoute(t, m, RoutePreview)
+
+	view := m.View()
+	if strings.Contains(view, "tskey-auth-secret") || strings.Contains(view, "code-pass1") {
+		t.Error("Preview should not show credentials")
+	}
+	if !strings.Contains(view, "--secrets-file=") {
+		t.Error("Preview should show that credentials are passed in a secrets file")
+	}
+}
+
+func TestGuidedSetupSkipsModeScreens(t *testing.T) {
+	m := NewModel(t.TempDir())
+	m.Init()
+
+	press(m, "enter")
+	expectRoute(t, m, RouteSkill)
+	press(m, "enter", "enter", "enter") // Beginner answers
+	expectRoute(t, m, RouteUseCase)
+	press(m, "down", "enter") // Home Lab
+	expectRoute(t, m, RouteDetection)
+
+	m.Update(screens.DetectionMsg{Info: &system.SystemInfo{}})
+	press(m, "enter")
+	expectRoute(t, m, RouteConfig)
+	if m.state.Config.DeploymentMode != config.ModeLocal {
+		t.Errorf("Use case should set the deployment mode, got %q", m.state.Config.DeploymentMode)
+	}
+
+	press(m, "esc")
+	expectRoute(t, m, RouteDetection)
+	press(m, "esc")
+	expectRoute(t, m, RouteUseCase)
+}
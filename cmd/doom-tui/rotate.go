@@ -0,0 +1,335 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/doom-coding/doom-coding/internal/config"
+	"github.com/doom-coding/doom-coding/internal/secrets"
+	"github.com/doom-coding/doom-coding/internal/service"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// Rotate command flags
+var (
+	rotateFile   string
+	rotatePrompt bool
+)
+
+// rotateCredential is a credential doom-tui rotate can change
+type rotateCredential struct {
+	field    string   // Document path, e.g. "credentials.code_password"
+	secret   string   // Compose secret the services read it from
+	services []string // Compose services to recreate, the first one decides whether they run
+	generate bool     // Passwords are generated, keys come from their issuer
+	value    func(c *config.Config) *string
+}
+
+// rotateCredentials are the credentials by command line name
+var rotateCredentials = map[string]rotateCredential{
+	"code-password": {
+		field: "credentials.code_password", secret: secrets.CodeServerPassword,
+		services: []string{"code-server"}, generate: true,
+		value: func(c *config.Config) *string { return &c.Credentials.CodePassword },
+	},
+	"sudo-password": {
+		field: "credentials.sudo_password", secret: secrets.SudoPassword,
+		services: []string{"code-server"}, generate: true,
+		value: func(c *config.Config) *string { return &c.Credentials.SudoPassword },
+	},
+	// code-server and claude share the network of the tailscale container,
+	// which they lose when it is recreated
+	"tailscale-key": {
+		field: "credentials.tailscale_key", secret: secrets.TailscaleAuthKey,
+		services: []string{"tailscale", "code-server", "claude"},
+		value:    func(c *config.Config) *string { return &c.Credentials.TailscaleKey },
+	},
+	"anthropic-key": {
+		field: "credentials.anthropic_key", secret: secrets.AnthropicAPIKey,
+		services: []string{"claude"},
+		value:    func(c *config.Config) *string { return &c.Credentials.AnthropicKey },
+	},
+}
+
+// generatedPasswordLength is the length of generated passwords
+const generatedPasswordLength = 24
+
+func newRotateCmd() *cobra.Command {
+	rotateCmd := &cobra.Command{
+		Use:   "rotate " + strings.Join(rotateCredentialNames(), "|"),
+		Short: "Change a credential and restart the services using it",
+		Long: `Change a credential and restart only the services using it.
+
+Passwords are generated, or read like keys with --prompt. Keys are read
+from the terminal without echo, or as a line from stdin when it is not a
+terminal. The new value is written to the compose secrets in secrets/ and
+the services reading it are recreated. When one of them does not come up
+healthy, the previous value is restored and the services are recreated
+again.
+
+Credentials are read from .env and secrets/ in the project root, or from
+the configuration file given with -f, which is updated once the services
+are healthy. Credentials that are references such as env:NAME or
+keyring:SERVICE/ACCOUNT are changed where they point to instead.`,
+		Args:          cobra.ExactArgs(1),
+		ValidArgs:     rotateCredentialNames(),
+		RunE:          runRotate,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	flags := rotateCmd.Flags()
+	flags.StringVarP(&rotateFile, "file", "f", "", "Configuration file to read and update instead of .env")
+	flags.BoolVar(&rotatePrompt, "prompt", false, "Read passwords instead of generating them")
+	return rotateCmd
+}
+
+// rotateCredentialNames returns the command line names of the credentials
+func rotateCredentialNames() []string {
+	names := make([]string, 0, len(rotateCredentials))
+	for name := range rotateCredentials {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func runRotate(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	cred, ok := rotateCredentials[name]
+	if !ok {
+		return fmt.Errorf("unknown credential %q, expected one of %s", name, strings.Join(rotateCredentialNames(), ", "))
+	}
+
+	projectRoot, err := findProjectRoot()
+	if err != nil {
+		return fmt.Errorf("could not find project root: %w", err)
+	}
+	var cfg *config.Config
+	if rotateFile != "" {
+		cfg, err = loadConfig(cmd, rotateFile)
+	} else {
+		cfg, err = config.LoadDotEnv(filepath.Join(projectRoot, ".env"))
+	}
+	if err != nil {
+		return err
+	}
+
+	if scheme, _, ok := config.ParseSecretRef(*cred.value(cfg)); ok && scheme != config.SecretLiteral {
+		return fmt.Errorf("%s is a %s: reference, change the secret it points to and run apply", cred.field, scheme)
+	}
+	if _, ok := cfg.ComposeSecrets()[cred.secret]; !ok {
+		return fmt.Errorf("the %s deployment mode does not use the %s", cfg.DeploymentMode, name)
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	prev, err := cfg.ResolveSecrets(ctx, config.NewSecretResolvers(projectRoot, nil))
+	if err != nil {
+		return err
+	}
+
+	value, generated, err := newCredentialValue(ctx, cmd, name, cred)
+	if err != nil {
+		return err
+	}
+	next := *prev
+	*cred.value(&next) = value
+	if problems := next.Validate().ForField(cred.field).Errors(); len(problems) > 0 {
+		return fmt.Errorf("invalid %s: %s", name, problems[0].Message)
+	}
+
+	out := cmd.OutOrStdout()
+	r := &rotator{projectRoot: projectRoot, out: out}
+	if lm := newRestarter(ctx, service.NewManager(projectRoot), projectRoot, cfg, cred.services); lm != nil {
+		r.restart = lm.RestartServices
+	}
+	if err := r.rotate(ctx, name, cred, prev, &next); err != nil {
+		return err
+	}
+
+	if rotateFile != "" {
+		*cred.value(cfg) = config.QuoteSecret(value)
+		if err := cfg.SaveToFile(rotateFile); err != nil {
+			return fmt.Errorf("the services use the new %s, but %s was not updated: %w", name, rotateFile, err)
+		}
+		fmt.Fprintf(out, "Updated %s\n", rotateFile)
+	}
+	if generated {
+		fmt.Fprintf(out, "New %s: %s\n", name, value)
+	}
+	return nil
+}
+
+// rotator switches an installation to a new credential
+type rotator struct {
+	projectRoot string
+
+	// restart recreates compose services and reports their health, nil
+	// when the services are not running
+	restart func(ctx context.Context, services ...string) ([]service.ServiceStatus, error)
+
+	out io.Writer
+}
+
+// rotate writes the secrets of next and recreates the services of cred.
+// When they do not come up healthy, the secrets of prev are restored and
+// the services recreated again.
+func (r *rotator) rotate(ctx context.Context, name string, cred rotateCredential, prev, next *config.Config) error {
+	if _, err := next.WriteSecrets(r.projectRoot); err != nil {
+		return err
+	}
+	if r.restart == nil {
+		fmt.Fprintf(r.out, "Services are not running, the new %s is used when they start\n", name)
+		return nil
+	}
+
+	fmt.Fprintf(r.out, "Recreating %s...\n", strings.Join(cred.services, ", "))
+	failure := r.restartHealthy(ctx, cred.services)
+	if failure == nil {
+		fmt.Fprintf(r.out, "Rotated %s\n", name)
+		return nil
+	}
+
+	// Roll back even when interrupted, the services would be left without
+	// a working credential otherwise
+	ctx = context.WithoutCancel(ctx)
+	fmt.Fprintf(r.out, "%v, restoring the previous %s\n", failure, name)
+	if _, err := prev.WriteSecrets(r.projectRoot); err != nil {
+		return fmt.Errorf("%w; restoring the previous %s failed: %v", failure, name, err)
+	}
+	if err := r.restartHealthy(ctx, cred.services); err != nil {
+		return fmt.Errorf("%w; the previous %s was restored, but %v", failure, name, err)
+	}
+	return fmt.Errorf("%w; rolled back to the previous %s", failure, name)
+}
+
+// restartHealthy recreates services and returns an error unless all of
+// them come up
+func (r *rotator) restartHealthy(ctx context.Context, services []string) error {
+	statuses, err := r.restart(ctx, services...)
+	if err != nil {
+		return err
+	}
+	var failed []string
+	for _, status := range statuses {
+		if status.State != service.StateHealthy && status.State != service.StateRunning {
+			failed = append(failed, fmt.Sprintf("%s is %s", status.Name, status.State))
+		}
+	}
+	if len(failed) > 0 {
+		return errors.New(strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// newRestarter returns the lifecycle manager that recreates services, or
+// nil when the container of the first one does not exist. The compose file
+// is the one the container was created from, as install.sh picks it by
+// host.
+func newRestarter(ctx context.Context, manager *service.Manager, projectRoot string, cfg *config.Config, services []string) *service.LifecycleManager {
+	// All compose files name the container of a service doom-<service>
+	composeFile, ok := manager.ComposeFile(ctx, "doom-"+services[0])
+	if !ok {
+		if !manager.ContainerExists(ctx, "doom-"+services[0]) {
+			return nil
+		}
+		composeFile = cfg.GetComposeFile()
+	}
+	return service.NewLifecycleManager(manager, projectRoot, composeFile)
+}
+
+// newCredentialValue generates a password or reads the new value of a
+// credential
+func newCredentialValue(ctx context.Context, cmd *cobra.Command, name string, cred rotateCredential) (value string, generated bool, err error) {
+	if cred.generate && !rotatePrompt {
+		value, err := generatePassword(generatedPasswordLength)
+		return value, true, err
+	}
+	value, err = readSecret(ctx, cmd.InOrStdin(), cmd.ErrOrStderr(), "New "+name+": ")
+	return value, false, err
+}
+
+// passwordAlphabet is used for generated passwords, which then need no
+// quoting anywhere
+const passwordAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+// generatePassword returns a random password of n characters
+func generatePassword(n int) (string, error) {
+	max := big.NewInt(int64(len(passwordAlphabet)))
+	b := make([]byte, n)
+	for i := range b {
+		j, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate password: %w", err)
+		}
+		b[i] = passwordAlphabet[j.Int64()]
+	}
+	return string(b), nil
+}
+
+// readSecret reads a line from in. When in is a terminal, label is written
+// to prompt and the line is read without echo.
+func readSecret(ctx context.Context, in io.Reader, prompt io.Writer, label string) (string, error) {
+	var line string
+	if f, ok := in.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		fmt.Fprint(prompt, label)
+		b, err := readPassword(ctx, int(f.Fd()))
+		fmt.Fprintln(prompt) // The line break was not echoed either
+		if err != nil {
+			return "", fmt.Errorf("failed to read the new value: %w", err)
+		}
+		line = string(b)
+	} else {
+		var err error
+		line, err = bufio.NewReader(in).ReadString('\n')
+		if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+			return "", fmt.Errorf("failed to read the new value: %w", err)
+		}
+	}
+
+	value := strings.TrimRight(line, "\r\n")
+	if value == "" {
+		return "", errors.New("no value given")
+	}
+	return value, nil
+}
+
+// readPassword reads a line from the terminal fd without echo. The
+// terminal state is restored when ctx is cancelled while reading, as
+// runRotate handles the interrupt signal instead of exiting.
+func readPassword(ctx context.Context, fd int) ([]byte, error) {
+	state, err := term.GetState(fd)
+	if err != nil {
+		return nil, err
+	}
+	defer term.Restore(fd, state)
+
+	type read struct {
+		line []byte
+		err  error
+	}
+	done := make(chan read, 1)
+	go func() {
+		line, err := term.ReadPassword(fd)
+		done <- read{line, err}
+	}()
+	select {
+	case r := <-done:
+		return r.line, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// openTerminal returns the master and the terminal side of a new pseudo
+// terminal
+func openTerminal(t *testing.T) (master, tty *os.File) {
+	t.Helper()
+	fd, err := unix.Open("/dev/ptmx", unix.O_RDWR|unix.O_NOCTTY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		t.Skipf("No pseudo terminals: %v", err)
+	}
+	master = os.NewFile(uintptr(fd), "/dev/ptmx")
+	t.Cleanup(func() { master.Close() })
+	if err := unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil {
+		t.Skipf("Failed to unlock pseudo terminal: %v", err)
+	}
+	n, err := unix.IoctlGetInt(fd, unix.TIOCGPTN)
+	if err != nil {
+		t.Skipf("Failed to get pseudo terminal number: %v", err)
+	}
+	tty, err = os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("Failed to open pseudo terminal: %v", err)
+	}
+	t.Cleanup(func() { tty.Close() })
+	return master, tty
+}
+
+// echoing reports whether the terminal echoes input
+func echoing(t *testing.T, tty *os.File) bool {
+	t.Helper()
+	termios, err := unix.IoctlGetTermios(int(tty.Fd()), unix.TCGETS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return termios.Lflag&unix.ECHO != 0
+}
+
+func TestReadSecretTerminal(t *testing.T) {
+	master, tty := openTerminal(t)
+	go io.Copy(io.Discard, master)
+	master.Write([]byte("sk-ant-new\r"))
+
+	value, err := readSecret(context.Background(), tty, io.Discard, "New anthropic-key: ")
+	if err != nil || value != "sk-ant-new" {
+		t.Errorf("readSecret = %q, %v", value, err)
+	}
+	if !echoing(t, tty) {
+		t.Error("Echo should be turned back on after reading")
+	}
+}
+
+func TestReadSecretTerminalInterrupted(t *testing.T) {
+	_, tty := openTerminal(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	var prompt strings.Builder
+	if _, err := readSecret(ctx, tty, &prompt, "New anthropic-key: "); err == nil {
+		t.Error("Expected an error for an interrupted prompt")
+	}
+	if !echoing(t, tty) {
+		t.Error("Echo should be turned back on when the prompt is interrupted")
+	}
+	if prompt.String() != "New anthropic-key: \n" {
+		t.Errorf("Unexpected prompt %q", prompt.String())
+	}
+}
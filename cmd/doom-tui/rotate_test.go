@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/doom-coding/doom-coding/internal/config"
+	"github.com/doom-coding/doom-coding/internal/runner/runnertest"
+	"github.com/doom-coding/doom-coding/internal/secrets"
+	"github.com/doom-coding/doom-coding/internal/service"
+)
+
+// fakeRestart records the services it recreates and reports them in the
+// states returned by next
+type fakeRestart struct {
+	calls [][]string
+	next  func(call int) service.ServiceState
+}
+
+func (f *fakeRestart) restart(_ context.Context, services ...string) ([]service.ServiceStatus, error) {
+	f.calls = append(f.calls, services)
+	var statuses []service.ServiceStatus
+	for _, name := range services {
+		statuses = append(statuses, service.ServiceStatus{Name: name, State: f.next(len(f.calls))})
+	}
+	return statuses, nil
+}
+
+// rotateConfigs returns the configuration before and after rotating the
+// code-server password
+func rotateConfigs() (prev, next *config.Config) {
+	prev = config.NewDefaultConfig()
+	prev.DeploymentMode = config.ModeLocal
+	prev.Credentials.CodePassword = "Old-Password-1"
+	prev.Credentials.SudoPassword = "Sudo-Password-1"
+	copied := *prev
+	next = &copied
+	next.Credentials.CodePassword = "New-Password-2"
+	return prev, next
+}
+
+func readSecretFile(t *testing.T, root, name string) string {
+	t.Helper()
+	values, err := secrets.NewManager(root).Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return values[name]
+}
+
+func TestRotate(t *testing.T) {
+	root := t.TempDir()
+	prev, next := rotateConfigs()
+	fake := &fakeRestart{next: func(int) service.ServiceState { return service.StateHealthy }}
+	r := &rotator{projectRoot: root, restart: fake.restart, out: io.Discard}
+
+	if err := r.rotate(context.Background(), "code-password", rotateCredentials["code-password"], prev, next); err != nil {
+		t.Fatal(err)
+	}
+	if got := readSecretFile(t, root, secrets.CodeServerPassword); got != "New-Password-2" {
+		t.Errorf("code_server_password = %q, want the new password", got)
+	}
+	if len(fake.calls) != 1 || strings.Join(fake.calls[0], ",") != "code-server" {
+		t.Errorf("Only code-server should be recreated, got %v", fake.calls)
+	}
+}
+
+func TestRotateRollsBack(t *testing.T) {
+	root := t.TempDir()
+	prev, next := rotateConfigs()
+	fake := &fakeRestart{next: func(call int) service.ServiceState {
+		if call == 1 {
+			return service.StateUnhealthy
+		}
+		return service.StateHealthy
+	}}
+	r := &rotator{projectRoot: root, restart: fake.restart, out: io.Discard}
+
+	err := r.rotate(context.Background(), "code-password", rotateCredentials["code-password"], prev, next)
+	if err == nil || !strings.Contains(err.Error(), "code-server is unhealthy") || !strings.Contains(err.Error(), "rolled back") {
+		t.Errorf("Expected a rollback error, got %v", err)
+	}
+	if got := readSecretFile(t, root, secrets.CodeServerPassword); got != "Old-Password-1" {
+		t.Errorf("code_server_password = %q, want the previous password", got)
+	}
+	if len(fake.calls) != 2 {
+		t.Errorf("The services should be recreated again with the previous password, got %v", fake.calls)
+	}
+}
+
+// newTestRestarter returns the lifecycle manager of rotate for a project
+// whose docker commands are answered by fake
+func newTestRestarter(t *testing.T, fake *runnertest.Fake, root string, cfg *config.Config) *service.LifecycleManager {
+	t.Helper()
+	manager := service.NewManager(root)
+	manager.SetRunner(fake)
+	lm := newRestarter(context.Background(), manager, root, cfg, rotateCredentials["code-password"].services)
+	if lm != nil {
+		lm.SetPollInterval(time.Millisecond)
+		lm.SetTimeout(5 * time.Second)
+	}
+	return lm
+}
+
+func TestRotateRollsBackContainers(t *testing.T) {
+	root := t.TempDir()
+	prev, next := rotateConfigs()
+	fake := runnertest.New()
+	composePath := filepath.Join(root, prev.GetComposeFile())
+	fake.On("docker", "inspect", "--format", `{{index .Config.Labels "com.docker.compose.project.config_files"}}`, "doom-code-server").Returns(composePath + "\n")
+	fake.On("docker", "compose", "-f", composePath, "up", "-d", "--force-recreate", "--no-deps", "code-server").Returns("")
+	// Exits with the new password, comes up again with the previous one
+	health := []string{"docker", "inspect", "--format", "{{.State.Status}},{{.State.Health.Status}}", "doom-code-server"}
+	fake.On(health...).Returns("exited,\n").Once()
+	fake.On(health...).Returns("running,healthy\n")
+
+	lm := newTestRestarter(t, fake, root, prev)
+	if lm == nil {
+		t.Fatal("The services of a running container should be recreated")
+	}
+	r := &rotator{projectRoot: root, restart: lm.RestartServices, out: io.Discard}
+	err := r.rotate(context.Background(), "code-password", rotateCredentials["code-password"], prev, next)
+	if err == nil || !strings.Contains(err.Error(), "code-server is stopped") || !strings.Contains(err.Error(), "rolled back") {
+		t.Errorf("Expected a rollback error, got %v", err)
+	}
+	if got := readSecretFile(t, root, secrets.CodeServerPassword); got != "Old-Password-1" {
+		t.Errorf("code_server_password = %q, want the previous password", got)
+	}
+	if n := fake.Count("docker", "compose", "-f", composePath, "up"); n != 2 {
+		t.Errorf("code-server should be recreated twice, got %d", n)
+	}
+}
+
+func TestRotateContainerMissing(t *testing.T) {
+	fake := runnertest.New()
+	fake.On("docker", "inspect").Exit(1)
+	prev, _ := rotateConfigs()
+	if lm := newTestRestarter(t, fake, t.TempDir(), prev); lm != nil {
+		t.Error("Nothing should be recreated without a code-server container")
+	}
+	if fake.Count("docker", "inspect", "--format", "{{.Id}}", "doom-code-server") != 1 {
+		t.Error("The container should be looked up through the manager's runner")
+	}
+}
+
+func TestRotateNotRunning(t *testing.T) {
+	root := t.TempDir()
+	prev, next := rotateConfigs()
+	r := &rotator{projectRoot: root, out: io.Discard}
+
+	if err := r.rotate(context.Background(), "code-password", rotateCredentials["code-password"], prev, next); err != nil {
+		t.Fatal(err)
+	}
+	if got := readSecretFile(t, root, secrets.CodeServerPassword); got != "New-Password-2" {
+		t.Errorf("code_server_password = %q, want the new password", got)
+	}
+}
+
+func TestRotateRejects(t *testing.T) {
+	tests := map[string]struct {
+		credential string
+		edit       func(c *config.Config)
+		want       string
+	}{
+		"reference": {"code-password", func(c *config.Config) {
+			c.Credentials.CodePassword = "env:DOOM_TEST_CODE_PASSWORD"
+		}, "env: reference"},
+		"unused": {"tailscale-key", func(c *config.Config) {
+			c.DeploymentMode = config.ModeLocal
+		}, "does not use the tailscale-key"},
+		"unknown": {"root-password", func(*config.Config) {}, "unknown credential"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := config.NewDefaultConfig()
+			cfg.Credentials.CodePassword = "Code-Password-1"
+			cfg.Credentials.SudoPassword = "Sudo-Password-1"
+			tt.edit(cfg)
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := cfg.SaveToFile(path); err != nil {
+				t.Fatal(err)
+			}
+
+			cmd := newRotateCmd()
+			cmd.SetArgs([]string{tt.credential, "-f", path})
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected an error with %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestGeneratePassword(t *testing.T) {
+	password, err := generatePassword(generatedPasswordLength)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(password) != generatedPasswordLength || strings.Trim(password, passwordAlphabet) != "" {
+		t.Errorf("Unexpected password %q", password)
+	}
+
+	cfg := config.NewDefaultConfig()
+	cfg.Credentials.CodePassword = password
+	if problems := cfg.Validate().ForField("credentials.code_password"); len(problems) > 0 {
+		t.Errorf("Generated passwords should be valid, got %v", problems)
+	}
+}
+
+func TestReadSecret(t *testing.T) {
+	value, err := readSecret(context.Background(), strings.NewReader("sk-ant-new\r\nignored\n"), io.Discard, "")
+	if err != nil || value != "sk-ant-new" {
+		t.Errorf("readSecret = %q, %v", value, err)
+	}
+	if _, err := readSecret(context.Background(), strings.NewReader(""), io.Discard, ""); err == nil {
+		t.Errorf("Expected an error for empty input, got %v", err)
+	}
+	if _, err := readSecret(context.Background(), strings.NewReader("\n"), io.Discard, ""); err == nil {
+		t.Error("Expected an error for an empty value")
+	}
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/doom-coding/doom-coding/internal/config"
+	"github.com/doom-coding/doom-coding/internal/health"
+	"github.com/doom-coding/doom-coding/internal/service"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes for the status command, following the Nagios plugin convention
+const (
+	exitHealthy  = 0
+	exitDegraded = 1
+	exitDown     = 2
+	exitUnknown  = 3
+)
+
+// exitError makes main exit with a specific code
+type exitError struct {
+	code int
+	err  error // Printed by main when set
+}
+
+func (e *exitError) Error() string {
+	if e.err != nil {
+		return e.err.Error()
+	}
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
+func (e *exitError) Unwrap() error { return e.err }
+
+// statusCollector gathers service and health state into a single document
+type statusCollector struct {
+	services func(ctx context.Context) []service.ServiceStatus
+	checker  *health.Checker
+}
+
+// newStatusCollector creates a collector backed by the lifecycle manager and
+// default probes for the compose stack of the installation
+func newStatusCollector(ctx context.Context, projectRoot string) *statusCollector {
+	manager := service.NewManager(projectRoot)
+	composeFile := statusComposeFile(ctx, manager, projectRoot)
+	lm := service.NewLifecycleManager(manager, projectRoot, composeFile)
+
+	probes := health.DefaultProbes(health.Endpoints{})
+	if composeFile == "" {
+		probes = hostProbes(probes)
+	}
+	return &statusCollector{
+		services: lm.Status,
+		checker:  health.NewChecker(probes...),
+	}
+}
+
+// statusComposeFile returns the compose file of the installation: the one
+// its containers were created from, or else the one of the deployment mode
+// in .env. It is empty for terminal-only installs.
+func statusComposeFile(ctx context.Context, manager *service.Manager, projectRoot string) string {
+	for _, container := range []string{"doom-code-server", "doom-claude"} {
+		if file, ok := manager.ComposeFile(ctx, container); ok {
+			return file
+		}
+	}
+	if cfg, err := config.LoadDotEnv(filepath.Join(projectRoot, ".env")); err == nil {
+		return cfg.GetComposeFile()
+	}
+	return "docker-compose.yml"
+}
+
+// hostProbes drops the probes of the container stack
+func hostProbes(probes []health.Probe) []health.Probe {
+	var host []health.Probe
+	for _, probe := range probes {
+		switch probe.(type) {
+		case *health.DockerProbe, *health.ComposeProbe, *health.ContainerProbe, *health.TailscaleSidecarProbe, *health.HTTPProbe, *health.TCPProbe:
+		default:
+			host = append(host, probe)
+		}
+	}
+	return host
+}
+
+// collect runs the health checks and queries service state
+func (c *statusCollector) collect(ctx context.Context) *health.Document {
+	doc := c.checker.Run(ctx).Document()
+	for _, svc := range c.services(ctx) {
+		doc.Services = append(doc.Services, health.Service{
+			Name:      svc.Name,
+			Container: svc.Container,
+			State:     string(svc.State),
+			Port:      svc.Port,
+		})
+	}
+	doc.State = assessState(doc)
+	return doc
+}
+
+// assessState derives the overall verdict from checks and services
+func assessState(doc *health.Document) health.State {
+	dockerOK := false
+	for _, check := range doc.Checks {
+		if check.Name == health.ProbeDocker && check.Status == health.StatusPass {
+			dockerOK = true
+		}
+	}
+
+	running, unhealthy := false, false
+	for _, svc := range doc.Services {
+		switch service.ServiceState(svc.State) {
+		case service.StateRunning, service.StateHealthy:
+			running = true
+		case service.StateUnhealthy:
+			running = true
+			unhealthy = true
+		}
+	}
+
+	// Terminal-only installs have no services to run
+	stack := len(doc.Services) > 0
+
+	switch {
+	case stack && (!dockerOK || !running):
+		return health.StateDown
+	case doc.Failed > 0 || unhealthy:
+		return health.StateDegraded
+	default:
+		return health.StateHealthy
+	}
+}
+
+// stateExitCode maps an overall state to the process exit code
+func stateExitCode(state health.State) int {
+	switch state {
+	case health.StateHealthy:
+		return exitHealthy
+	case health.StateDegraded:
+		return exitDegraded
+	case health.StateDown:
+		return exitDown
+	default:
+		return exitUnknown
+	}
+}
+
+// statusResult converts a state into the error returned from the command
+func statusResult(state health.State) error {
+	if code := stateExitCode(state); code != exitHealthy {
+		return &exitError{code: code}
+	}
+	return nil
+}
+
+func runStatus(cmd *cobra.Command, args []string) error {
+	projectRoot, err := findProjectRoot()
+	if err != nil {
+		return &exitError{code: exitUnknown, err: fmt.Errorf("could not find project root: %w", err)}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	collector := newStatusCollector(ctx, projectRoot)
+	out := cmd.OutOrStdout()
+
+	if statusWatch {
+		interval := time.Duration(statusInterval) * time.Second
+		if interval <= 0 {
+			return &exitError{code: exitUnknown, err: fmt.Errorf("--interval must be positive")}
+		}
+		return watchStatus(ctx, out, collector, interval, statusJSON)
+	}
+
+	doc := collector.collect(ctx)
+	if err := printStatus(out, doc, statusJSON); err != nil {
+		return &exitError{code: exitUnknown, err: err}
+	}
+	return statusResult(doc.State)
+}
+
+// printStatus writes a full status report
+func printStatus(w io.Writer, doc *health.Document, asJSON bool) error {
+	if asJSON {
+		data, err := json.MarshalIndent(doc, "", "    ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	}
+	renderStatus(w, doc)
+	return nil
+}
+
+// watchStatus refreshes the status every interval and prints state changes
+func watchStatus(ctx context.Context, w io.Writer, collector *statusCollector, interval time.Duration, asJSON bool) error {
+	prev := collector.collect(ctx)
+	if asJSON {
+		if err := printJSONLine(w, prev); err != nil {
+			return err
+		}
+	} else {
+		renderStatus(w, prev)
+		fmt.Fprintf(w, "\nWatching every %s (Ctrl+C to stop)...\n", interval)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return statusResult(prev.State)
+		case <-ticker.C:
+		}
+
+		cur := collector.collect(ctx)
+		if ctx.Err() != nil {
+			return statusResult(prev.State)
+		}
+		if asJSON {
+			if err := printJSONLine(w, cur); err != nil {
+				return err
+			}
+		} else {
+			stamp := cur.GeneratedAt.Local().Format("15:04:05")
+			for _, change := range diffStatus(prev, cur) {
+				fmt.Fprintf(w, "[%s] %s\n", stamp, change)
+			}
+		}
+		prev = cur
+	}
+}
+
+// printJSONLine writes a document as a single JSON line
+func printJSONLine(w io.Writer, doc *health.Document) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+// diffStatus lists the state changes between two documents
+func diffStatus(prev, cur *health.Document) []string {
+	var changes []string
+
+	if prev.State != cur.State {
+		changes = append(changes, fmt.Sprintf("overall: %s -> %s", prev.State, cur.State))
+	}
+
+	prevServices := make(map[string]string)
+	for _, svc := range prev.Services {
+		prevServices[svc.Container] = svc.State
+	}
+	for _, svc := range cur.Services {
+		if old, ok := prevServices[svc.Container]; ok && old != svc.State {
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", svc.Name, old, svc.State))
+		}
+	}
+
+	prevChecks := make(map[string]health.Status)
+	for _, check := range prev.Checks {
+		prevChecks[check.Name] = check.Status
+	}
+	for _, check := range cur.Checks {
+		old, ok := prevChecks[check.Name]
+		if !ok {
+			changes = append(changes, fmt.Sprintf("%s: new check (%s)", check.Name, check.Status))
+		} else if old != check.Status {
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s %s", check.Name, old, check.Status, check.Message))
+		}
+	}
+
+	return changes
+}
+
+// renderStatus writes the human-readable status report
+func renderStatus(w io.Writer, doc *health.Document) {
+	fmt.Fprintf(w, "Doom Coding Status: %s\n", strings.ToUpper(string(doc.State)))
+
+	if len(doc.Services) > 0 {
+		fmt.Fprintln(w, "\nServices:")
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, svc := range doc.Services {
+			port := "-"
+			if svc.Port > 0 {
+				port = fmt.Sprintf(":%d", svc.Port)
+			}
+			fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", svc.Name, svc.Container, svc.State, port)
+		}
+		tw.Flush()
+	}
+
+	fmt.Fprintln(w, "\nChecks:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, check := range doc.Checks {
+		message := check.Message
+		if check.Error != "" {
+			message += " (" + check.Error + ")"
+		}
+		fmt.Fprintf(tw, "  %s %s\t%s\n", statusSymbol(check.Status), check.Name, message)
+	}
+	tw.Flush()
+
+	fmt.Fprintf(w, "\nSummary: %d passed, %d failed, %d warnings\n", doc.Passed, doc.Failed, doc.Warnings)
+}
+
+// statusSymbol returns the marker used by health-check.sh for a status
+func statusSymbol(status health.Status) string {
+	switch status {
+	case health.StatusPass:
+		return "✅"
+	case health.StatusWarn:
+		return "⚠ "
+	case health.StatusFail:
+		return "❌"
+	default:
+		return "- "
+	}
+}
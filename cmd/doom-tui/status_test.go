@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/doom-coding/doom-coding/internal/config"
+	"github.com/doom-coding/doom-coding/internal/health"
+	"github.com/doom-coding/doom-coding/internal/runner"
+	"github.com/doom-coding/doom-coding/internal/runner/runnertest"
+	"github.com/doom-coding/doom-coding/internal/service"
+)
+
+// composeFilesLabel is the format status reads the compose file of a
+// container with
+const composeFilesLabel = `{{index .Config.Labels "com.docker.compose.project.config_files"}}`
+
+type fakeProbe struct {
+	name   string
+	status health.Status
+}
+
+func (p *fakeProbe) Name() string { return p.name }
+
+func (p *fakeProbe) Check(ctx context.Context, r runner.CommandRunner) health.Outcome {
+	return health.Outcome{Status: p.status, Message: "fake"}
+}
+
+// fakeCollector returns a collector with fixed services and probe statuses
+func fakeCollector(states []service.ServiceState, probes ...health.Probe) *statusCollector {
+	return &statusCollector{
+		services: func(ctx context.Context) []service.ServiceStatus {
+			var out []service.ServiceStatus
+			for i, state := range states {
+				name := string(rune('a' + i))
+				out = append(out, service.ServiceStatus{Name: name, Container: "doom-" + name, State: state})
+			}
+			return out
+		},
+		checker: health.NewChecker(probes...),
+	}
+}
+
+func TestAssessState(t *testing.T) {
+	dockerUp := &fakeProbe{name: health.ProbeDocker, status: health.StatusPass}
+	dockerDown := &fakeProbe{name: health.ProbeDocker, status: health.StatusFail}
+	failing := &fakeProbe{name: health.ProbeTTYD, status: health.StatusFail}
+	warning := &fakeProbe{name: health.ProbeSSHHardening, status: health.StatusWarn}
+
+	tests := []struct {
+		name     string
+		states   []service.ServiceState
+		probes   []health.Probe
+		expected health.State
+	}{
+		{"all healthy", []service.ServiceState{service.StateHealthy, service.StateRunning}, []health.Probe{dockerUp, warning}, health.StateHealthy},
+		{"failing probe", []service.ServiceState{service.StateHealthy}, []health.Probe{dockerUp, failing}, health.StateDegraded},
+		{"unhealthy service", []service.ServiceState{service.StateHealthy, service.StateUnhealthy}, []health.Probe{dockerUp}, health.StateDegraded},
+		{"docker down", []service.ServiceState{service.StateHealthy}, []health.Probe{dockerDown}, health.StateDown},
+		{"nothing running", []service.ServiceState{service.StateStopped, service.StateUnknown}, []health.Probe{dockerUp}, health.StateDown},
+		{"terminal-only", nil, []health.Probe{warning}, health.StateHealthy},
+	}
+
+	for _, tc := range tests {
+		doc := fakeCollector(tc.states, tc.probes...).collect(context.Background())
+		if doc.State != tc.expected {
+			t.Errorf("%s: expected %s, got %s", tc.name, tc.expected, doc.State)
+		}
+		if err := doc.Validate(); err != nil {
+			t.Errorf("%s: collected document is invalid: %v", tc.name, err)
+		}
+	}
+}
+
+func TestStatusComposeFile(t *testing.T) {
+	root := t.TempDir()
+	fake := runnertest.New()
+	manager := service.NewManager(root)
+	manager.SetRunner(fake)
+
+	created := filepath.Join(root, "docker-compose.native-userspace.yml")
+	fake.On("docker", "inspect", "--format", composeFilesLabel, "doom-code-server").Returns(created + "\n").Once()
+	if got := statusComposeFile(context.Background(), manager, root); got != "docker-compose.native-userspace.yml" {
+		t.Errorf("Expected the compose file of the containers, got %q", got)
+	}
+
+	fake.On("docker", "inspect", "--format", composeFilesLabel, "doom-code-server").Exit(1)
+	fake.On("docker", "inspect", "--format", composeFilesLabel, "doom-claude").Exit(1)
+	if got := statusComposeFile(context.Background(), manager, root); got != "docker-compose.yml" {
+		t.Errorf("Expected the default compose file without .env, got %q", got)
+	}
+
+	cfg := config.NewDefaultConfig()
+	cfg.DeploymentMode = config.ModeLocal
+	if err := cfg.WriteEnvFile(root); err != nil {
+		t.Fatal(err)
+	}
+	if got := statusComposeFile(context.Background(), manager, root); got != "docker-compose.lxc.yml" {
+		t.Errorf("Expected the compose file of the local mode, got %q", got)
+	}
+}
+
+func TestHostProbes(t *testing.T) {
+	probes := hostProbes(health.DefaultProbes(health.Endpoints{}))
+	if len(probes) == 0 {
+		t.Fatal("The host probes should be kept")
+	}
+	for _, probe := range probes {
+		switch probe.Name() {
+		case health.ProbeDocker, health.ProbeDockerCompose, health.ProbeCodeServer, health.ProbeTTYD:
+			t.Errorf("Terminal-only installs should not check %s", probe.Name())
+		}
+	}
+}
+
+func TestStatusExitCodes(t *testing.T) {
+	tests := []struct {
+		state    health.State
+		expected int
+	}{
+		{health.StateHealthy, exitHealthy},
+		{health.StateDegraded, exitDegraded},
+		{health.StateDown, exitDown},
+		{"", exitUnknown},
+	}
+
+	for _, tc := range tests {
+		err := statusResult(tc.state)
+		if tc.expected == exitHealthy {
+			if err != nil {
+				t.Errorf("%q: expected nil error, got %v", tc.state, err)
+			}
+			continue
+		}
+		var exitErr *exitError
+		if !errors.As(err, &exitErr) || exitErr.code != tc.expected {
+			t.Errorf("%q: expected exit code %d, got %v", tc.state, tc.expected, err)
+		}
+	}
+}
+
+func TestPrintStatusJSON(t *testing.T) {
+	doc := fakeCollector([]service.ServiceState{service.StateHealthy},
+		&fakeProbe{name: health.ProbeDocker, status: health.StatusPass}).collect(context.Background())
+
+	var buf bytes.Buffer
+	if err := printStatus(&buf, doc, true); err != nil {
+		t.Fatalf("printStatus failed: %v", err)
+	}
+
+	decoded, err := health.Decode(buf.Bytes())
+	if err != nil {
+		t.Fatalf("status --json output does not match schema: %v\n%s", err, buf.String())
+	}
+	if decoded.State != health.StateHealthy || len(decoded.Services) != 1 {
+		t.Errorf("Unexpected document: %+v", decoded)
+	}
+}
+
+func TestRenderStatus(t *testing.T) {
+	doc := fakeCollector([]service.ServiceState{service.StateHealthy},
+		&fakeProbe{name: health.ProbeDocker, status: health.StatusPass},
+		&fakeProbe{name: health.ProbeTTYD, status: health.StatusFail}).collect(context.Background())
+
+	var buf bytes.Buffer
+	renderStatus(&buf, doc)
+	out := buf.String()
+
+	for _, want := range []string{"DEGRADED", "doom-a", "✅ docker", "❌ ttyd", "1 passed, 1 failed"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestDiffStatus(t *testing.T) {
+	prev := &health.Document{
+		State:    health.StateHealthy,
+		Services: []health.Service{{Name: "code-server", Container: "doom-code-server", State: "healthy"}},
+		Checks:   []health.Check{{Name: "docker", Status: health.StatusPass}, {Name: "ttyd", Status: health.StatusPass}},
+	}
+	cur := &health.Document{
+		State:    health.StateDegraded,
+		Services: []health.Service{{Name: "code-server", Container: "doom-code-server", State: "unhealthy"}},
+		Checks:   []health.Check{{Name: "docker", Status: health.StatusPass}, {Name: "ttyd", Status: health.StatusFail, Message: "refused"}},
+	}
+
+	changes := diffStatus(prev, cur)
+	expected := []string{
+		"overall: healthy -> degraded",
+		"code-server: healthy -> unhealthy",
+		"ttyd: pass -> fail refused",
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("Expected %d changes, got %v", len(expected), changes)
+	}
+	for i := range expected {
+		if changes[i] != expected[i] {
+			t.Errorf("Change %d: expected %q, got %q", i, expected[i], changes[i])
+		}
+	}
+
+	if changes := diffStatus(cur, cur); len(changes) != 0 {
+		t.Errorf("Identical documents should have no changes, got %v", changes)
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent use
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestWatchStatusJSONLines(t *testing.T) {
+	collector := fakeCollector([]service.ServiceState{service.StateRunning},
+		&fakeProbe{name: health.ProbeDocker, status: health.StatusPass},
+		&fakeProbe{name: health.ProbeTTYD, status: health.StatusFail})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var out syncBuffer
+	done := make(chan error, 1)
+	go func() {
+		done <- watchStatus(ctx, &out, collector, 10*time.Millisecond, true)
+	}()
+
+	deadline := time.After(5 * time.Second)
+	for strings.Count(out.String(), "\n") < 3 {
+		select {
+		case <-deadline:
+			t.Fatalf("watch produced too few lines:\n%s", out.String())
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+	cancel()
+
+	err := <-done
+	var exitErr *exitError
+	if !errors.As(err, &exitErr) || exitErr.code != exitDegraded {
+		t.Errorf("Expected degraded exit code, got %v", err)
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var doc health.Document
+		if err := json.Unmarshal([]byte(line), &doc); err != nil {
+			t.Fatalf("Line is not a JSON document: %v\n%s", err, line)
+		}
+		if doc.State != health.StateDegraded {
+			t.Errorf("Expected degraded state, got %s", doc.State)
+		}
+	}
+}
@@ -0,0 +1,354 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/doom-coding/doom-coding/internal/secrets"
+)
+
+// dotEnvKeys maps the .env variables written by GenerateEnvFile and
+// install.sh to configuration fields. Only older versions wrote the
+// credentials to .env.
+var dotEnvKeys = []envOverride{
+	{"TS_AUTHKEY", secretField(func(c *Config) *string { return &c.Credentials.TailscaleKey })},
+	{"CODE_SERVER_PASSWORD", secretField(func(c *Config) *string { return &c.Credentials.CodePassword })},
+	{"SUDO_PASSWORD", secretField(func(c *Config) *string { return &c.Credentials.SudoPassword })},
+	{"ANTHROPIC_API_KEY", secretField(func(c *Config) *string { return &c.Credentials.AnthropicKey })},
+	{"CODE_SERVER_PORT", intField(func(c *Config) *int { return &c.Advanced.CodeServerPort })},
+	{"PUID", stringField(func(c *Config) *string { return &c.Environment.PUID })},
+	{"PGID", stringField(func(c *Config) *string { return &c.Environment.PGID })},
+	{"TZ", stringField(func(c *Config) *string { return &c.Environment.Timezone })},
+	{"WORKSPACE_PATH", stringField(func(c *Config) *string { return &c.Environment.WorkspacePath })},
+	{"TARGETARCH", stringField(func(c *Config) *string { return &c.Advanced.TargetArch })},
+	{"TS_ACCEPT_DNS", boolField(func(c *Config) *bool { return &c.Advanced.TSAcceptDNS })},
+	{"TS_EXTRA_ARGS", stringField(func(c *Config) *string { return &c.Advanced.TSExtraArgs })},
+	{"CLAUDE_AUTOMATION", stringField(func(c *Config) *string { return &c.Advanced.ClaudeAutomation })},
+}
+
+// secretField sets a credential to a value from a .env file, which is
+// never a secret reference
+func secretField(field func(c *Config) *string) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		*field(c) = QuoteSecret(value)
+		return nil
+	}
+}
+
+// dotEnvKey matches a valid variable name
+var dotEnvKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// modeComment matches the deployment mode header written by GenerateEnvFile
+var modeComment = regexp.MustCompile(`(?m)^# Deployment mode: (\S+)\s*$`)
+
+// ParseDotEnv parses a .env file the way docker compose reads it: blank
+// lines and comments are skipped, "export " prefixes are allowed, single
+// quoted values are taken literally and double quoted values may contain
+// escapes and span lines. Unquoted and double quoted values expand $VAR,
+// ${VAR}, ${VAR:-default} and ${VAR-default} from the variables above them.
+func ParseDotEnv(data []byte) (map[string]string, error) {
+	vars := make(map[string]string)
+	p := &dotEnvParser{lines: strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")}
+
+	for p.next() {
+		line := strings.TrimSpace(p.line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || !dotEnvKey.MatchString(key) {
+			return nil, fmt.Errorf("line %d: expected KEY=value", p.number)
+		}
+
+		value, err := p.value(strings.TrimLeft(value, " \t"), vars)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", p.number, key, err)
+		}
+		vars[key] = value
+	}
+	return vars, nil
+}
+
+// dotEnvParser walks the lines of a .env file
+type dotEnvParser struct {
+	lines  []string
+	line   string
+	number int
+}
+
+func (p *dotEnvParser) next() bool {
+	if p.number >= len(p.lines) {
+		return false
+	}
+	p.line = p.lines[p.number]
+	p.number++
+	return true
+}
+
+// value parses the value after the "=" of the current line
+func (p *dotEnvParser) value(raw string, vars map[string]string) (string, error) {
+	switch {
+	case strings.HasPrefix(raw, "'"):
+		end := strings.Index(raw[1:], "'")
+		if end < 0 {
+			return "", fmt.Errorf("unterminated single quote")
+		}
+		return raw[1 : end+1], nil
+
+	case strings.HasPrefix(raw, `"`):
+		var sb strings.Builder
+		rest := raw[1:]
+		for {
+			for i := 0; i < len(rest); i++ {
+				switch c := rest[i]; c {
+				case '\\':
+					if i+1 == len(rest) {
+						sb.WriteByte(c)
+						continue
+					}
+					i++
+					switch rest[i] {
+					case 'n':
+						sb.WriteByte('\n')
+					case 'r':
+						sb.WriteByte('\r')
+					case '$':
+						sb.WriteString(escapedDollar) // Not expanded below
+					default:
+						// Any other escaped character stands for itself
+						sb.WriteByte(rest[i])
+					}
+				case '"':
+					return expand(sb.String(), vars)
+				default:
+					sb.WriteByte(c)
+				}
+			}
+			// The value continues on the next line
+			if !p.next() {
+				return "", fmt.Errorf("unterminated double quote")
+			}
+			sb.WriteByte('\n')
+			rest = p.line
+		}
+
+	default:
+		// An inline comment starts at a # after whitespace
+		for i := 1; i < len(raw); i++ {
+			if raw[i] == '#' && (raw[i-1] == ' ' || raw[i-1] == '\t') {
+				raw = raw[:i]
+				break
+			}
+		}
+		return expand(strings.TrimSpace(raw), vars)
+	}
+}
+
+// escapedDollar stands for a \$ in a double quoted value until expansion
+const escapedDollar = "\x00"
+
+// expand replaces variable references with the values of vars
+func expand(value string, vars map[string]string) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c != '$' || i+1 == len(value) {
+			sb.WriteByte(c)
+			continue
+		}
+
+		if value[i+1] == '$' {
+			sb.WriteByte('$')
+			i++
+			continue
+		}
+
+		if value[i+1] != '{' {
+			end := i + 1
+			for end < len(value) && (value[end] == '_' || isAlnum(value[end])) {
+				end++
+			}
+			if end == i+1 {
+				sb.WriteByte(c)
+				continue
+			}
+			sb.WriteString(vars[value[i+1:end]])
+			i = end - 1
+			continue
+		}
+
+		end := strings.IndexByte(value[i:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated ${ in %q", value)
+		}
+		ref := value[i+2 : i+end]
+		i += end
+
+		name, fallback, colon := ref, "", false
+		if j := strings.IndexAny(ref, ":-"); j >= 0 {
+			name = ref[:j]
+			switch {
+			case strings.HasPrefix(ref[j:], ":-"):
+				fallback, colon = ref[j+2:], true
+			case ref[j] == '-':
+				fallback = ref[j+1:]
+			default:
+				return "", fmt.Errorf("unsupported expansion ${%s}", ref)
+			}
+		}
+		if !dotEnvKey.MatchString(name) {
+			return "", fmt.Errorf("invalid variable name in ${%s}", ref)
+		}
+
+		v, ok := vars[name]
+		if !ok || (colon && v == "") {
+			v = fallback
+		}
+		sb.WriteString(v)
+	}
+	return strings.ReplaceAll(sb.String(), escapedDollar, "$"), nil
+}
+
+func isAlnum(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// envWriter writes KEY=value lines, keeping the first error
+type envWriter struct {
+	sb  *strings.Builder
+	err error
+}
+
+func (w *envWriter) set(key, value string) {
+	if w.err != nil {
+		return
+	}
+	line, err := FormatDotEnv(key, value)
+	if err != nil {
+		w.err = err
+		return
+	}
+	w.sb.WriteString(line)
+	w.sb.WriteByte('\n')
+}
+
+// dotEnvPlain matches the values written without quotes
+var dotEnvPlain = regexp.MustCompile(`^[A-Za-z0-9_./:@%+,=-]*$`)
+
+// FormatDotEnv returns the KEY=value line for a .env file that ParseDotEnv
+// and docker compose read back as value. Values with characters that have
+// a meaning in .env files are quoted: in single quotes when that is
+// enough, as they are taken literally, and otherwise in double quotes with
+// ", \, $ and line breaks escaped. Values with NUL bytes cannot be passed
+// in the environment and are rejected.
+func FormatDotEnv(key, value string) (string, error) {
+	if !dotEnvKey.MatchString(key) {
+		return "", fmt.Errorf("invalid variable name %q", key)
+	}
+	if strings.IndexByte(value, 0) >= 0 {
+		return "", fmt.Errorf("%s: value contains a NUL byte", key)
+	}
+
+	switch {
+	case dotEnvPlain.MatchString(value):
+		return key + "=" + value, nil
+	case !strings.ContainsAny(value, "'\n\r"):
+		return key + "='" + value + "'", nil
+	default:
+		var sb strings.Builder
+		sb.WriteString(key + `="`)
+		for i := 0; i < len(value); i++ {
+			switch c := value[i]; c {
+			case '"', '\\', '$':
+				sb.WriteByte('\\')
+				sb.WriteByte(c)
+			case '\n':
+				sb.WriteString(`\n`)
+			case '\r':
+				sb.WriteString(`\r`)
+			default:
+				sb.WriteByte(c)
+			}
+		}
+		sb.WriteByte('"')
+		return sb.String(), nil
+	}
+}
+
+// ImportDotEnv sets the configuration from the variables of a .env file.
+// Known variables set their fields, the others are kept in ExtraEnv so
+// GenerateEnvFile writes them again. The deployment mode is tailscale when
+// TS_AUTHKEY is set and local otherwise.
+func (c *Config) ImportDotEnv(vars map[string]string) error {
+	known := make(map[string]bool, len(dotEnvKeys))
+	for _, key := range dotEnvKeys {
+		known[key.name] = true
+		value, ok := vars[key.name]
+		if !ok || value == "" {
+			continue
+		}
+		if err := key.set(c, value); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", key.name, err)
+		}
+	}
+
+	for key, value := range vars {
+		if known[key] {
+			continue
+		}
+		if c.ExtraEnv == nil {
+			c.ExtraEnv = make(map[string]string)
+		}
+		c.ExtraEnv[key] = value
+	}
+
+	if c.Credentials.TailscaleKey != "" {
+		c.DeploymentMode = ModeTailscale
+	} else {
+		c.DeploymentMode = ModeLocal
+	}
+	c.Components.Tailscale = c.DeploymentMode == ModeTailscale
+	return nil
+}
+
+// LoadDotEnv reads a .env file into a configuration based on the defaults,
+// see ImportDotEnv. The deployment mode header written by GenerateEnvFile
+// takes precedence over the guessed mode. Credentials the .env does not
+// set are read from the secret files next to it, see WriteSecrets.
+func LoadDotEnv(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read env file: %w", err)
+	}
+	vars, err := ParseDotEnv(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	config := NewDefaultConfig()
+	if err := config.ImportDotEnv(vars); err != nil {
+		return nil, err
+	}
+	if m := modeComment.FindSubmatch(data); m != nil {
+		config.DeploymentMode = string(bytes.TrimSpace(m[1]))
+		config.Components.Tailscale = config.DeploymentMode == ModeTailscale
+	}
+
+	values, err := secrets.NewManager(filepath.Dir(path)).Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets: %w", err)
+	}
+	for name, field := range config.composeSecretFields() {
+		if *field == "" && values[name] != "" {
+			*field = QuoteSecret(values[name])
+		}
+	}
+	return config, nil
+}
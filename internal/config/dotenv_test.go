@@ -0,0 +1,238 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParseDotEnv(t *testing.T) {
+	data := `# Comment
+export PUID=1001
+
+EMPTY=
+UNQUOTED = value with spaces # comment
+HASH=pass#word
+SINGLE='$PUID # literal'
+DOUBLE="line one\nsay \"hi\" \$PUID=$PUID"
+MULTI="first
+second"
+BRACES=${PUID}-${MISSING:-fallback}-${EMPTY-kept}-${EMPTY:-replaced}
+`
+	vars, err := ParseDotEnv([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"PUID":     "1001",
+		"EMPTY":    "",
+		"UNQUOTED": "value with spaces",
+		"HASH":     "pass#word",
+		"SINGLE":   "$PUID # literal",
+		"DOUBLE":   "line one\nsay \"hi\" $PUID=1001",
+		"MULTI":    "first\nsecond",
+		"BRACES":   "1001-fallback--replaced",
+	}
+	for key, value := range want {
+		if vars[key] != value {
+			t.Errorf("%s = %q, want %q", key, vars[key], value)
+		}
+	}
+}
+
+func TestParseDotEnvErrors(t *testing.T) {
+	tests := map[string]string{
+		"no equals":         "PUID 1000",
+		"invalid key":       "1PUID=1000",
+		"unterminated":      `PASSWORD="secret`,
+		"unterminated ${":   "TZ=${TIMEZONE",
+		"unsupported ${:?}": "TZ=${TIMEZONE:?required}",
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseDotEnv([]byte(data)); err == nil || !strings.Contains(err.Error(), "line 1") {
+				t.Errorf("Expected an error for line 1, got %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadDotEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	data := `TS_AUTHKEY=tskey-auth-abc
+CODE_SERVER_PASSWORD="code secret"
+SUDO_PASSWORD=sudo-secret
+PUID=1001
+TZ=${TZ:-Asia/Tokyo}
+CODE_SERVER_PORT=9443
+TS_ACCEPT_DNS=true
+HTTP_PROXY=http://proxy:3128
+`
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadDotEnv(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DeploymentMode != ModeTailscale || !cfg.Components.Tailscale {
+		t.Errorf("A Tailscale key should select tailscale mode, got %s", cfg.DeploymentMode)
+	}
+	if cfg.Credentials.CodePassword != "code secret" || cfg.Environment.PUID != "1001" ||
+		cfg.Environment.Timezone != "Asia/Tokyo" || cfg.Advanced.CodeServerPort != 9443 || !cfg.Advanced.TSAcceptDNS {
+		t.Errorf("Known keys were not imported: %+v", cfg)
+	}
+	if cfg.Environment.PGID != "1000" {
+		t.Errorf("Missing keys should keep their defaults, got PGID %q", cfg.Environment.PGID)
+	}
+	if cfg.ExtraEnv["HTTP_PROXY"] != "http://proxy:3128" || len(cfg.ExtraEnv) != 1 {
+		t.Errorf("Unknown keys should be kept as extras, got %v", cfg.ExtraEnv)
+	}
+
+	env, err := cfg.GenerateEnvFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(env, "HTTP_PROXY=http://proxy:3128\n") {
+		t.Errorf("Extras should be written to .env:\n%s", env)
+	}
+}
+
+func TestLoadDotEnvGenerated(t *testing.T) {
+	cfg := NewDefaultConfig()
+	cfg.DeploymentMode = ModeNativeTailscale
+	cfg.Components.Tailscale = false
+	cfg.Credentials.CodePassword = "code-secret"
+	cfg.Credentials.SudoPassword = "sudo-secret"
+	cfg.Advanced.TSExtraArgs = "--advertise-tags=tag:doom-coding"
+	cfg.Environment.PUID = strconv.Itoa(os.Getuid())
+	cfg.Environment.PGID = strconv.Itoa(os.Getgid())
+
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := cfg.WriteEnvFile(filepath.Dir(path)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cfg.WriteSecrets(filepath.Dir(path)); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadDotEnv(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.DeploymentMode != ModeNativeTailscale {
+		t.Errorf("The mode header should be used, got %s", loaded.DeploymentMode)
+	}
+	if loaded.Credentials != cfg.Credentials || loaded.Environment != cfg.Environment || loaded.Advanced != cfg.Advanced {
+		t.Errorf("Import should restore the generated config:\n%+v\n%+v", cfg, loaded)
+	}
+	if len(loaded.ExtraEnv) > 0 {
+		t.Errorf("A generated .env has no extras, got %v", loaded.ExtraEnv)
+	}
+}
+
+func TestImportDotEnvInvalidValue(t *testing.T) {
+	err := NewDefaultConfig().ImportDotEnv(map[string]string{"CODE_SERVER_PORT": "https"})
+	if err == nil || !strings.Contains(err.Error(), "CODE_SERVER_PORT") {
+		t.Errorf("Expected an error naming CODE_SERVER_PORT, got %v", err)
+	}
+}
+
+// trickyValues break .env files when written without quoting
+var trickyValues = []string{
+	"",
+	"plain-Value_1.2:3@host/path",
+	"pass word",
+	"pass#word",
+	"pass #word",
+	" leading and trailing ",
+	"$HOME",
+	"${PUID:-0}",
+	"$$",
+	"it's",
+	`say "hi"`,
+	`back\slash\n`,
+	"line one\nline two",
+	"crlf\r\n",
+	"'single' and \"double\" and $dollar",
+	"#",
+	"=",
+	"ümlaut ✓",
+	"\xff\xfe",
+}
+
+func TestFormatDotEnvRoundTrip(t *testing.T) {
+	for _, value := range trickyValues {
+		line, err := FormatDotEnv("VALUE", value)
+		if err != nil {
+			t.Errorf("FormatDotEnv(%q): %v", value, err)
+			continue
+		}
+		// PUID is set above to catch values that are expanded
+		vars, err := ParseDotEnv([]byte("PUID=1000\n" + line + "\n"))
+		if err != nil {
+			t.Errorf("ParseDotEnv(%q): %v", line, err)
+			continue
+		}
+		if vars["VALUE"] != value {
+			t.Errorf("Round trip of %q through %q gave %q", value, line, vars["VALUE"])
+		}
+	}
+}
+
+func TestFormatDotEnvRejects(t *testing.T) {
+	if _, err := FormatDotEnv("PASSWORD", "nul\x00byte"); err == nil {
+		t.Error("Values with a NUL byte should be rejected")
+	}
+	if _, err := FormatDotEnv("MY-VAR", "value"); err == nil {
+		t.Error("Invalid variable names should be rejected")
+	}
+}
+
+func TestGenerateEnvFileRoundTrip(t *testing.T) {
+	for _, value := range trickyValues {
+		cfg := NewDefaultConfig()
+		cfg.DeploymentMode = ModeLocal
+		cfg.Components.Tailscale = false
+		cfg.Environment.WorkspacePath = value
+		cfg.Advanced.TSExtraArgs = value
+		cfg.ExtraEnv = map[string]string{"HTTP_PROXY": value}
+
+		env, err := cfg.GenerateEnvFile()
+		if err != nil {
+			t.Fatal(err)
+		}
+		vars, err := ParseDotEnv([]byte(env))
+		if err != nil {
+			t.Fatalf("Generated .env for %q does not parse: %v\n%s", value, err, env)
+		}
+		for _, key := range []string{"WORKSPACE_PATH", "HTTP_PROXY"} {
+			if vars[key] != value {
+				t.Errorf("%s = %q, want %q", key, vars[key], value)
+			}
+		}
+		if value != "" && vars["TS_EXTRA_ARGS"] != value {
+			t.Errorf("TS_EXTRA_ARGS = %q, want %q", vars["TS_EXTRA_ARGS"], value)
+		}
+	}
+}
+
+func TestGenerateEnvFileRejectsNUL(t *testing.T) {
+	cfg := NewDefaultConfig()
+	cfg.Environment.WorkspacePath = "nul\x00byte"
+	cfg.Credentials.CodePassword = "nul\x00byte"
+
+	if _, err := cfg.GenerateEnvFile(); err == nil || !strings.Contains(err.Error(), "WORKSPACE_PATH") {
+		t.Errorf("Expected an error naming WORKSPACE_PATH, got %v", err)
+	}
+	if problems := cfg.Validate().ForField("environment.workspace_path"); len(problems) == 0 {
+		t.Error("Validate should report values that cannot be written to .env")
+	}
+	if problems := cfg.Validate().ForField("credentials.code_password"); len(problems) == 0 {
+		t.Error("Validate should report credentials the containers cannot read")
+	}
+}
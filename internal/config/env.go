@@ -0,0 +1,99 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// EnvPrefix is the prefix of the environment variables that override
+// configuration values. The variable for a field is the prefix followed by
+// the upper-cased JSON name of the field, e.g. DOOM_CODE_PASSWORD.
+const EnvPrefix = "DOOM_"
+
+// envOverride sets a configuration field from an environment variable
+type envOverride struct {
+	name string
+	set  func(c *Config, value string) error
+}
+
+// envOverrides lists the variables read by ApplyEnv
+var envOverrides = []envOverride{
+	{"DEPLOYMENT_MODE", stringField(func(c *Config) *string { return &c.DeploymentMode })},
+
+	{"DOCKER", boolField(func(c *Config) *bool { return &c.Components.Docker })},
+	{"TAILSCALE", boolField(func(c *Config) *bool { return &c.Components.Tailscale })},
+	{"TERMINAL_TOOLS", boolField(func(c *Config) *bool { return &c.Components.TerminalTools })},
+	{"SSH_HARDENING", boolField(func(c *Config) *bool { return &c.Components.SSHHardening })},
+	{"SECRETS_MANAGER", boolField(func(c *Config) *bool { return &c.Components.SecretsManager })},
+
+	{"TAILSCALE_KEY", stringField(func(c *Config) *string { return &c.Credentials.TailscaleKey })},
+	{"CODE_PASSWORD", stringField(func(c *Config) *string { return &c.Credentials.CodePassword })},
+	{"SUDO_PASSWORD", stringField(func(c *Config) *string { return &c.Credentials.SudoPassword })},
+	{"ANTHROPIC_KEY", stringField(func(c *Config) *string { return &c.Credentials.AnthropicKey })},
+
+	{"PUID", stringField(func(c *Config) *string { return &c.Environment.PUID })},
+	{"PGID", stringField(func(c *Config) *string { return &c.Environment.PGID })},
+	{"TIMEZONE", stringField(func(c *Config) *string { return &c.Environment.Timezone })},
+	{"WORKSPACE_PATH", stringField(func(c *Config) *string { return &c.Environment.WorkspacePath })},
+
+	{"CODE_SERVER_PORT", intField(func(c *Config) *int { return &c.Advanced.CodeServerPort })},
+	{"CLAUDE_AUTOMATION", stringField(func(c *Config) *string { return &c.Advanced.ClaudeAutomation })},
+	{"TS_ACCEPT_DNS", boolField(func(c *Config) *bool { return &c.Advanced.TSAcceptDNS })},
+	{"TS_EXTRA_ARGS", stringField(func(c *Config) *string { return &c.Advanced.TSExtraArgs })},
+	{"TARGET_ARCH", stringField(func(c *Config) *string { return &c.Advanced.TargetArch })},
+}
+
+func stringField(field func(c *Config) *string) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		*field(c) = value
+		return nil
+	}
+}
+
+func boolField(field func(c *Config) *bool) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		*field(c) = b
+		return nil
+	}
+}
+
+func intField(field func(c *Config) *int) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		*field(c) = n
+		return nil
+	}
+}
+
+// EnvVars returns the names of the environment variables read by ApplyEnv
+func EnvVars() []string {
+	names := make([]string, len(envOverrides))
+	for i, override := range envOverrides {
+		names[i] = EnvPrefix + override.name
+	}
+	return names
+}
+
+// ApplyEnv overrides configuration values with the DOOM_* environment
+// variables returned by lookup, usually os.LookupEnv. Unset and empty
+// variables are ignored.
+func (c *Config) ApplyEnv(lookup func(string) (string, bool)) error {
+	for _, override := range envOverrides {
+		name := EnvPrefix + override.name
+		value, ok := lookup(name)
+		if !ok || value == "" {
+			continue
+		}
+		if err := override.set(c, value); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", name, err)
+		}
+	}
+	return nil
+}
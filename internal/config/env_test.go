@@ -0,0 +1,63 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// mapLookup returns a lookup function for ApplyEnv backed by vars
+func mapLookup(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	cfg := NewDefaultConfig()
+	err := cfg.ApplyEnv(mapLookup(map[string]string{
+		"DOOM_DEPLOYMENT_MODE":  ModeLocal,
+		"DOOM_TAILSCALE":        "false",
+		"DOOM_CODE_PASSWORD":    "from-env",
+		"DOOM_TIMEZONE":         "",
+		"DOOM_CODE_SERVER_PORT": "9443",
+		"DOOM_TS_ACCEPT_DNS":    "true",
+		"CODE_PASSWORD":         "unprefixed",
+	}))
+	if err != nil {
+		t.Fatalf("ApplyEnv failed: %v", err)
+	}
+
+	if cfg.DeploymentMode != ModeLocal || cfg.Components.Tailscale {
+		t.Errorf("Mode and components should be overridden, got %q %+v", cfg.DeploymentMode, cfg.Components)
+	}
+	if cfg.Credentials.CodePassword != "from-env" {
+		t.Errorf("CodePassword = %q, want from-env", cfg.Credentials.CodePassword)
+	}
+	if cfg.Environment.Timezone != "Europe/Berlin" {
+		t.Errorf("Empty variables should be ignored, got timezone %q", cfg.Environment.Timezone)
+	}
+	if cfg.Advanced.CodeServerPort != 9443 || !cfg.Advanced.TSAcceptDNS {
+		t.Errorf("Advanced settings should be overridden, got %+v", cfg.Advanced)
+	}
+}
+
+func TestApplyEnvInvalidValue(t *testing.T) {
+	cfg := NewDefaultConfig()
+	err := cfg.ApplyEnv(mapLookup(map[string]string{"DOOM_CODE_SERVER_PORT": "https"}))
+	if err == nil || !strings.Contains(err.Error(), "DOOM_CODE_SERVER_PORT") {
+		t.Errorf("Expected an error naming the variable, got %v", err)
+	}
+}
+
+func TestEnvVars(t *testing.T) {
+	vars := EnvVars()
+	if len(vars) != len(envOverrides) {
+		t.Fatalf("Expected %d variables, got %d", len(envOverrides), len(vars))
+	}
+	for _, name := range vars {
+		if !strings.HasPrefix(name, EnvPrefix) {
+			t.Errorf("Variable %s should start with %s", name, EnvPrefix)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Format is a configuration file format
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatYAML Format = "yaml"
+	FormatTOML Format = "toml"
+)
+
+// FormatFromPath returns the format for a file extension: .yaml and .yml
+// are YAML, .toml is TOML and everything else is JSON
+func FormatFromPath(path string) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".toml":
+		return FormatTOML
+	default:
+		return FormatJSON
+	}
+}
+
+// Unmarshal parses a configuration document. Documents of older schema
+// versions are migrated, see Decode. Fields missing from the document keep
+// their default values.
+func Unmarshal(data []byte, format Format) (*Config, error) {
+	config, _, err := Decode(data, format)
+	return config, err
+}
+
+// Marshal encodes the configuration in the given format
+func (c *Config) Marshal(format Format) ([]byte, error) {
+	switch format {
+	case FormatYAML:
+		return c.marshalYAML(nil)
+	case FormatTOML:
+		var buf bytes.Buffer
+		enc := toml.NewEncoder(&buf)
+		enc.Indent = ""
+		if err := enc.Encode(c); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case FormatJSON:
+		return json.MarshalIndent(c, "", "  ")
+	default:
+		return nil, fmt.Errorf("unknown config format %q", format)
+	}
+}
+
+// marshalYAML encodes the configuration as YAML. When existing holds a
+// YAML document, its comments and key order are kept and only the values
+// are updated.
+func (c *Config) marshalYAML(existing []byte) ([]byte, error) {
+	var node yaml.Node
+	if err := node.Encode(c); err != nil {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if len(existing) > 0 && yaml.Unmarshal(existing, &doc) == nil &&
+		len(doc.Content) == 1 && doc.Content[0].Kind == yaml.MappingNode {
+		mergeYAML(doc.Content[0], &node)
+	} else {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{&node}}
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// mergeYAML updates the mapping dst with the values of the mapping src.
+// Comments, quoting and key order of dst are kept, keys missing from src
+// are removed and new keys are appended.
+func mergeYAML(dst, src *yaml.Node) {
+	values := make(map[string]*yaml.Node)
+	var order []string
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key := src.Content[i].Value
+		values[key] = src.Content[i+1]
+		order = append(order, key)
+	}
+
+	var content []*yaml.Node
+	seen := make(map[string]bool)
+	for i := 0; i+1 < len(dst.Content); i += 2 {
+		key, old := dst.Content[i], dst.Content[i+1]
+		value, ok := values[key.Value]
+		if !ok {
+			continue
+		}
+		seen[key.Value] = true
+
+		if old.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode {
+			mergeYAML(old, value)
+			value = old
+		} else {
+			if old.Kind == yaml.ScalarNode && value.Kind == yaml.ScalarNode && old.Tag == value.Tag {
+				value.Style = old.Style
+			}
+			value.HeadComment = old.HeadComment
+			value.LineComment = old.LineComment
+			value.FootComment = old.FootComment
+		}
+		content = append(content, key, value)
+	}
+
+	for i, key := range order {
+		if !seen[key] {
+			content = append(content, src.Content[2*i], values[key])
+		}
+	}
+	dst.Content = content
+}
+
+// decodeDocument decodes data in the given format into v
+func decodeDocument(data []byte, format Format, v any) error {
+	switch format {
+	case FormatYAML:
+		return yaml.Unmarshal(data, v)
+	case FormatTOML:
+		_, err := toml.Decode(string(data), v)
+		return err
+	case FormatJSON:
+		return json.Unmarshal(data, v)
+	default:
+		return fmt.Errorf("unknown config format %q", format)
+	}
+}
+
+// encodeDocument encodes a generic document in the given format
+func encodeDocument(doc map[string]any, format Format) ([]byte, error) {
+	switch format {
+	case FormatYAML:
+		return yaml.Marshal(doc)
+	case FormatTOML:
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(doc); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case FormatJSON:
+		return json.Marshal(doc)
+	default:
+		return nil, fmt.Errorf("unknown config format %q", format)
+	}
+}
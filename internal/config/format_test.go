@@ -0,0 +1,171 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFormatFromPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want Format
+	}{
+		{"config.json", FormatJSON},
+		{"hosts/devbox.yaml", FormatYAML},
+		{"devbox.YML", FormatYAML},
+		{"devbox.toml", FormatTOML},
+		{"config", FormatJSON},
+	}
+
+	for _, tt := range tests {
+		if got := FormatFromPath(tt.path); got != tt.want {
+			t.Errorf("FormatFromPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestConfigRoundTripFormats(t *testing.T) {
+	cfg := NewDefaultConfig()
+	cfg.DeploymentMode = ModeLocal
+	cfg.Components.Tailscale = false
+	cfg.Credentials.CodePassword = "code # not a comment"
+	cfg.Credentials.AnthropicKey = "sk-ant-test"
+	cfg.Advanced.CodeServerPort = 9443
+	cfg.Advanced.TSExtraArgs = "--advertise-tags=tag:doom-coding"
+	cfg.ExtraEnv = map[string]string{"HTTP_PROXY": "http://proxy:3128"}
+
+	for _, name := range []string{"config.json", "config.yaml", "config.toml"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			if err := cfg.SaveToFile(path); err != nil {
+				t.Fatal(err)
+			}
+			loaded, err := LoadFromFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(loaded, cfg) {
+				t.Errorf("Round trip changed the config:\n%+v\n%+v", cfg, loaded)
+			}
+		})
+	}
+}
+
+func TestLoadFromFileKeepsDefaults(t *testing.T) {
+	docs := map[string]string{
+		"partial.yaml": "deployment_mode: local\nenvironment:\n  timezone: Asia/Tokyo\n",
+		"partial.toml": "deployment_mode = \"local\"\n\n[environment]\ntimezone = \"Asia/Tokyo\"\n",
+	}
+
+	for name, doc := range docs {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			if err := os.WriteFile(path, []byte(doc), 0600); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := LoadFromFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.DeploymentMode != ModeLocal || cfg.Environment.Timezone != "Asia/Tokyo" {
+				t.Errorf("Values from the file were not loaded: %+v", cfg)
+			}
+			if cfg.Environment.PUID != "1000" || cfg.Advanced.CodeServerPort != 8443 {
+				t.Errorf("Missing fields should keep their defaults: %+v", cfg)
+			}
+		})
+	}
+}
+
+func TestSaveToFileKeepsYAMLComments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "devbox.yaml")
+	doc := `# Dev box in the office
+deployment_mode: local # No TUN device in this LXC
+environment:
+  # Matches the host user
+  puid: "1000"
+  timezone: Europe/Berlin
+`
+	if err := os.WriteFile(path, []byte(doc), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Environment.Timezone = "Asia/Tokyo"
+	if err := cfg.SaveToFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(data)
+	for _, want := range []string{
+		"# Dev box in the office",
+		"deployment_mode: local # No TUN device in this LXC",
+		"# Matches the host user",
+		`puid: "1000"`,
+		"timezone: Asia/Tokyo",
+		"code_server_port: 8443",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Saved YAML should contain %q:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "deployment_mode") > strings.Index(out, "components") {
+		t.Errorf("Existing keys should keep their position:\n%s", out)
+	}
+}
+
+func TestLoadFromFileInvalidYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "invalid.yaml")
+	if err := os.WriteFile(path, []byte("components: [docker"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadFromFile(path)
+	if err == nil || !strings.Contains(err.Error(), "failed to parse config file") {
+		t.Errorf("Expected a parse error, got %v", err)
+	}
+}
+
+// docExample returns the fenced code block of docs/TUI.md that starts with
+// first
+func docExample(t *testing.T, first string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "..", "docs", "TUI.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, block, ok := strings.Cut(string(data), "```yaml\n"+first+"\n")
+	if !ok {
+		t.Fatalf("No example starting with %q in docs/TUI.md", first)
+	}
+	block, _, _ = strings.Cut(block, "```")
+	return first + "\n" + block
+}
+
+func TestLoadDocumentedYAMLExample(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "devbox.yaml")
+	if err := os.WriteFile(path, []byte(docExample(t, "# Office dev box, no TUN device in this LXC")), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DeploymentMode != ModeLocal || !cfg.Components.Tailscale {
+		t.Errorf("Expected local mode with the default components, got %s %+v", cfg.DeploymentMode, cfg.Components)
+	}
+	if errs := cfg.Validate().Errors(); len(errs) > 0 {
+		t.Errorf("The documented example should be valid, got %v", errs)
+	}
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// SecretsFileFlag is the install.sh flag naming the file with the credentials
+const SecretsFileFlag = "--secrets-file"
+
+// secretFlags are the install.sh flags that take a credential. They still
+// work, but their values are visible in the process list.
+var secretFlags = []string{"--tailscale-key", "--code-password", "--sudo-password", "--anthropic-key"}
+
+// InstallSecrets holds the credentials for install.sh by the names its
+// secrets file uses: TAILSCALE_KEY, CODE_PASSWORD, SUDO_PASSWORD and
+// ANTHROPIC_KEY
+type InstallSecrets map[string]string
+
+// InstallSecrets returns the credentials of the configuration for install.sh
+func (c *Config) InstallSecrets() InstallSecrets {
+	return InstallSecrets{
+		"TAILSCALE_KEY": c.Credentials.TailscaleKey,
+		"CODE_PASSWORD": c.Credentials.CodePassword,
+		"SUDO_PASSWORD": c.Credentials.SudoPassword,
+		"ANTHROPIC_KEY": c.Credentials.AnthropicKey,
+	}
+}
+
+// Names returns the names of the secrets that are set
+func (s InstallSecrets) Names() []string {
+	var names []string
+	for name, value := range s {
+		if value != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WriteFile writes the secrets that are set to a new temporary file in dir,
+// or the default temporary directory when dir is empty, that only the
+// current user can read. Each secret is a NUL terminated KEY=value record,
+// so values may contain line breaks. WriteFile returns the flag that passes
+// the file to install.sh and a function that removes the file. When no
+// secret is set, no file is written and the flag is empty.
+func (s InstallSecrets) WriteFile(dir string) (flag string, cleanup func(), err error) {
+	names := s.Names()
+	if len(names) == 0 {
+		return "", func() {}, nil
+	}
+
+	var sb strings.Builder
+	for _, name := range names {
+		if strings.IndexByte(s[name], 0) >= 0 {
+			return "", nil, fmt.Errorf("%s contains a NUL byte", name)
+		}
+		sb.WriteString(name + "=" + s[name])
+		sb.WriteByte(0)
+	}
+
+	// CreateTemp creates the file with mode 0600
+	f, err := os.CreateTemp(dir, "doom-secrets-*")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create secrets file: %w", err)
+	}
+	cleanup = func() { os.Remove(f.Name()) }
+	if _, err := f.WriteString(sb.String()); err != nil {
+		f.Close()
+		cleanup()
+		return "", nil, fmt.Errorf("failed to write secrets file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to write secrets file: %w", err)
+	}
+	return SecretsFileFlag + "=" + f.Name(), cleanup, nil
+}
+
+// RedactFlags returns install.sh flags with the values of credential flags
+// replaced by ***, for showing commands to the user
+func RedactFlags(flags []string) []string {
+	redacted := make([]string, len(flags))
+	for i, flag := range flags {
+		redacted[i] = flag
+		name, _, ok := strings.Cut(flag, "=")
+		if !ok {
+			continue
+		}
+		for _, secret := range secretFlags {
+			if name == secret {
+				redacted[i] = name + "=***"
+			}
+		}
+	}
+	return redacted
+}
+
+// Redacted returns a copy of the configuration with the credentials that
+// are set replaced by ***, for previews
+func (c *Config) Redacted() *Config {
+	redacted := *c
+	for _, field := range redacted.credentialFields() {
+		if *field.value != "" {
+			*field.value = "***"
+		}
+	}
+	return &redacted
+}
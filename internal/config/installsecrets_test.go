@@ -0,0 +1,80 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInstallSecretsWriteFile(t *testing.T) {
+	cfg := NewDefaultConfig()
+	cfg.Credentials.CodePassword = "pass\nword $HOME"
+	cfg.Credentials.SudoPassword = "sudo-secret"
+	cfg.Credentials.AnthropicKey = "sk-ant-test"
+
+	flag, cleanup, err := cfg.InstallSecrets().WriteFile(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, ok := strings.CutPrefix(flag, SecretsFileFlag+"=")
+	if !ok {
+		t.Fatalf("Expected a %s flag, got %q", SecretsFileFlag, flag)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Secrets file mode = %v, want 0600", info.Mode().Perm())
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "ANTHROPIC_KEY=sk-ant-test\x00CODE_PASSWORD=pass\nword $HOME\x00SUDO_PASSWORD=sudo-secret\x00"; string(data) != want {
+		t.Errorf("Secrets file = %q, want %q", data, want)
+	}
+
+	cleanup()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("cleanup should remove the secrets file")
+	}
+}
+
+func TestInstallSecretsWriteFileEmpty(t *testing.T) {
+	dir := t.TempDir()
+	flag, cleanup, err := NewDefaultConfig().InstallSecrets().WriteFile(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cleanup()
+	if flag != "" {
+		t.Errorf("Without credentials there should be no flag, got %q", flag)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) > 0 {
+		t.Error("Without credentials no file should be written")
+	}
+}
+
+func TestRedactFlags(t *testing.T) {
+	flags := []string{"--unattended", "--code-password=secret", "--tailscale-key=tskey-auth-abc", "--env-file=prod.env"}
+	want := []string{"--unattended", "--code-password=***", "--tailscale-key=***", "--env-file=prod.env"}
+	if got := RedactFlags(flags); !reflect.DeepEqual(got, want) {
+		t.Errorf("RedactFlags() = %v, want %v", got, want)
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := NewDefaultConfig()
+	cfg.Credentials.CodePassword = "secret"
+
+	redacted := cfg.Redacted()
+	if redacted.Credentials.CodePassword != "***" || redacted.Credentials.TailscaleKey != "" {
+		t.Errorf("Only credentials that are set should be redacted, got %+v", redacted.Credentials)
+	}
+	if cfg.Credentials.CodePassword != "secret" {
+		t.Error("Redacted should not change the original")
+	}
+}
@@ -0,0 +1,288 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// SchemaVersion is the current version of the config document schema.
+// Version 1 is the unversioned format written before the version field was
+// added.
+const SchemaVersion = 2
+
+// ErrUnsupportedVersion is returned when a document is newer than this build understands
+var ErrUnsupportedVersion = errors.New("unsupported config schema version")
+
+// ChangeKind says what a migration did to a field
+type ChangeKind string
+
+const (
+	ChangeAdded   ChangeKind = "added"   // Missing field set to its default value
+	ChangeDropped ChangeKind = "dropped" // Unknown field removed
+)
+
+// Change is a field changed while migrating a document
+type Change struct {
+	Field  string // Dotted path, e.g. "advanced.ts_extra_args"
+	Kind   ChangeKind
+	Detail string
+}
+
+func (c Change) String() string {
+	if c.Detail == "" {
+		return fmt.Sprintf("%s: %s", c.Field, c.Kind)
+	}
+	return fmt.Sprintf("%s: %s %s", c.Field, c.Kind, c.Detail)
+}
+
+// MigrationReport lists the changes made to bring a document to SchemaVersion
+type MigrationReport struct {
+	FromVersion int
+	Changes     []Change
+}
+
+// Changed reports whether the document differed from the current schema
+func (r *MigrationReport) Changed() bool {
+	return r.FromVersion != SchemaVersion || len(r.Changes) > 0
+}
+
+// Migration upgrades a document from version From to From+1. Documents are
+// the generic maps decoded from JSON, YAML or TOML, so a migration works
+// for every format.
+type Migration struct {
+	From        int
+	Description string
+
+	// Added lists the dotted paths of the fields version From+1 introduced,
+	// which are reported when a document does not have them
+	Added []string
+
+	// Migrate rewrites the document, nil when adding fields is all it takes
+	Migrate func(doc map[string]any) ([]Change, error)
+}
+
+// migrations holds the registered migrations by the version they upgrade from
+var migrations = map[int]Migration{}
+
+// registerMigration adds a migration to the chain
+func registerMigration(m Migration) {
+	if _, exists := migrations[m.From]; exists {
+		panic(fmt.Sprintf("config: duplicate migration from version %d", m.From))
+	}
+	migrations[m.From] = m
+}
+
+func init() {
+	// Version 2 only added the version field, every other field was
+	// already in the unversioned format
+	registerMigration(Migration{
+		From:        1,
+		Description: "add the version field",
+	})
+}
+
+// addedFields reports the fields that are missing from doc. They get their
+// default values when the document is decoded.
+func addedFields(doc map[string]any, fields []string) []Change {
+	var changes []Change
+	for _, path := range fields {
+		if _, ok := lookupField(doc, path); ok {
+			continue
+		}
+		field, ok := defaultField(path)
+		if !ok {
+			panic(fmt.Sprintf("config: migration adds unknown field %s", path))
+		}
+		detail := fmt.Sprintf("with default %v", field.Interface())
+		if field.Kind() == reflect.String {
+			detail = fmt.Sprintf("with default %q", field.String())
+		}
+		changes = append(changes, Change{Field: path, Kind: ChangeAdded, Detail: detail})
+	}
+	return changes
+}
+
+// lookupField returns the value at a dotted path of doc
+func lookupField(doc map[string]any, path string) (any, bool) {
+	section, rest, nested := strings.Cut(path, ".")
+	value, ok := doc[section]
+	if !ok || !nested {
+		return value, ok
+	}
+	sub, ok := value.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	return lookupField(sub, rest)
+}
+
+// defaultField returns the default value of the field at a dotted path
+func defaultField(path string) (reflect.Value, bool) {
+	v := reflect.ValueOf(NewDefaultConfig()).Elem()
+	for _, name := range strings.Split(path, ".") {
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, false
+		}
+		found := false
+		for i := 0; i < v.NumField(); i++ {
+			if fieldName(v.Type().Field(i)) == name {
+				v, found = v.Field(i), true
+				break
+			}
+		}
+		if !found {
+			return reflect.Value{}, false
+		}
+	}
+	return v, true
+}
+
+// dropUnknown removes the keys of doc that are not fields of the struct
+// type t. Values are not reported as they may be secrets.
+func dropUnknown(doc map[string]any, t reflect.Type, prefix string) []Change {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		if name := fieldName(t.Field(i)); name != "" {
+			fields[name] = t.Field(i).Type
+		}
+	}
+
+	keys := make([]string, 0, len(doc))
+	for key := range doc {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var changes []Change
+	for _, key := range keys {
+		fieldType, ok := fields[key]
+		if !ok {
+			delete(doc, key)
+			changes = append(changes, Change{Field: prefix + key, Kind: ChangeDropped, Detail: "(unknown field)"})
+			continue
+		}
+		if section, ok := doc[key].(map[string]any); ok && fieldType.Kind() == reflect.Struct {
+			changes = append(changes, dropUnknown(section, fieldType, prefix+key+".")...)
+		}
+	}
+	return changes
+}
+
+// fieldName returns the document key of a struct field
+func fieldName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// documentVersion returns the schema version of doc, 1 when it has none
+func documentVersion(doc map[string]any) (int, error) {
+	value, ok := doc["version"]
+	if !ok {
+		return 1, nil
+	}
+	switch v := value.(type) {
+	case int:
+		return v, nil
+	case int64:
+		return int(v), nil
+	case float64:
+		if v == float64(int(v)) {
+			return int(v), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid config version %v", value)
+}
+
+// migrate upgrades doc to SchemaVersion one version at a time and removes
+// unknown fields
+func migrate(doc map[string]any) (*MigrationReport, error) {
+	version, err := documentVersion(doc)
+	if err != nil {
+		return nil, err
+	}
+	if version > SchemaVersion {
+		return nil, fmt.Errorf("%w: %d (this build supports up to %d)", ErrUnsupportedVersion, version, SchemaVersion)
+	}
+	if version < 1 {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, version)
+	}
+
+	report := &MigrationReport{FromVersion: version}
+	for v := version; v < SchemaVersion; v++ {
+		m, ok := migrations[v]
+		if !ok {
+			return nil, fmt.Errorf("no migration from config version %d", v)
+		}
+		report.Changes = append(report.Changes, addedFields(doc, m.Added)...)
+		if m.Migrate != nil {
+			changes, err := m.Migrate(doc)
+			if err != nil {
+				return nil, fmt.Errorf("failed to migrate config from version %d: %w", v, err)
+			}
+			report.Changes = append(report.Changes, changes...)
+		}
+		doc["version"] = v + 1
+	}
+
+	report.Changes = append(report.Changes, dropUnknown(doc, reflect.TypeOf(Config{}), "")...)
+	return report, nil
+}
+
+// Decode parses a configuration document of any supported schema version.
+// Older documents are migrated to SchemaVersion; the report lists the
+// changes that were made. SOPS encrypted documents are decrypted first.
+func Decode(data []byte, format Format) (*Config, *MigrationReport, error) {
+	data, encryption, err := decryptSOPS(data, format)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var doc map[string]any
+	if err := decodeDocument(data, format, &doc); err != nil {
+		return nil, nil, err
+	}
+	if doc == nil {
+		doc = map[string]any{}
+	}
+
+	report, err := migrate(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	if report.Changed() {
+		// Decode the migrated document, in the same format so that each
+		// decoder's conversions still apply
+		if data, err = encodeDocument(doc, format); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	config := NewDefaultConfig()
+	if err := decodeDocument(data, format, config); err != nil {
+		return nil, nil, err
+	}
+	config.encryption = encryption
+	return config, report, nil
+}
+
+// LoadAndMigrate loads a configuration file like LoadFromFile and also
+// returns the changes made to migrate it to the current schema version
+func LoadAndMigrate(path string) (*Config, *MigrationReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	config, report, err := Decode(data, FormatFromPath(path))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	return config, report, nil
+}
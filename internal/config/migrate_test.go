@@ -0,0 +1,109 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+// hasChange reports whether report contains a change of kind to field
+func hasChange(report *MigrationReport, field string, kind ChangeKind) bool {
+	for _, change := range report.Changes {
+		if change.Field == field && change.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDecodeMigratesUnversionedDocuments(t *testing.T) {
+	docs := map[Format]string{
+		FormatJSON: `{"deployment_mode": "local", "environment": {"puid": "1001"}, "advanced": {"code_server_port": 9443}}`,
+		FormatYAML: "deployment_mode: local\nenvironment:\n  puid: 1001\nadvanced:\n  code_server_port: 9443\n",
+		FormatTOML: "deployment_mode = \"local\"\n\n[environment]\npuid = \"1001\"\n\n[advanced]\ncode_server_port = 9443\n",
+	}
+
+	for format, doc := range docs {
+		t.Run(string(format), func(t *testing.T) {
+			cfg, report, err := Decode([]byte(doc), format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Version != SchemaVersion {
+				t.Errorf("Version = %d, want %d", cfg.Version, SchemaVersion)
+			}
+			if cfg.DeploymentMode != ModeLocal || cfg.Environment.PUID != "1001" || cfg.Advanced.CodeServerPort != 9443 {
+				t.Errorf("Values from the document were not kept: %+v", cfg)
+			}
+			if cfg.Environment.Timezone != "Europe/Berlin" {
+				t.Errorf("Missing fields should keep their defaults, got timezone %q", cfg.Environment.Timezone)
+			}
+
+			if report.FromVersion != 1 || !report.Changed() {
+				t.Errorf("Expected a migration from version 1, got %+v", report)
+			}
+			// Version 1 already had every field but version
+			if len(report.Changes) > 0 {
+				t.Errorf("Fields of version 1 should not be reported as added: %v", report.Changes)
+			}
+		})
+	}
+}
+
+func TestAddedFields(t *testing.T) {
+	doc := map[string]any{"advanced": map[string]any{"code_server_port": 9443}}
+
+	report := &MigrationReport{Changes: addedFields(doc, []string{"advanced.code_server_port", "advanced.ts_extra_args", "environment.timezone"})}
+	if hasChange(report, "advanced.code_server_port", ChangeAdded) {
+		t.Errorf("Fields in the document should not be reported: %v", report.Changes)
+	}
+	if !hasChange(report, "advanced.ts_extra_args", ChangeAdded) || !hasChange(report, "environment.timezone", ChangeAdded) {
+		t.Errorf("Missing fields should be reported: %v", report.Changes)
+	}
+	if got := report.Changes[len(report.Changes)-1].String(); got != `environment.timezone: added with default "Europe/Berlin"` {
+		t.Errorf("Unexpected change %q", got)
+	}
+}
+
+func TestDecodeDropsUnknownFields(t *testing.T) {
+	doc := `{"version": 2, "skill_level": "expert", "credentials": {"code_password": "secret", "old_token": "hunter2"}}`
+
+	cfg, report, err := Decode([]byte(doc), FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Credentials.CodePassword != "secret" {
+		t.Errorf("Known fields should be kept, got %+v", cfg.Credentials)
+	}
+	for _, field := range []string{"skill_level", "credentials.old_token"} {
+		if !hasChange(report, field, ChangeDropped) {
+			t.Errorf("Unknown field %s should be reported: %v", field, report.Changes)
+		}
+	}
+	for _, change := range report.Changes {
+		if change.String() == "" || change.Detail == "hunter2" {
+			t.Errorf("Changes should not contain values of dropped fields: %v", change)
+		}
+	}
+}
+
+func TestDecodeCurrentVersion(t *testing.T) {
+	data, err := NewDefaultConfig().Marshal(FormatYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, report, err := Decode(data, FormatYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Changed() {
+		t.Errorf("A current document should not be migrated: %+v", report)
+	}
+}
+
+func TestDecodeNewerVersion(t *testing.T) {
+	_, _, err := Decode([]byte(`{"version": 99}`), FormatJSON)
+	if !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("Expected ErrUnsupportedVersion, got %v", err)
+	}
+}
@@ -0,0 +1,250 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/doom-coding/doom-coding/internal/runner"
+)
+
+// Secret reference schemes. A credential starting with one of them and a
+// colon is a reference that ResolveSecrets replaces by the secret, so
+// configs can be shared without the secrets in them.
+const (
+	SecretEnv     = "env"     // env:NAME, an environment variable
+	SecretFile    = "file"    // file:PATH, the contents of a file
+	SecretCommand = "cmd"     // cmd:COMMAND, the output of a shell command
+	SecretAge     = "age"     // age:PATH, an age encrypted file
+	SecretKeyring = "keyring" // keyring:SERVICE/ACCOUNT, the OS keyring
+	SecretLiteral = "literal" // literal:VALUE, VALUE itself
+)
+
+// secretSchemes are the schemes that make a credential a reference
+var secretSchemes = map[string]bool{
+	SecretEnv: true, SecretFile: true, SecretCommand: true,
+	SecretAge: true, SecretKeyring: true, SecretLiteral: true,
+}
+
+// SecretResolver returns the secret a reference points to. ref is the part
+// after the scheme, e.g. "TS_AUTHKEY" for "env:TS_AUTHKEY".
+type SecretResolver interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// SecretResolverFunc adapts a function to a SecretResolver
+type SecretResolverFunc func(ctx context.Context, ref string) (string, error)
+
+// Resolve implements SecretResolver
+func (f SecretResolverFunc) Resolve(ctx context.Context, ref string) (string, error) {
+	return f(ctx, ref)
+}
+
+// SecretResolvers holds the resolver of each reference scheme. literal:
+// references need no resolver.
+type SecretResolvers map[string]SecretResolver
+
+// ParseSecretRef splits a credential into its scheme and reference. ok is
+// false for plain values.
+func ParseSecretRef(value string) (scheme, ref string, ok bool) {
+	scheme, ref, found := strings.Cut(value, ":")
+	if !found || !secretSchemes[scheme] {
+		return "", "", false
+	}
+	return scheme, ref, true
+}
+
+// QuoteSecret returns a credential that stands for value itself, escaping
+// values that would be taken for a reference
+func QuoteSecret(value string) string {
+	if _, _, ok := ParseSecretRef(value); ok {
+		return SecretLiteral + ":" + value
+	}
+	return value
+}
+
+// literalSecret returns the value of a credential that is not resolved
+// from elsewhere, and false for references whose value is not known yet
+func literalSecret(value string) (string, bool) {
+	scheme, ref, ok := ParseSecretRef(value)
+	switch {
+	case !ok:
+		return value, true
+	case scheme == SecretLiteral:
+		return ref, true
+	default:
+		return "", false
+	}
+}
+
+// credentialField is a credential and its document path
+type credentialField struct {
+	path  string
+	value *string
+}
+
+// credentialFields returns the credentials of c
+func (c *Config) credentialFields() []credentialField {
+	return []credentialField{
+		{"credentials.tailscale_key", &c.Credentials.TailscaleKey},
+		{"credentials.code_password", &c.Credentials.CodePassword},
+		{"credentials.sudo_password", &c.Credentials.SudoPassword},
+		{"credentials.anthropic_key", &c.Credentials.AnthropicKey},
+	}
+}
+
+// ResolveSecrets returns a copy of the configuration with the credential
+// references replaced by their secrets. References are only resolved here,
+// when the secrets are needed, and each one only once.
+func (c *Config) ResolveSecrets(ctx context.Context, resolvers SecretResolvers) (*Config, error) {
+	resolved := *c
+	cache := make(map[string]string)
+	for _, field := range resolved.credentialFields() {
+		scheme, ref, ok := ParseSecretRef(*field.value)
+		if !ok {
+			continue
+		}
+		if scheme == SecretLiteral {
+			*field.value = ref
+			continue
+		}
+		if value, ok := cache[*field.value]; ok {
+			*field.value = value
+			continue
+		}
+
+		resolver := resolvers[scheme]
+		if resolver == nil {
+			return nil, fmt.Errorf("%s: no resolver for %s: references", field.path, scheme)
+		}
+		value, err := resolver.Resolve(ctx, ref)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to resolve %s reference: %w", field.path, scheme, err)
+		}
+		cache[*field.value] = value
+		*field.value = value
+	}
+	return &resolved, nil
+}
+
+// unresolvedSecret returns an error for the first credential that is still
+// a reference, as those must not be written out
+func (c *Config) unresolvedSecret() error {
+	for _, field := range c.credentialFields() {
+		if _, _, ok := ParseSecretRef(*field.value); ok {
+			return fmt.Errorf("%s is a secret reference, resolve it with ResolveSecrets first", field.path)
+		}
+	}
+	return nil
+}
+
+// NewSecretResolvers returns the resolvers of the built-in schemes.
+// Relative file: and age: paths are taken from baseDir, usually the
+// project root, where cmd: commands also run; commands run through r, or
+// runner.Default when r is nil. Trailing line breaks are removed from
+// files and command output. age: files are decrypted with the identities
+// used for SOPS configs, see AgeKeyFile.
+func NewSecretResolvers(baseDir string, r runner.CommandRunner) SecretResolvers {
+	r = runner.OrDefault(r)
+	path := func(ref string) string {
+		if filepath.IsAbs(ref) {
+			return ref
+		}
+		return filepath.Join(baseDir, ref)
+	}
+
+	return SecretResolvers{
+		SecretEnv: SecretResolverFunc(func(_ context.Context, name string) (string, error) {
+			value, ok := os.LookupEnv(name)
+			if !ok {
+				return "", fmt.Errorf("environment variable %s is not set", name)
+			}
+			return value, nil
+		}),
+
+		SecretFile: SecretResolverFunc(func(_ context.Context, ref string) (string, error) {
+			data, err := os.ReadFile(path(ref))
+			if err != nil {
+				return "", err
+			}
+			return trimLineBreaks(data), nil
+		}),
+
+		SecretCommand: SecretResolverFunc(func(ctx context.Context, command string) (string, error) {
+			return commandOutput(ctx, r, runner.Command{Name: "sh", Args: []string{"-c", command}, Dir: baseDir})
+		}),
+
+		SecretAge: SecretResolverFunc(func(_ context.Context, ref string) (string, error) {
+			return decryptAgeFile(path(ref))
+		}),
+
+		SecretKeyring: SecretResolverFunc(func(ctx context.Context, ref string) (string, error) {
+			service, account, ok := strings.Cut(ref, "/")
+			if !ok || service == "" || account == "" {
+				return "", fmt.Errorf("keyring references are keyring:SERVICE/ACCOUNT")
+			}
+			switch runtime.GOOS {
+			case "darwin":
+				return commandOutput(ctx, r, runner.Command{Name: "security",
+					Args: []string{"find-generic-password", "-s", service, "-a", account, "-w"}})
+			case "windows":
+				return "", fmt.Errorf("the keyring is not supported on %s", runtime.GOOS)
+			default:
+				// libsecret, e.g. GNOME Keyring or KWallet
+				return commandOutput(ctx, r, runner.Command{Name: "secret-tool",
+					Args: []string{"lookup", "service", service, "account", account}})
+			}
+		}),
+	}
+}
+
+// commandOutput runs a command and returns its output without trailing
+// line breaks. Its error output is part of the error.
+func commandOutput(ctx context.Context, r runner.CommandRunner, cmd runner.Command) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := r.Run(ctx, cmd); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %w: %s", cmd.Name, err, msg)
+		}
+		return "", fmt.Errorf("%s: %w", cmd.Name, err)
+	}
+	return trimLineBreaks(stdout.Bytes()), nil
+}
+
+// decryptAgeFile decrypts a binary or armored age file
+func decryptAgeFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	identities, err := ageIdentities()
+	if err != nil {
+		return "", err
+	}
+
+	var in io.Reader = bytes.NewReader(data)
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte(armor.Header)) {
+		in = armor.NewReader(bytes.NewReader(bytes.TrimSpace(data)))
+	}
+	r, err := age.Decrypt(in, identities...)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	return trimLineBreaks(plaintext), nil
+}
+
+func trimLineBreaks(data []byte) string {
+	return strings.TrimRight(string(data), "\r\n")
+}
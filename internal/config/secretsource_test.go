@@ -0,0 +1,218 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/doom-coding/doom-coding/internal/runner/runnertest"
+)
+
+func TestParseSecretRef(t *testing.T) {
+	tests := []struct {
+		value, scheme, ref string
+		ok                 bool
+	}{
+		{"env:TS_AUTHKEY", SecretEnv, "TS_AUTHKEY", true},
+		{"cmd:pass show doom/ts", SecretCommand, "pass show doom/ts", true},
+		{"literal:env:x", SecretLiteral, "env:x", true},
+		{"tskey-auth-abc", "", "", false},
+		{"https://example.com", "", "", false},
+		{"Env:TS_AUTHKEY", "", "", false},
+	}
+	for _, tt := range tests {
+		scheme, ref, ok := ParseSecretRef(tt.value)
+		if scheme != tt.scheme || ref != tt.ref || ok != tt.ok {
+			t.Errorf("ParseSecretRef(%q) = %q, %q, %v", tt.value, scheme, ref, ok)
+		}
+	}
+
+	if QuoteSecret("file:/etc/passwd") != "literal:file:/etc/passwd" || QuoteSecret("s3cret:x") != "s3cret:x" {
+		t.Error("QuoteSecret should only escape values that look like references")
+	}
+}
+
+func TestResolveSecrets(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "code.txt"), []byte("code-secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DOOM_TEST_TS_KEY", "tskey-auth-env")
+
+	fake := runnertest.New()
+	fake.On("sh", "-c", "pass show doom/sudo").Returns("sudo-secret\n")
+
+	cfg := NewDefaultConfig()
+	cfg.Credentials = Credentials{
+		TailscaleKey: "env:DOOM_TEST_TS_KEY",
+		CodePassword: "file:code.txt",
+		SudoPassword: "cmd:pass show doom/sudo",
+		AnthropicKey: "literal:sk-ant-literal",
+	}
+
+	resolved, err := cfg.ResolveSecrets(context.Background(), NewSecretResolvers(dir, fake))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Credentials{
+		TailscaleKey: "tskey-auth-env",
+		CodePassword: "code-secret",
+		SudoPassword: "sudo-secret",
+		AnthropicKey: "sk-ant-literal",
+	}
+	if resolved.Credentials != want {
+		t.Errorf("Resolved %+v, want %+v", resolved.Credentials, want)
+	}
+	if cfg.Credentials.TailscaleKey != "env:DOOM_TEST_TS_KEY" {
+		t.Error("ResolveSecrets should not change the config itself")
+	}
+	if calls := fake.Calls(); len(calls) != 1 || calls[0].Dir != dir {
+		t.Errorf("Commands should run once in the base directory, got %+v", calls)
+	}
+}
+
+func TestResolveSecretsOnce(t *testing.T) {
+	fake := runnertest.New()
+	fake.On("sh", "-c", "pass show doom/code").Returns("Shared-Secret-1")
+
+	cfg := NewDefaultConfig()
+	cfg.Credentials.CodePassword = "cmd:pass show doom/code"
+	cfg.Credentials.SudoPassword = "cmd:pass show doom/code"
+
+	resolved, err := cfg.ResolveSecrets(context.Background(), NewSecretResolvers("", fake))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved.Credentials.SudoPassword != "Shared-Secret-1" || len(fake.Calls()) != 1 {
+		t.Errorf("A reference should be resolved once, got %d calls", len(fake.Calls()))
+	}
+}
+
+func TestResolveSecretsAge(t *testing.T) {
+	recipient := useAgeKey(t)
+	r, err := age.ParseX25519Recipient(recipient)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	aw := armor.NewWriter(&buf)
+	w, err := age.Encrypt(aw, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("tskey-auth-age\n"))
+	w.Close()
+	aw.Close()
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "secrets"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "secrets", "ts.age"), buf.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := NewDefaultConfig()
+	cfg.Credentials.TailscaleKey = "age:secrets/ts.age"
+	resolved, err := cfg.ResolveSecrets(context.Background(), NewSecretResolvers(dir, runnertest.New()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved.Credentials.TailscaleKey != "tskey-auth-age" {
+		t.Errorf("TailscaleKey = %q, want tskey-auth-age", resolved.Credentials.TailscaleKey)
+	}
+}
+
+func TestResolveSecretsKeyring(t *testing.T) {
+	fake := runnertest.New()
+	fake.On("secret-tool", "lookup", "service", "doom-coding", "account", "anthropic").Returns("sk-ant-keyring")
+	fake.On("security", "find-generic-password", "-s", "doom-coding", "-a", "anthropic", "-w").Returns("sk-ant-keyring\n")
+
+	cfg := NewDefaultConfig()
+	cfg.Credentials.AnthropicKey = "keyring:doom-coding/anthropic"
+	resolved, err := cfg.ResolveSecrets(context.Background(), NewSecretResolvers("", fake))
+	if err != nil {
+		t.Skipf("No keyring on this platform: %v", err)
+	}
+	if resolved.Credentials.AnthropicKey != "sk-ant-keyring" {
+		t.Errorf("AnthropicKey = %q, want sk-ant-keyring", resolved.Credentials.AnthropicKey)
+	}
+}
+
+func TestResolveSecretsErrors(t *testing.T) {
+	fake := runnertest.New()
+	fake.On("sh", "-c", "pass show doom/missing").Stderr("Error: doom/missing is not in the password store.\n").Exit(1)
+
+	tests := map[string]struct {
+		value     string
+		resolvers SecretResolvers
+		want      string
+	}{
+		"unset variable": {"env:DOOM_TEST_UNSET", NewSecretResolvers("", fake), "DOOM_TEST_UNSET is not set"},
+		"missing file":   {"file:/nonexistent/secret", NewSecretResolvers("", fake), "no such file"},
+		"failed command": {"cmd:pass show doom/missing", NewSecretResolvers("", fake), "not in the password store"},
+		"bad keyring":    {"keyring:doom-coding", NewSecretResolvers("", fake), "keyring:SERVICE/ACCOUNT"},
+		"no resolver":    {"env:HOME", SecretResolvers{}, "no resolver for env: references"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := NewDefaultConfig()
+			cfg.Credentials.CodePassword = tt.value
+			_, err := cfg.ResolveSecrets(context.Background(), tt.resolvers)
+			if err == nil || !strings.Contains(err.Error(), tt.want) || !strings.Contains(err.Error(), "credentials.code_password") {
+				t.Errorf("Expected an error for credentials.code_password with %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestSecretRefsValidateAndWrite(t *testing.T) {
+	cfg := NewDefaultConfig()
+	cfg.Credentials = Credentials{
+		TailscaleKey: "env:TS_AUTHKEY",
+		CodePassword: "cmd:pass show doom/code",
+		SudoPassword: "literal:short",
+		AnthropicKey: "file:",
+	}
+
+	problems := cfg.Validate()
+	for _, field := range []string{"credentials.tailscale_key", "credentials.code_password"} {
+		if p := problems.ForField(field); len(p) > 0 {
+			t.Errorf("References should be checked once resolved, got %v", p)
+		}
+	}
+	if p := problems.ForField("credentials.sudo_password"); len(p) == 0 || p[0].Code != CodeTooShort {
+		t.Errorf("literal: values should be validated, got %v", p)
+	}
+	if p := problems.ForField("credentials.anthropic_key"); len(p) == 0 || p[0].Code != CodeInvalidFormat {
+		t.Errorf("Empty references should be reported, got %v", p)
+	}
+
+	if _, err := cfg.WriteSecrets(t.TempDir()); err == nil || !strings.Contains(err.Error(), "credentials.tailscale_key") {
+		t.Errorf("Unresolved references should not be written to secret files, got %v", err)
+	}
+}
+
+func TestImportDotEnvQuotesReferences(t *testing.T) {
+	cfg := NewDefaultConfig()
+	if err := cfg.ImportDotEnv(map[string]string{"CODE_SERVER_PASSWORD": "cmd:rm -rf ~"}); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Credentials.CodePassword != "literal:cmd:rm -rf ~" {
+		t.Errorf("Values from .env should never be references, got %q", cfg.Credentials.CodePassword)
+	}
+
+	resolved, err := cfg.ResolveSecrets(context.Background(), SecretResolvers{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved.Credentials.CodePassword != "cmd:rm -rf ~" {
+		t.Errorf("CodePassword = %q, want the value from .env", resolved.Credentials.CodePassword)
+	}
+}
@@ -0,0 +1,763 @@
+package config
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// SOPS encrypted documents keep their structure: each value is replaced by
+// its AES-256-GCM ciphertext under a random data key, and a "sops" key holds
+// the data key encrypted for every age recipient and a MAC over all values.
+// This file implements the parts of the format needed for age keys, so
+// configs encrypted with setup-secrets.sh can be read without the sops
+// binary and configs written here can be edited with sops.
+
+const (
+	sopsKey = "sops"
+
+	// sopsVersion is the format version written to the metadata, that of
+	// the sops release installed by setup-secrets.sh
+	sopsVersion = "3.8.1"
+
+	// credentialsRegex selects the values encrypted by SetAgeRecipients
+	credentialsRegex = "^credentials$"
+)
+
+// ErrNoAgeIdentity is returned when no age identity can decrypt a config
+var ErrNoAgeIdentity = errors.New("no matching age identity")
+
+// sopsValue matches an encrypted value
+var sopsValue = regexp.MustCompile(`^ENC\[AES256_GCM,data:(.+),iv:(.+),tag:(.+),type:(.+)\]$`)
+
+// sopsMetadata is the "sops" key of an encrypted document
+type sopsMetadata struct {
+	Age          []sopsAgeKey `yaml:"age"`
+	LastModified string       `yaml:"lastmodified"`
+	MAC          string       `yaml:"mac"`
+	Rules        sopsRules    `yaml:",inline"`
+	Version      string       `yaml:"version"`
+}
+
+// sopsAgeKey is the data key encrypted for an age recipient
+type sopsAgeKey struct {
+	Recipient string `yaml:"recipient"`
+	Enc       string `yaml:"enc"`
+}
+
+// sopsRules select the values that are encrypted. sops sets at most one of
+// the suffixes and regular expressions; without any, all values are.
+type sopsRules struct {
+	UnencryptedSuffix string `yaml:"unencrypted_suffix,omitempty"`
+	EncryptedSuffix   string `yaml:"encrypted_suffix,omitempty"`
+	UnencryptedRegex  string `yaml:"unencrypted_regex,omitempty"`
+	EncryptedRegex    string `yaml:"encrypted_regex,omitempty"`
+	MACOnlyEncrypted  bool   `yaml:"mac_only_encrypted,omitempty"`
+}
+
+// sopsEncryption says how SaveToFile encrypts a config
+type sopsEncryption struct {
+	recipients []string
+	rules      sopsRules
+}
+
+// SetAgeRecipients makes SaveToFile encrypt the credentials for the given
+// age recipients ("age1..."), in the SOPS format. Without recipients the
+// config is saved in plaintext. A config loaded from an encrypted file is
+// saved encrypted for the same recipients, and keeps the rules the file was
+// encrypted with.
+func (c *Config) SetAgeRecipients(recipients ...string) error {
+	if len(recipients) == 0 {
+		c.encryption = nil
+		return nil
+	}
+	for _, r := range recipients {
+		if _, err := age.ParseX25519Recipient(r); err != nil {
+			return fmt.Errorf("invalid age recipient %q: %w", r, err)
+		}
+	}
+
+	rules := sopsRules{EncryptedRegex: credentialsRegex}
+	if c.encryption != nil {
+		rules = c.encryption.rules
+	}
+	c.encryption = &sopsEncryption{recipients: recipients, rules: rules}
+	return nil
+}
+
+// AgeRecipients returns the age recipients SaveToFile encrypts for, none
+// when the config is saved in plaintext
+func (c *Config) AgeRecipients() []string {
+	if c.encryption == nil {
+		return nil
+	}
+	return append([]string(nil), c.encryption.recipients...)
+}
+
+// AgeKeyFile returns the path of the age identities used to decrypt
+// configs: SOPS_AGE_KEY_FILE, or else sops/age/keys.txt in the user config
+// directory, where setup-secrets.sh generates the key
+func AgeKeyFile() (string, error) {
+	if path := os.Getenv("SOPS_AGE_KEY_FILE"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the age key file: %w", err)
+	}
+	return filepath.Join(dir, "sops", "age", "keys.txt"), nil
+}
+
+// ageIdentities loads the identities of SOPS_AGE_KEY and the AgeKeyFile
+func ageIdentities() ([]age.Identity, error) {
+	var identities []age.Identity
+	if keys := os.Getenv("SOPS_AGE_KEY"); keys != "" {
+		ids, err := age.ParseIdentities(strings.NewReader(keys))
+		if err != nil {
+			return nil, fmt.Errorf("invalid SOPS_AGE_KEY: %w", err)
+		}
+		identities = append(identities, ids...)
+	}
+
+	path, err := AgeKeyFile()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		if len(identities) > 0 {
+			return identities, nil
+		}
+		return nil, fmt.Errorf("%w: %s does not exist (set SOPS_AGE_KEY_FILE)", ErrNoAgeIdentity, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read age key file: %w", err)
+	}
+	defer f.Close()
+
+	ids, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid age key file %s: %w", path, err)
+	}
+	return append(identities, ids...), nil
+}
+
+// decryptSOPS returns the plaintext of a SOPS encrypted document in the
+// same format and how it was encrypted. Other documents are returned as
+// they are, with no encryption.
+func decryptSOPS(data []byte, format Format) ([]byte, *sopsEncryption, error) {
+	if !bytes.Contains(data, []byte(sopsKey)) {
+		return data, nil, nil
+	}
+	if format == FormatTOML {
+		var doc map[string]any
+		if _, err := toml.Decode(string(data), &doc); err == nil && doc[sopsKey] != nil {
+			return nil, nil, fmt.Errorf("SOPS encryption is not supported for TOML configs")
+		}
+		return data, nil, nil
+	}
+
+	doc, err := parseSOPSTree(data, format)
+	if err != nil {
+		return data, nil, nil // Reported by the caller's decoder
+	}
+	root := doc.Content[0]
+	metaNode := mappingValue(root, sopsKey)
+	if metaNode == nil || metaNode.Kind != yaml.MappingNode {
+		return data, nil, nil
+	}
+
+	var meta sopsMetadata
+	if err := metaNode.Decode(&meta); err != nil {
+		return nil, nil, fmt.Errorf("invalid sops metadata: %w", err)
+	}
+	encrypts, err := meta.Rules.matcher()
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := meta.dataKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mac := sha512.New()
+	err = walkSOPS(root, nil, func(_, value *yaml.Node, path []string) error {
+		encrypted := encrypts(path)
+		if encrypted && value.Tag == "!!str" && value.Value != "" {
+			plaintext, typ, err := decryptValue(key, value.Value, sopsPath(path))
+			if err != nil {
+				return fmt.Errorf("%s: %w", strings.Join(path, "."), err)
+			}
+			if err := setScalar(value, plaintext, typ); err != nil {
+				return fmt.Errorf("%s: %w", strings.Join(path, "."), err)
+			}
+		} else if encrypted && value.Tag != "!!str" && value.Tag != "!!null" {
+			return fmt.Errorf("%s: value is not encrypted", strings.Join(path, "."))
+		}
+		if encrypted || !meta.Rules.MACOnlyEncrypted {
+			return hashValue(mac, value)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decrypt config: %w", err)
+	}
+	if err := meta.verifyMAC(key, mac); err != nil {
+		return nil, nil, err
+	}
+
+	removeMappingKey(root, sopsKey)
+	if data, err = encodeSOPSTree(doc, format); err != nil {
+		return nil, nil, err
+	}
+
+	encryption := &sopsEncryption{rules: meta.Rules}
+	for _, k := range meta.Age {
+		encryption.recipients = append(encryption.recipients, k.Recipient)
+	}
+	return data, encryption, nil
+}
+
+// encryptSOPS encrypts a plaintext document for the recipients of enc
+func encryptSOPS(data []byte, format Format, enc *sopsEncryption) ([]byte, error) {
+	if format != FormatJSON && format != FormatYAML {
+		return nil, fmt.Errorf("SOPS encryption is not supported for %s configs", strings.ToUpper(string(format)))
+	}
+	doc, err := parseSOPSTree(data, format)
+	if err != nil {
+		return nil, err
+	}
+	root := doc.Content[0]
+	encrypts, err := enc.rules.matcher()
+	if err != nil {
+		return nil, err
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	mac := sha512.New()
+	err = walkSOPS(root, nil, func(keyNode, value *yaml.Node, path []string) error {
+		encrypted := encrypts(path)
+		if encrypted || !enc.rules.MACOnlyEncrypted {
+			if err := hashValue(mac, value); err != nil {
+				return err
+			}
+		}
+		if !encrypted {
+			return nil
+		}
+		// Comments in encrypted sections could hold secrets too
+		value.HeadComment, value.LineComment, value.FootComment = "", "", ""
+		if keyNode != nil && encrypts(path[:len(path)-1]) {
+			keyNode.HeadComment, keyNode.LineComment, keyNode.FootComment = "", "", ""
+		}
+
+		plaintext, typ, err := scalarPlaintext(value)
+		if err != nil || typ == "" || len(plaintext) == 0 && typ == "str" {
+			return err // Nulls and empty strings are not encrypted
+		}
+		ciphertext, err := encryptValue(key, plaintext, typ, sopsPath(path))
+		if err != nil {
+			return err
+		}
+		*value = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: ciphertext}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt config: %w", err)
+	}
+
+	meta := sopsMetadata{
+		LastModified: time.Now().UTC().Format(time.RFC3339),
+		Rules:        enc.rules,
+		Version:      sopsVersion,
+	}
+	for _, r := range enc.recipients {
+		k, err := encryptDataKey(key, r)
+		if err != nil {
+			return nil, err
+		}
+		meta.Age = append(meta.Age, k)
+	}
+	if meta.MAC, err = encryptValue(key, []byte(fmt.Sprintf("%X", mac.Sum(nil))), "str", meta.LastModified); err != nil {
+		return nil, err
+	}
+
+	var metaNode yaml.Node
+	if err := metaNode.Encode(&meta); err != nil {
+		return nil, err
+	}
+	removeMappingKey(root, sopsKey)
+	root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: sopsKey}, &metaNode)
+	return encodeSOPSTree(doc, format)
+}
+
+// matcher returns a function reporting whether the value at a path is
+// encrypted under the rules
+func (r sopsRules) matcher() (func(path []string) bool, error) {
+	var unencrypted, encrypted *regexp.Regexp
+	var err error
+	if r.UnencryptedRegex != "" {
+		if unencrypted, err = regexp.Compile(r.UnencryptedRegex); err != nil {
+			return nil, fmt.Errorf("invalid unencrypted_regex: %w", err)
+		}
+	}
+	if r.EncryptedRegex != "" {
+		if encrypted, err = regexp.Compile(r.EncryptedRegex); err != nil {
+			return nil, fmt.Errorf("invalid encrypted_regex: %w", err)
+		}
+	}
+
+	anyKey := func(path []string, match func(string) bool) bool {
+		for _, key := range path {
+			if match(key) {
+				return true
+			}
+		}
+		return false
+	}
+	return func(path []string) bool {
+		switch {
+		case r.UnencryptedSuffix != "":
+			return !anyKey(path, func(k string) bool { return strings.HasSuffix(k, r.UnencryptedSuffix) })
+		case r.EncryptedSuffix != "":
+			return anyKey(path, func(k string) bool { return strings.HasSuffix(k, r.EncryptedSuffix) })
+		case unencrypted != nil:
+			return !anyKey(path, unencrypted.MatchString)
+		case encrypted != nil:
+			return anyKey(path, encrypted.MatchString)
+		default:
+			return true
+		}
+	}, nil
+}
+
+// dataKey decrypts the data key with the first age identity that matches
+// one of the recipients
+func (m *sopsMetadata) dataKey() ([]byte, error) {
+	if len(m.Age) == 0 {
+		return nil, fmt.Errorf("config is not encrypted with age, decrypt it with sops")
+	}
+	identities, err := ageIdentities()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, k := range m.Age {
+		r, err := age.Decrypt(armor.NewReader(strings.NewReader(k.Enc)), identities...)
+		if err != nil {
+			continue
+		}
+		key, err := io.ReadAll(r)
+		if err == nil && len(key) == 32 {
+			return key, nil
+		}
+	}
+	recipients := make([]string, len(m.Age))
+	for i, k := range m.Age {
+		recipients[i] = k.Recipient
+	}
+	return nil, fmt.Errorf("%w: config is encrypted for %s", ErrNoAgeIdentity, strings.Join(recipients, ", "))
+}
+
+// verifyMAC checks the MAC of the metadata against the one computed over
+// the decrypted values
+func (m *sopsMetadata) verifyMAC(key []byte, mac hash.Hash) error {
+	lastModified, err := time.Parse(time.RFC3339, m.LastModified)
+	if err != nil {
+		return fmt.Errorf("invalid sops lastmodified %q", m.LastModified)
+	}
+	stored, _, err := decryptValue(key, m.MAC, lastModified.Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("failed to decrypt the sops MAC: %w", err)
+	}
+	if !hmac.Equal(stored, []byte(fmt.Sprintf("%X", mac.Sum(nil)))) {
+		return fmt.Errorf("config MAC mismatch: the encrypted file was modified")
+	}
+	return nil
+}
+
+// encryptDataKey encrypts the data key for an age recipient
+func encryptDataKey(key []byte, recipient string) (sopsAgeKey, error) {
+	r, err := age.ParseX25519Recipient(recipient)
+	if err != nil {
+		return sopsAgeKey{}, fmt.Errorf("invalid age recipient %q: %w", recipient, err)
+	}
+
+	var buf bytes.Buffer
+	aw := armor.NewWriter(&buf)
+	w, err := age.Encrypt(aw, r)
+	if err != nil {
+		return sopsAgeKey{}, err
+	}
+	if _, err := w.Write(key); err != nil {
+		return sopsAgeKey{}, err
+	}
+	if err := w.Close(); err != nil {
+		return sopsAgeKey{}, err
+	}
+	if err := aw.Close(); err != nil {
+		return sopsAgeKey{}, err
+	}
+	return sopsAgeKey{Recipient: recipient, Enc: buf.String()}, nil
+}
+
+// sopsGCM returns the AES-GCM cipher sops uses, with 32 byte nonces
+func sopsGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCMWithNonceSize(block, 32)
+}
+
+// encryptValue encrypts a value of a sops type (str, int, float or bool)
+func encryptValue(key, plaintext []byte, typ, additionalData string) (string, error) {
+	gcm, err := sopsGCM(key)
+	if err != nil {
+		return "", err
+	}
+	iv := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(iv); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nil, iv, plaintext, []byte(additionalData))
+	data, tag := sealed[:len(sealed)-gcm.Overhead()], sealed[len(sealed)-gcm.Overhead():]
+	enc := base64.StdEncoding.EncodeToString
+	return fmt.Sprintf("ENC[AES256_GCM,data:%s,iv:%s,tag:%s,type:%s]", enc(data), enc(iv), enc(tag), typ), nil
+}
+
+// decryptValue decrypts an ENC[...] value and returns it with its type
+func decryptValue(key []byte, value, additionalData string) ([]byte, string, error) {
+	m := sopsValue.FindStringSubmatch(value)
+	if m == nil {
+		return nil, "", fmt.Errorf("value is not encrypted")
+	}
+	var parts [3][]byte
+	for i := range parts {
+		var err error
+		if parts[i], err = base64.StdEncoding.DecodeString(m[i+1]); err != nil {
+			return nil, "", fmt.Errorf("invalid encrypted value: %w", err)
+		}
+	}
+	data, iv, tag := parts[0], parts[1], parts[2]
+
+	gcm, err := sopsGCM(key)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(iv) != gcm.NonceSize() {
+		return nil, "", fmt.Errorf("invalid encrypted value: IV of %d bytes", len(iv))
+	}
+	plaintext, err := gcm.Open(nil, iv, append(data, tag...), []byte(additionalData))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decrypt value: %w", err)
+	}
+	return plaintext, m[4], nil
+}
+
+// sopsPath returns the additional data a value is encrypted with
+func sopsPath(path []string) string {
+	return strings.Join(path, ":") + ":"
+}
+
+// scalarPlaintext returns the bytes sops encrypts and hashes for a scalar
+// and its sops type. Nulls have no type and are neither encrypted nor
+// hashed.
+func scalarPlaintext(n *yaml.Node) ([]byte, string, error) {
+	switch n.Tag {
+	case "!!str":
+		return []byte(n.Value), "str", nil
+	case "!!int":
+		var v int
+		if err := n.Decode(&v); err != nil {
+			return nil, "", err
+		}
+		return []byte(strconv.Itoa(v)), "int", nil
+	case "!!float":
+		var v float64
+		if err := n.Decode(&v); err != nil {
+			return nil, "", err
+		}
+		return []byte(strconv.FormatFloat(v, 'f', -1, 64)), "float", nil
+	case "!!bool":
+		var v bool
+		if err := n.Decode(&v); err != nil {
+			return nil, "", err
+		}
+		// sops writes booleans the way its first, Python version did
+		if v {
+			return []byte("True"), "bool", nil
+		}
+		return []byte("False"), "bool", nil
+	case "!!null":
+		return nil, "", nil
+	default:
+		return nil, "", fmt.Errorf("unsupported value of type %s", n.Tag)
+	}
+}
+
+// hashValue adds a scalar to the MAC
+func hashValue(mac hash.Hash, n *yaml.Node) error {
+	plaintext, _, err := scalarPlaintext(n)
+	if err != nil {
+		return err
+	}
+	mac.Write(plaintext)
+	return nil
+}
+
+// setScalar sets a node to a decrypted value of a sops type
+func setScalar(n *yaml.Node, plaintext []byte, typ string) error {
+	value := string(plaintext)
+	var tag string
+	switch typ {
+	case "str", "bytes":
+		tag = "!!str"
+	case "int":
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("invalid int value")
+		}
+		tag = "!!int"
+	case "float":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("invalid float value")
+		}
+		tag = "!!float"
+	case "bool":
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid bool value")
+		}
+		value, tag = strconv.FormatBool(v), "!!bool"
+	default:
+		return fmt.Errorf("unsupported value type %q", typ)
+	}
+	*n = yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value}
+	return nil
+}
+
+// walkSOPS calls fn for every scalar below node in document order, with
+// the mapping key it belongs to (nil in sequences) and the path of keys
+// leading to it. The sops metadata is skipped.
+func walkSOPS(node *yaml.Node, path []string, fn func(key, value *yaml.Node, path []string) error) error {
+	var walk func(key, value *yaml.Node, path []string) error
+	walk = func(key, value *yaml.Node, path []string) error {
+		switch value.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(value.Content); i += 2 {
+				k := value.Content[i]
+				if len(path) == 0 && k.Value == sopsKey {
+					continue
+				}
+				if err := walk(k, value.Content[i+1], append(path[:len(path):len(path)], k.Value)); err != nil {
+					return err
+				}
+			}
+		case yaml.SequenceNode:
+			for _, item := range value.Content {
+				if err := walk(nil, item, path); err != nil {
+					return err
+				}
+			}
+		case yaml.ScalarNode:
+			return fn(key, value, path)
+		default:
+			return fmt.Errorf("%s: YAML aliases are not supported in encrypted configs", strings.Join(path, "."))
+		}
+		return nil
+	}
+	return walk(nil, node, path)
+}
+
+// mappingValue returns the value of key in a mapping node
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// removeMappingKey removes key from a mapping node
+func removeMappingKey(mapping *yaml.Node, key string) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+// parseSOPSTree parses a JSON or YAML document into a node tree that keeps
+// the key order, which the MAC depends on
+func parseSOPSTree(data []byte, format Format) (*yaml.Node, error) {
+	var doc *yaml.Node
+	switch format {
+	case FormatYAML:
+		doc = &yaml.Node{}
+		if err := yaml.Unmarshal(data, doc); err != nil {
+			return nil, err
+		}
+	case FormatJSON:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		root, err := parseJSONNode(dec)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := dec.Token(); err != io.EOF {
+			return nil, fmt.Errorf("unexpected data after the JSON document")
+		}
+		doc = &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}}
+	default:
+		return nil, fmt.Errorf("unknown config format %q", format)
+	}
+
+	if len(doc.Content) != 1 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("config document is not a mapping")
+	}
+	return doc, nil
+}
+
+// parseJSONNode parses the next JSON value of dec into a node
+func parseJSONNode(dec *json.Decoder) (*yaml.Node, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	scalar := func(tag, value string) *yaml.Node {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value}
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		if t == '{' {
+			node = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+		for dec.More() {
+			if t == '{' {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				node.Content = append(node.Content, scalar("!!str", key.(string)))
+			}
+			value, err := parseJSONNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, value)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return node, nil
+	case string:
+		return scalar("!!str", t), nil
+	case json.Number:
+		if _, err := t.Int64(); err == nil {
+			return scalar("!!int", t.String()), nil
+		}
+		return scalar("!!float", t.String()), nil
+	case bool:
+		return scalar("!!bool", strconv.FormatBool(t)), nil
+	default:
+		return scalar("!!null", "null"), nil
+	}
+}
+
+// encodeSOPSTree encodes a node tree in the given format
+func encodeSOPSTree(doc *yaml.Node, format Format) ([]byte, error) {
+	var buf bytes.Buffer
+	if format == FormatJSON {
+		if err := writeJSONNode(&buf, doc.Content[0], ""); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeJSONNode writes a node as indented JSON, keeping the key order
+func writeJSONNode(buf *bytes.Buffer, n *yaml.Node, indent string) error {
+	switch n.Kind {
+	case yaml.MappingNode, yaml.SequenceNode:
+		open, close, step := "[", "]", 1
+		if n.Kind == yaml.MappingNode {
+			open, close, step = "{", "}", 2
+		}
+		if len(n.Content) == 0 {
+			buf.WriteString(open + close)
+			return nil
+		}
+		buf.WriteString(open + "\n")
+		for i := 0; i < len(n.Content); i += step {
+			if i > 0 {
+				buf.WriteString(",\n")
+			}
+			buf.WriteString(indent + "  ")
+			value := n.Content[i]
+			if step == 2 {
+				key, _ := json.Marshal(value.Value)
+				buf.Write(key)
+				buf.WriteString(": ")
+				value = n.Content[i+1]
+			}
+			if err := writeJSONNode(buf, value, indent+"  "); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("\n" + indent + close)
+		return nil
+	case yaml.ScalarNode:
+		var v any
+		if err := n.Decode(&v); err != nil {
+			return err
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+		return nil
+	default:
+		return fmt.Errorf("cannot write YAML node kind %d as JSON", n.Kind)
+	}
+}
@@ -0,0 +1,7 @@
+#!/bin/bash
+# Encrypts config.yaml into config.sops.yaml with sops and the test only
+# age key in keys.txt, for the tests that read files written by sops
+set -euo pipefail
+
+cd "$(dirname "$0")"
+SOPS_AGE_KEY_FILE=keys.txt sops --encrypt config.yaml > config.sops.yaml
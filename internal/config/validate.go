@@ -0,0 +1,369 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // Check timezones on hosts without a zoneinfo database
+	"unicode"
+
+	"github.com/doom-coding/doom-coding/internal/service"
+)
+
+// Severity says whether a validation problem blocks the installation
+type Severity string
+
+const (
+	SeverityError   Severity = "error"   // The configuration cannot be installed
+	SeverityWarning Severity = "warning" // Installable, but probably not what was meant
+)
+
+// Validation error codes
+const (
+	CodeRequired        = "required"
+	CodeInvalidValue    = "invalid_value"
+	CodeInvalidFormat   = "invalid_format"
+	CodeOutOfRange      = "out_of_range"
+	CodePrivilegedPort  = "privileged_port"
+	CodePortConflict    = "port_conflict"
+	CodeNotNumeric      = "not_numeric"
+	CodeUnknownID       = "unknown_id"
+	CodeRootID          = "root_id"
+	CodeInvalidTimezone = "invalid_timezone"
+	CodeNotDirectory    = "not_directory"
+	CodeNotWritable     = "not_writable"
+	CodeTooShort        = "too_short"
+	CodeWeakPassword    = "weak_password"
+	CodeModeConflict    = "mode_conflict"
+)
+
+// MinPasswordLength is the shortest accepted password, as in install.sh
+const MinPasswordLength = 8
+
+// ValidationError is a problem with one field of the configuration
+type ValidationError struct {
+	Field    string // Dotted path of the field, e.g. "credentials.code_password"
+	Code     string // One of the Code constants
+	Message  string
+	Severity Severity
+}
+
+func (e ValidationError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationErrors is the result of Config.Validate
+type ValidationErrors []ValidationError
+
+// HasErrors reports whether any problem has SeverityError
+func (v ValidationErrors) HasErrors() bool {
+	return len(v.Errors()) > 0
+}
+
+// Errors returns the problems with SeverityError
+func (v ValidationErrors) Errors() ValidationErrors {
+	return v.withSeverity(SeverityError)
+}
+
+// Warnings returns the problems with SeverityWarning
+func (v ValidationErrors) Warnings() ValidationErrors {
+	return v.withSeverity(SeverityWarning)
+}
+
+// ForField returns the problems of a field
+func (v ValidationErrors) ForField(field string) ValidationErrors {
+	var result ValidationErrors
+	for _, e := range v {
+		if e.Field == field {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+func (v ValidationErrors) withSeverity(severity Severity) ValidationErrors {
+	var result ValidationErrors
+	for _, e := range v {
+		if e.Severity == severity {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// validator collects the problems found by Validate
+type validator struct {
+	problems ValidationErrors
+}
+
+func (v *validator) errorf(field, code, format string, args ...any) {
+	v.problems = append(v.problems, ValidationError{Field: field, Code: code, Message: fmt.Sprintf(format, args...), Severity: SeverityError})
+}
+
+func (v *validator) warnf(field, code, format string, args ...any) {
+	v.problems = append(v.problems, ValidationError{Field: field, Code: code, Message: fmt.Sprintf(format, args...), Severity: SeverityWarning})
+}
+
+// Validate checks that the configuration is valid. Relative workspace paths
+// are not checked on disk, use ValidateIn for that.
+func (c *Config) Validate() ValidationErrors {
+	return c.ValidateIn("")
+}
+
+// ValidateIn checks that the configuration is valid for the project in
+// projectRoot, against which a relative workspace path is resolved
+func (c *Config) ValidateIn(projectRoot string) ValidationErrors {
+	v := &validator{}
+	c.validateMode(v)
+	c.validateCredentials(v)
+	c.validateEnvironment(v, projectRoot)
+	c.validateAdvanced(v)
+	c.validateEnvValues(v)
+	return v.problems
+}
+
+func (c *Config) validateMode(v *validator) {
+	switch c.DeploymentMode {
+	case ModeTailscale, ModeLocal, ModeNativeTailscale:
+	case ModeTerminalOnly:
+		if c.Components.Docker {
+			v.errorf("components.docker", CodeModeConflict, "Docker is not used in terminal-only mode")
+		}
+	case "":
+		v.errorf("deployment_mode", CodeRequired, "deployment mode is required")
+		return
+	default:
+		v.errorf("deployment_mode", CodeInvalidValue, "unknown deployment mode %q, use %s, %s, %s or %s",
+			c.DeploymentMode, ModeTailscale, ModeLocal, ModeNativeTailscale, ModeTerminalOnly)
+		return
+	}
+
+	// components.tailscale defaults to true and is ignored outside tailscale
+	// mode, see GenerateBashFlags
+}
+
+func (c *Config) validateCredentials(v *validator) {
+	// References are checked once they are resolved, see ResolveSecrets
+	for _, field := range c.credentialFields() {
+		if scheme, ref, ok := ParseSecretRef(*field.value); ok && ref == "" && scheme != SecretLiteral {
+			v.errorf(field.path, CodeInvalidFormat, "%s: reference is missing what to read", scheme)
+		}
+		// The containers read the secret files into environment variables
+		if strings.IndexByte(*field.value, 0) >= 0 {
+			v.errorf(field.path, CodeInvalidValue, "contains a NUL byte, which cannot be passed to the containers")
+		}
+	}
+
+	// Check required passwords
+	if c.Components.Docker {
+		validatePassword(v, "credentials.code_password", "code-server password", c.Credentials.CodePassword)
+		validatePassword(v, "credentials.sudo_password", "sudo password", c.Credentials.SudoPassword)
+	}
+
+	// Check Tailscale key for VPN mode (not required for native-tailscale as it uses host Tailscale)
+	key, known := literalSecret(c.Credentials.TailscaleKey)
+	switch {
+	case !known:
+		// A reference, checked once resolved
+	case key == "":
+		if c.DeploymentMode == ModeTailscale && c.Components.Tailscale {
+			v.errorf("credentials.tailscale_key", CodeRequired, "Tailscale auth key is required for VPN mode")
+		}
+	case !strings.HasPrefix(key, "tskey-"):
+		v.errorf("credentials.tailscale_key", CodeInvalidFormat, "Tailscale auth key should start with 'tskey-auth-'")
+	case !strings.HasPrefix(key, "tskey-auth-"):
+		v.warnf("credentials.tailscale_key", CodeInvalidFormat, "Tailscale key is not an auth key ('tskey-auth-')")
+	}
+
+	if key, _ := literalSecret(c.Credentials.AnthropicKey); key != "" {
+		if !strings.HasPrefix(key, "sk-ant-") || strings.IndexFunc(key, unicode.IsSpace) >= 0 {
+			v.errorf("credentials.anthropic_key", CodeInvalidFormat, "Anthropic API key should start with 'sk-ant-' and contain no spaces")
+		}
+	}
+}
+
+// commonPasswords are rejected as weak regardless of their length
+var commonPasswords = map[string]bool{
+	"password": true, "password1": true, "changeme": true, "12345678": true,
+	"123456789": true, "qwertyuiop": true, "iloveyou": true, "doomcoding": true,
+}
+
+// validatePassword checks that a required password is long enough and
+// warns when it is easy to guess
+func validatePassword(v *validator, field, name, password string) {
+	password, known := literalSecret(password)
+	if !known {
+		return
+	}
+	if password == "" {
+		v.errorf(field, CodeRequired, "%s is required", name)
+		return
+	}
+	if len([]rune(password)) < MinPasswordLength {
+		v.errorf(field, CodeTooShort, "%s must be at least %d characters", name, MinPasswordLength)
+		return
+	}
+
+	var lower, upper, digit, other bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+	classes := 0
+	for _, present := range []bool{lower, upper, digit, other} {
+		if present {
+			classes++
+		}
+	}
+	if commonPasswords[strings.ToLower(password)] || classes < 2 {
+		v.warnf(field, CodeWeakPassword, "%s is weak, mix letters, digits and symbols", name)
+	}
+}
+
+func (c *Config) validateEnvironment(v *validator, projectRoot string) {
+	validateID(v, "environment.puid", "PUID", c.Environment.PUID, lookupUser)
+	validateID(v, "environment.pgid", "PGID", c.Environment.PGID, lookupGroup)
+
+	// Empty uses the default of the compose files
+	if tz := c.Environment.Timezone; tz != "" {
+		if _, err := time.LoadLocation(tz); err != nil || tz == "Local" {
+			v.errorf("environment.timezone", CodeInvalidTimezone, "%q is not an IANA timezone such as Europe/Berlin", tz)
+		}
+	}
+
+	c.validateWorkspace(v, projectRoot)
+}
+
+func lookupUser(id string) error {
+	_, err := user.LookupId(id)
+	return err
+}
+
+func lookupGroup(id string) error {
+	_, err := user.LookupGroupId(id)
+	return err
+}
+
+// validateID checks a numeric user or group ID and warns when it does not
+// exist on this host. Empty uses the default of the compose files.
+func validateID(v *validator, field, name, id string, lookup func(string) error) {
+	if id == "" {
+		return
+	}
+	n, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		v.errorf(field, CodeNotNumeric, "%s must be a numeric ID, got %q", name, id)
+		return
+	}
+	if n == 0 {
+		v.warnf(field, CodeRootID, "%s 0 runs the containers as root", name)
+		return
+	}
+
+	err = lookup(id)
+	var unknownUser user.UnknownUserIdError
+	var unknownGroup user.UnknownGroupIdError
+	if errors.As(err, &unknownUser) || errors.As(err, &unknownGroup) {
+		v.warnf(field, CodeUnknownID, "%s %s does not exist on this host", name, id)
+	}
+}
+
+func (c *Config) validateWorkspace(v *validator, projectRoot string) {
+	const field = "environment.workspace_path"
+	path := c.Environment.WorkspacePath
+	if path == "" {
+		v.errorf(field, CodeRequired, "workspace path cannot be empty")
+		return
+	}
+	if !filepath.IsAbs(path) {
+		if projectRoot == "" {
+			return
+		}
+		path = filepath.Join(projectRoot, path)
+	}
+
+	// A missing workspace is created by the installer, so its closest
+	// existing parent has to be writable
+	for {
+		info, err := os.Stat(path)
+		if err == nil {
+			if !info.IsDir() {
+				v.errorf(field, CodeNotDirectory, "%s is not a directory", path)
+			} else if !writable(path) {
+				v.errorf(field, CodeNotWritable, "%s is not writable", path)
+			}
+			return
+		}
+		// Keep going up on ENOTDIR too, so that a file in the path is
+		// reported as not a directory
+		parent := filepath.Dir(path)
+		if errors.Is(err, os.ErrPermission) || parent == path {
+			return
+		}
+		path = parent
+	}
+}
+
+func (c *Config) validateAdvanced(v *validator) {
+	const field = "advanced.code_server_port"
+	port := c.Advanced.CodeServerPort
+	switch {
+	case port == 0:
+		// Not set, code-server uses its default port
+	case port < 0 || port > 65535:
+		v.errorf(field, CodeOutOfRange, "port %d is not between 1 and 65535", port)
+	case port < 1024:
+		v.warnf(field, CodePrivilegedPort, "port %d is below 1024 and needs root to bind", port)
+	default:
+		for name, defaultPort := range service.DefaultPorts() {
+			if name != "code-server" && port == defaultPort {
+				v.errorf(field, CodePortConflict, "port %d is used by %s", port, name)
+			}
+		}
+	}
+}
+
+// validateEnvValues checks that the values written to .env can be
+// represented there, see FormatDotEnv
+func (c *Config) validateEnvValues(v *validator) {
+	values := map[string]string{
+		"environment.puid":           c.Environment.PUID,
+		"environment.pgid":           c.Environment.PGID,
+		"environment.timezone":       c.Environment.Timezone,
+		"environment.workspace_path": c.Environment.WorkspacePath,
+		"advanced.claude_automation": c.Advanced.ClaudeAutomation,
+		"advanced.ts_extra_args":     c.Advanced.TSExtraArgs,
+		"advanced.target_arch":       c.Advanced.TargetArch,
+	}
+	for key, value := range c.ExtraEnv {
+		values["extra_env."+key] = value
+	}
+
+	fields := make([]string, 0, len(values))
+	for field := range values {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		if key, ok := strings.CutPrefix(field, "extra_env."); ok && !dotEnvKey.MatchString(key) {
+			v.errorf(field, CodeInvalidFormat, "%q is not a valid variable name", key)
+		}
+		if strings.IndexByte(values[field], 0) >= 0 {
+			v.errorf(field, CodeInvalidValue, "contains a NUL byte, which cannot be written to .env")
+		}
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package config
+
+// writable assumes dir is writable where access(2) is not available
+func writable(dir string) bool {
+	return true
+}
@@ -0,0 +1,13 @@
+//go:build unix
+
+package config
+
+import "syscall"
+
+// accessWrite is W_OK from unistd.h
+const accessWrite = 0x2
+
+// writable reports whether the current user may create files in dir
+func writable(dir string) bool {
+	return syscall.Access(dir, accessWrite) == nil
+}
@@ -0,0 +1,170 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/doom-coding/doom-coding/internal/runner"
+)
+
+// cancelWhenStarted runs exec in the background and calls cancel once the
+// named step has started. It returns the error of the run.
+func cancelWhenStarted(t *testing.T, exec *Executor, stepName string, run func() error, cancel func()) error {
+	t.Helper()
+	events := exec.Subscribe()
+	done := make(chan error, 1)
+	go func() { done <- run() }()
+
+	for ev := range events {
+		if started, ok := ev.(StepStarted); ok && started.Step.Name == stepName {
+			cancel()
+		}
+	}
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(10 * time.Second):
+		t.Fatal("Run did not return after cancel")
+		return nil
+	}
+}
+
+func TestCancelStopsRunningStep(t *testing.T) {
+	exec := &Executor{
+		ProjectRoot: t.TempDir(),
+		Steps: []Step{
+			{Name: "slow", Command: "sh", Args: []string{"-c", "sleep 30 & wait"}},
+			{Name: "after", Command: "true", DependsOn: []string{"slow"}},
+		},
+	}
+
+	start := time.Now()
+	err := cancelWhenStarted(t, exec, "slow", func() error { return exec.RunSteps(context.Background()) }, exec.Cancel)
+	if err == nil || !strings.Contains(err.Error(), "cancelled") {
+		t.Errorf("Expected cancellation error, got %v", err)
+	}
+	if took := time.Since(start); took > 5*time.Second {
+		t.Errorf("Cancel should stop the running step, took %s", took)
+	}
+
+	results := exec.GetResults()
+	if results[0].Status != StepCancelled || results[0].Success {
+		t.Errorf("Running step should be cancelled, got %+v", results[0])
+	}
+	if results[1].Status != StepCancelled {
+		t.Errorf("Dependent step should not run, got %s", results[1].Status)
+	}
+}
+
+func TestContextCancelStopsRunningStep(t *testing.T) {
+	exec := &Executor{
+		ProjectRoot: t.TempDir(),
+		Steps:       []Step{{Name: "slow", Command: "sleep", Args: []string{"30"}}},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	err := cancelWhenStarted(t, exec, "slow", func() error { return exec.RunSteps(ctx) }, cancel)
+	if err == nil {
+		t.Error("Expected cancellation error")
+	}
+	if status := exec.GetResults()[0].Status; status != StepCancelled {
+		t.Errorf("Expected cancelled, got %s", status)
+	}
+}
+
+func TestOnCancelCleanup(t *testing.T) {
+	dir := t.TempDir()
+	exec := &Executor{
+		ProjectRoot: dir,
+		Steps: []Step{{
+			Name:     "pull",
+			Command:  "sleep",
+			Args:     []string{"30"},
+			OnCancel: []string{"sh", "-c", "touch cleaned && echo cleaned up"},
+		}},
+	}
+
+	cancelWhenStarted(t, exec, "pull", func() error { return exec.RunSteps(context.Background()) }, exec.Cancel)
+
+	result := exec.GetResults()[0]
+	if result.Status != StepCancelled {
+		t.Fatalf("Expected cancelled, got %s", result.Status)
+	}
+	if !strings.Contains(result.Output, "cleaned up") {
+		t.Errorf("Cleanup output missing: %q", result.Output)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "cleaned")); err != nil {
+		t.Errorf("Cleanup should run in the step directory: %v", err)
+	}
+}
+
+func TestOnCancelNotRunOnFailure(t *testing.T) {
+	fake := &fakeRunner{runs: map[string][]fakeRun{"apt-get": {{exitCode: 100}}}}
+	exec := &Executor{
+		ProjectRoot: "/test",
+		Runner:      fake,
+		Steps:       []Step{{Name: "pkgs", Command: "apt-get", OnCancel: []string{"cleanup"}}},
+	}
+
+	exec.RunSteps(context.Background())
+	if status := exec.GetResults()[0].Status; status != StepFailed {
+		t.Errorf("Expected failed, got %s", status)
+	}
+	if fake.count("cleanup") != 0 {
+		t.Error("OnCancel should only run for cancelled steps")
+	}
+}
+
+// blockingRunner prints output and then blocks until the context is done
+type blockingRunner struct {
+	output string
+}
+
+func (r blockingRunner) Run(ctx context.Context, cmd runner.Command) error {
+	io.WriteString(cmd.Stdout, r.output)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (r blockingRunner) LookPath(file string) (string, error) {
+	return "/usr/bin/" + file, nil
+}
+
+func TestCancelInstallScript(t *testing.T) {
+	exec := NewExecutor("/test/project")
+	exec.LogFile = filepath.Join(t.TempDir(), "install.log")
+	exec.Runner = blockingRunner{output: "::doom-step name=docker_install status=start index=3 total=10\n"}
+
+	err := cancelWhenStarted(t, exec, "docker_install", func() error {
+		return exec.RunInstallScript(context.Background(), nil)
+	}, exec.Cancel)
+	if err == nil || !strings.Contains(err.Error(), "cancelled") {
+		t.Errorf("Expected cancellation error, got %v", err)
+	}
+
+	results := exec.GetResults()
+	if len(results) != 1 || results[0].Status != StepCancelled {
+		t.Errorf("Open step should be cancelled, got %+v", results)
+	}
+}
+
+func TestCancelledFailMarker(t *testing.T) {
+	exec := NewExecutor("/test/project")
+	ctx, cancel := context.WithCancel(context.Background())
+	progress := newScriptProgress(ctx, exec)
+
+	progress.line(Stdout, "::doom-step name=base_packages status=start")
+	cancel()
+	progress.line(Stdout, "::doom-step name=base_packages status=fail")
+
+	if status := exec.GetResults()[0].Status; status != StepCancelled {
+		t.Errorf("A fail marker after cancel should record the step as cancelled, got %s", status)
+	}
+}
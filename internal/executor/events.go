@@ -0,0 +1,122 @@
+package executor
+
+import (
+	"time"
+)
+
+// Event is something that happened during a run. The concrete types are
+// StepStarted, StepOutput, StepRetried, StepFinished and RunFinished.
+type Event interface {
+	// EventType returns the snake_case name used in JSON output
+	EventType() string
+}
+
+// Stream identifies which output stream a line was written to
+type Stream string
+
+const (
+	Stdout Stream = "stdout"
+	Stderr Stream = "stderr"
+)
+
+// StepStarted is emitted when a step's command is about to run. Steps that
+// are skipped, blocked, resumed or cancelled only emit StepFinished.
+type StepStarted struct {
+	Step  *Step
+	Index int // Position of the step, starting at 1
+	Total int
+	Time  time.Time
+}
+
+// StepOutput is a single line of output from a running step. Step is nil
+// for install script output outside a marked step.
+type StepOutput struct {
+	Step   *Step
+	Index  int
+	Total  int
+	Stream Stream
+	Line   string
+	Time   time.Time
+}
+
+// StepRetried is emitted when a failed attempt will be retried after Backoff
+type StepRetried struct {
+	Step        *Step
+	Index       int
+	Total       int
+	Attempt     int // The attempt that failed
+	MaxAttempts int
+	Backoff     time.Duration
+	Err         error
+	Time        time.Time
+}
+
+// StepFinished is emitted once for every step with its final result
+type StepFinished struct {
+	Step   *Step
+	Index  int
+	Total  int
+	Result StepResult
+	Time   time.Time
+}
+
+// RunFinished is the last event of a run
+type RunFinished struct {
+	Results  []StepResult
+	Err      error
+	Duration time.Duration
+	Time     time.Time
+}
+
+func (StepStarted) EventType() string  { return "step_started" }
+func (StepOutput) EventType() string   { return "step_output" }
+func (StepRetried) EventType() string  { return "step_retried" }
+func (StepFinished) EventType() string { return "step_finished" }
+func (RunFinished) EventType() string  { return "run_finished" }
+
+// eventBuffer is the channel capacity given to each subscriber
+const eventBuffer = 64
+
+// Subscribe returns a channel that receives the events of the next run and
+// is closed after its RunFinished event. Delivery blocks the run when the
+// buffer is full, so subscribers must keep reading until the channel closes.
+func (e *Executor) Subscribe() <-chan Event {
+	ch := make(chan Event, eventBuffer)
+	e.mu.Lock()
+	e.subscribers = append(e.subscribers, ch)
+	e.mu.Unlock()
+	return ch
+}
+
+// emit delivers an event to every subscriber in order
+func (e *Executor) emit(ev Event) {
+	e.emitMu.Lock()
+	defer e.emitMu.Unlock()
+
+	e.mu.Lock()
+	subscribers := e.subscribers
+	if _, last := ev.(RunFinished); last {
+		e.subscribers = nil
+	}
+	e.mu.Unlock()
+
+	for _, ch := range subscribers {
+		ch <- ev
+	}
+	if _, last := ev.(RunFinished); last {
+		for _, ch := range subscribers {
+			close(ch)
+		}
+	}
+}
+
+// finishRun emits RunFinished and passes err through
+func (e *Executor) finishRun(start time.Time, err error) error {
+	e.emit(RunFinished{
+		Results:  e.GetResults(),
+		Err:      err,
+		Duration: time.Since(start),
+		Time:     time.Now(),
+	})
+	return err
+}
@@ -0,0 +1,175 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// collectEvents subscribes to exec and returns a function that waits for
+// the run to finish and returns every event received
+func collectEvents(exec *Executor) func() []Event {
+	ch := exec.Subscribe()
+	done := make(chan []Event)
+	go func() {
+		var events []Event
+		for ev := range ch {
+			events = append(events, ev)
+		}
+		done <- events
+	}()
+	return func() []Event {
+		select {
+		case events := <-done:
+			return events
+		case <-time.After(5 * time.Second):
+			return nil
+		}
+	}
+}
+
+func TestRunStepsEvents(t *testing.T) {
+	fake := &fakeRunner{runs: map[string][]fakeRun{
+		"fetch": {{output: "one\ntwo\n"}},
+		"fail":  {{exitCode: 2}},
+	}}
+	exec := &Executor{
+		ProjectRoot: "/test",
+		Runner:      fake,
+		Steps: []Step{
+			{Name: "fetch", Command: "fetch"},
+			{Name: "broken", Command: "fail", DependsOn: []string{"fetch"}},
+			{Name: "after", Command: "fetch", DependsOn: []string{"broken"}},
+		},
+	}
+
+	events := collectEvents(exec)
+	if err := exec.RunSteps(context.Background()); err == nil {
+		t.Fatal("Expected failure of the broken step")
+	}
+
+	var types []string
+	for _, ev := range events() {
+		name := ev.EventType()
+		switch ev := ev.(type) {
+		case StepStarted:
+			name += ":" + ev.Step.Name
+		case StepOutput:
+			name += ":" + ev.Line
+		case StepFinished:
+			name += ":" + ev.Step.Name + ":" + string(ev.Result.Status)
+			if ev.Total != 3 {
+				t.Errorf("Expected total 3, got %d", ev.Total)
+			}
+		}
+		types = append(types, name)
+	}
+
+	expected := []string{
+		"step_started:fetch",
+		"step_output:one",
+		"step_output:two",
+		"step_finished:fetch:succeeded",
+		"step_started:broken",
+		"step_finished:broken:failed",
+		"step_finished:after:blocked",
+		"run_finished",
+	}
+	if strings.Join(types, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected events %v, got %v", expected, types)
+	}
+}
+
+func TestRunFinishedOnInvalidGraph(t *testing.T) {
+	exec := &Executor{
+		ProjectRoot: t.TempDir(),
+		Steps:       []Step{{Name: "a", Command: "true", DependsOn: []string{"nope"}}},
+	}
+
+	events := collectEvents(exec)
+	err := exec.RunSteps(context.Background())
+
+	got := events()
+	if len(got) != 1 {
+		t.Fatalf("Expected only run_finished, got %v", got)
+	}
+	finished, ok := got[0].(RunFinished)
+	if !ok || finished.Err != err {
+		t.Errorf("Expected run_finished carrying %v, got %+v", err, got[0])
+	}
+}
+
+func TestSubscribeOnlyReceivesNextRun(t *testing.T) {
+	exec := &Executor{
+		ProjectRoot: "/test",
+		Runner:      &fakeRunner{},
+		Steps:       []Step{{Name: "a", Command: "a"}},
+	}
+
+	first := collectEvents(exec)
+	exec.RunSteps(context.Background())
+	second := collectEvents(exec)
+	exec.RunSteps(context.Background())
+
+	if n := len(first()); n != 3 {
+		t.Errorf("First subscriber expected 3 events, got %d", n)
+	}
+	if n := len(second()); n != 3 {
+		t.Errorf("Second subscriber expected 3 events, got %d", n)
+	}
+}
+
+func TestWriteJSONLines(t *testing.T) {
+	fake := &fakeRunner{runs: map[string][]fakeRun{"fetch": {{output: "done\n"}}}}
+	exec := &Executor{
+		ProjectRoot: "/test",
+		Runner:      fake,
+		Steps:       []Step{{Name: "fetch", Command: "fetch"}},
+	}
+
+	var buf bytes.Buffer
+	written := make(chan error)
+	events := exec.Subscribe()
+	go func() { written <- WriteJSONLines(&buf, events) }()
+
+	if err := exec.RunSteps(context.Background()); err != nil {
+		t.Fatalf("RunSteps failed: %v", err)
+	}
+	if err := <-written; err != nil {
+		t.Fatalf("WriteJSONLines failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected 4 lines, got %d: %s", len(lines), buf.String())
+	}
+
+	var output map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[1]), &output); err != nil {
+		t.Fatalf("Invalid JSON line: %v", err)
+	}
+	if output["type"] != "step_output" || output["step"] != "fetch" || output["line"] != "done" || output["stream"] != "stdout" {
+		t.Errorf("Unexpected step_output line: %s", lines[1])
+	}
+
+	var finished struct {
+		Type    string `json:"type"`
+		Success *bool  `json:"success"`
+		Results []struct {
+			Step   string `json:"step"`
+			Status string `json:"status"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal([]byte(lines[3]), &finished); err != nil {
+		t.Fatalf("Invalid JSON line: %v", err)
+	}
+	if finished.Type != "run_finished" || finished.Success == nil || !*finished.Success {
+		t.Errorf("Unexpected run_finished line: %s", lines[3])
+	}
+	if len(finished.Results) != 1 || finished.Results[0].Status != "succeeded" {
+		t.Errorf("Unexpected results: %s", lines[3])
+	}
+}
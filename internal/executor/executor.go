@@ -1,16 +1,19 @@
 package executor
 
 import (
-	"bufio"
+	"bytes"
 	"context"
 	"fmt"
-	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/doom-coding/doom-coding/internal/config"
+	"github.com/doom-coding/doom-coding/internal/health"
+	"github.com/doom-coding/doom-coding/internal/runner"
 )
 
 // Step represents an installation step
@@ -23,19 +26,39 @@ type Step struct {
 	Timeout     time.Duration
 	Optional    bool
 	Condition   func() bool // Only run if this returns true
+	DependsOn   []string    // Names of steps that must finish first
+	Retry       RetryPolicy // Zero value runs the step once
+	OnCancel    []string    // Cleanup command and arguments run when the step is cancelled while running
 }
 
+// StepStatus is the final state of a step in a run
+type StepStatus string
+
+const (
+	StepSucceeded StepStatus = "succeeded"
+	StepFailed    StepStatus = "failed"
+	StepSkipped   StepStatus = "skipped"   // Condition not met
+	StepBlocked   StepStatus = "blocked"   // A required dependency failed
+	StepCancelled StepStatus = "cancelled" // Run cancelled before or while the step ran
+	StepResumed   StepStatus = "resumed"   // Completed by a previous run with the same inputs
+)
+
 // StepResult contains the result of executing a step
 type StepResult struct {
 	Step     *Step
+	Status   StepStatus
 	Success  bool
-	Output   string
+	Output   string // Output of the last attempt
 	Error    error
 	Duration time.Duration
+	Attempts []AttemptResult
 }
 
-// ProgressCallback is called during execution with progress updates
-type ProgressCallback func(stepIndex int, totalSteps int, step *Step, output string)
+// cleanupTimeout bounds how long an OnCancel command may run
+const cleanupTimeout = time.Minute
+
+// DefaultMaxParallel is the number of steps run at once when MaxParallel is unset
+const DefaultMaxParallel = 4
 
 // Executor handles running installation steps
 type Executor struct {
@@ -44,11 +67,20 @@ type Executor struct {
 	Verbose     bool
 	LogFile     string
 	Steps       []Step
-
-	mu         sync.Mutex
-	results    []StepResult
+	MaxParallel int                  // Maximum concurrently running steps, 0 for DefaultMaxParallel
+	Journal     *Journal             // Records step outcomes when set
+	Resume      bool                 // Skip steps the journal shows as completed with the same inputs
+	Inputs      []string             // Files the steps read, e.g. .env; their contents are part of every input hash
+	Runner      runner.CommandRunner // Executes step commands, nil for the local host
+
+	mu          sync.Mutex
+	emitMu      sync.Mutex // Serializes event delivery
+	subscribers []chan Event
+	results     []StepResult
 	currentStep int
-	cancelled  bool
+	cancelled   bool
+	cancelRun   context.CancelFunc // Cancels the context of the active run
+	sleep       func(ctx context.Context, d time.Duration) error // Waits between retries, nil for sleepContext
 }
 
 // NewExecutor creates a new executor instance
@@ -60,6 +92,15 @@ func NewExecutor(projectRoot string) *Executor {
 	}
 }
 
+// networkRetry retries steps that download packages or images
+var networkRetry = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 5 * time.Second,
+	Multiplier:     2,
+	MaxBackoff:     time.Minute,
+	Jitter:         0.2,
+}
+
 // getDefaultSteps returns the default installation steps
 func getDefaultSteps(projectRoot string) []Step {
 	return []Step{
@@ -77,6 +118,8 @@ func getDefaultSteps(projectRoot string) []Step {
 			Args:        []string{filepath.Join(projectRoot, "scripts", "install.sh"), "--dry-run"},
 			Timeout:     5 * time.Minute,
 			Optional:    true,
+			DependsOn:   []string{"system_check"},
+			Retry:       networkRetry,
 		},
 		{
 			Name:        "docker_install",
@@ -84,6 +127,8 @@ func getDefaultSteps(projectRoot string) []Step {
 			Command:     "bash",
 			Args:        []string{"-c", "command -v docker || echo 'Docker will be installed'"},
 			Timeout:     10 * time.Minute,
+			DependsOn:   []string{"base_packages"},
+			Retry:       networkRetry,
 		},
 		{
 			Name:        "network_config",
@@ -91,6 +136,7 @@ func getDefaultSteps(projectRoot string) []Step {
 			Command:     "bash",
 			Args:        []string{"-c", "echo 'Network configuration...'"},
 			Timeout:     2 * time.Minute,
+			DependsOn:   []string{"docker_install"},
 		},
 		{
 			Name:        "terminal_tools",
@@ -98,6 +144,7 @@ func getDefaultSteps(projectRoot string) []Step {
 			Command:     "bash",
 			Args:        []string{"-c", "echo 'Terminal tools setup...'"},
 			Timeout:     10 * time.Minute,
+			DependsOn:   []string{"base_packages"},
 		},
 		{
 			Name:        "ssh_hardening",
@@ -105,6 +152,7 @@ func getDefaultSteps(projectRoot string) []Step {
 			Command:     "bash",
 			Args:        []string{"-c", "echo 'SSH hardening...'"},
 			Timeout:     2 * time.Minute,
+			DependsOn:   []string{"base_packages"},
 		},
 		{
 			Name:        "secrets_setup",
@@ -112,6 +160,7 @@ func getDefaultSteps(projectRoot string) []Step {
 			Command:     "bash",
 			Args:        []string{"-c", "echo 'Secrets management...'"},
 			Timeout:     2 * time.Minute,
+			DependsOn:   []string{"base_packages"},
 		},
 		{
 			Name:        "env_config",
@@ -119,6 +168,7 @@ func getDefaultSteps(projectRoot string) []Step {
 			Command:     "bash",
 			Args:        []string{"-c", "echo '.env file created'"},
 			Timeout:     30 * time.Second,
+			DependsOn:   []string{"secrets_setup"},
 		},
 		{
 			Name:        "services_start",
@@ -126,6 +176,8 @@ func getDefaultSteps(projectRoot string) []Step {
 			Command:     "bash",
 			Args:        []string{"-c", "echo 'Starting containers...'"},
 			Timeout:     5 * time.Minute,
+			DependsOn:   []string{"docker_install", "network_config", "env_config"},
+			Retry:       networkRetry,
 		},
 		{
 			Name:        "health_check",
@@ -133,12 +185,24 @@ func getDefaultSteps(projectRoot string) []Step {
 			Command:     "bash",
 			Args:        []string{filepath.Join(projectRoot, "scripts", "health-check.sh")},
 			Timeout:     2 * time.Minute,
+			DependsOn:   []string{"services_start"},
 		},
 	}
 }
 
-// RunInstallScript runs the main install.sh script with given flags
-func (e *Executor) RunInstallScript(ctx context.Context, flags []string, progressCb ProgressCallback) error {
+// RunInstallScript runs the main install.sh script with given flags. The
+// script's step markers are turned into step events and results, and all
+// other output is delivered to subscribers as StepOutput events. Unless
+// DryRun is set, the steps are recorded in the Journal; with Resume, the
+// script is asked to skip the steps the journal shows as completed with the
+// same flags, script and Inputs.
+//
+// A step the script marks as failed is retried with the RetryPolicy of the
+// step of the same name in Steps: install.sh runs again with the steps
+// completed so far in ResumeEnv, so it picks up at the failed step. Steps
+// the script does not mark, and failures before the first marker, are not
+// retried.
+func (e *Executor) RunInstallScript(ctx context.Context, flags []string) error {
 	scriptPath := filepath.Join(e.ProjectRoot, "scripts", "install.sh")
 
 	args := append([]string{scriptPath}, flags...)
@@ -150,136 +214,523 @@ func (e *Executor) RunInstallScript(ctx context.Context, flags []string, progres
 		args = append(args, "--verbose")
 	}
 
-	return e.runCommand(ctx, "bash", args, progressCb)
+	e.mu.Lock()
+	e.results = nil
+	e.currentStep = 0
+	e.mu.Unlock()
+
+	start := time.Now()
+	ctx, stop := e.startRun(ctx)
+	defer stop()
+
+	progress := newScriptProgress(ctx, e)
+	env := []string{MarkerEnv + "=1"}
+	if e.Journal != nil && !e.DryRun {
+		progress.journal = e.Journal
+		progress.hash = scriptStepHash(args, digestFiles(append([]string{scriptPath}, e.Inputs...)))
+		if e.Resume {
+			progress.resumed = e.resumableSteps(progress.hash)
+			env = append(env, ResumeEnv+"="+strings.Join(progress.resumed, ","))
+		}
+	}
+
+	var err error
+	for {
+		err = e.runCommand(ctx, progress, "bash", args, env)
+		if err == nil || e.isCancelled() || ctx.Err() != nil || !e.retryScript(ctx, progress, err) {
+			break
+		}
+		progress.resumed = progress.completed()
+		env = []string{MarkerEnv + "=1", ResumeEnv + "=" + strings.Join(progress.resumed, ",")}
+	}
+	if err != nil && (e.isCancelled() || ctx.Err() != nil) {
+		err = fmt.Errorf("installation cancelled: %w", err)
+	}
+	if err == nil {
+		err = progress.journalErr
+	}
+	return e.finishRun(start, err)
 }
 
-// RunSteps executes all configured steps
-func (e *Executor) RunSteps(ctx context.Context, progressCb ProgressCallback) error {
+// retryScript reports whether the step that made install.sh exit with err
+// may be retried, and waits for its backoff if so
+func (e *Executor) retryScript(ctx context.Context, progress *scriptProgress, err error) bool {
+	failed, ok := progress.failedStep()
+	if !ok {
+		return false
+	}
+	policy := failed.Step.Retry
+	attempt := len(failed.Attempts)
+	last := failed.Attempts[attempt-1]
+	exitCode := runner.ExitCode(err)
+	if attempt >= policy.attempts() || !policy.retryable(exitCode, last.Output) {
+		return false
+	}
+
+	delay := policy.backoff(attempt, jitterSource())
+	progress.updateAttempt(failed.Step.Name, func(a *AttemptResult) {
+		a.ExitCode = exitCode
+		a.Backoff = delay
+	})
+	e.emit(StepRetried{
+		Step:        failed.Step,
+		Index:       progress.failedIndex,
+		Total:       progress.total,
+		Attempt:     attempt,
+		MaxAttempts: policy.attempts(),
+		Backoff:     delay,
+		Err:         err,
+		Time:        time.Now(),
+	})
+
+	sleep := e.sleep
+	if sleep == nil {
+		sleep = sleepContext
+	}
+	return sleep(ctx, delay) == nil
+}
+
+// scriptStepHash returns the input hash of the install script steps run
+// with args. The secrets file is left out, as its name changes with every
+// run, and so are the secret files in secrets/: changed credentials do not
+// invalidate the journal, they are written again by the steps that run.
+func scriptStepHash(args []string, inputs string) func(name string) string {
+	parts := []string{inputs}
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, config.SecretsFileFlag) {
+			parts = append(parts, arg)
+		}
+	}
+	return func(name string) string {
+		return hashParts(append([]string{name}, parts...)...)
+	}
+}
+
+// resumableSteps returns the steps the journal shows as completed with the
+// input hash given by hash, sorted by name
+func (e *Executor) resumableSteps(hash func(name string) string) []string {
+	e.Journal.mu.Lock()
+	names := make([]string, 0, len(e.Journal.Steps))
+	for name := range e.Journal.Steps {
+		names = append(names, name)
+	}
+	e.Journal.mu.Unlock()
+
+	var completed []string
+	for _, name := range names {
+		if e.Journal.Completed(name, hash(name)) {
+			completed = append(completed, name)
+		}
+	}
+	sort.Strings(completed)
+	return completed
+}
+
+// RunSteps executes the configured steps in dependency order. Steps whose
+// dependencies have finished run concurrently up to MaxParallel; dependents
+// of a failed required step are marked blocked while independent branches
+// keep running. GetResults reports an outcome for every step and
+// subscribers receive an event for every state change.
+func (e *Executor) RunSteps(ctx context.Context) error {
+	start := time.Now()
+	graph, err := buildStepGraph(e.Steps)
+	if err != nil {
+		return e.finishRun(start, fmt.Errorf("invalid step graph: %w", err))
+	}
+
+	ctx, stop := e.startRun(ctx)
+	defer stop()
+
+	inputs := digestFiles(e.Inputs)
+	hashes := make([]string, len(e.Steps))
+	for i := range e.Steps {
+		hashes[i] = hashParts(e.Steps[i].InputHash(), inputs)
+	}
+
+	e.mu.Lock()
 	e.results = make([]StepResult, 0, len(e.Steps))
 	e.currentStep = 0
+	e.mu.Unlock()
 
-	for i, step := range e.Steps {
+	workers := e.MaxParallel
+	if workers <= 0 {
+		workers = DefaultMaxParallel
+	}
+
+	type finished struct {
+		index  int
+		result StepResult
+	}
+
+	results := make([]StepResult, len(e.Steps))
+	waiting := make([]int, len(e.Steps))
+	for i := range e.Steps {
+		waiting[i] = len(graph.deps[i])
+	}
+	ready := graph.roots()
+	done := make(chan finished)
+	running, settled := 0, 0
+	var journalErr error
+
+	// settle records a final result and releases or blocks its dependents
+	var settle func(i int, result StepResult)
+	settle = func(i int, result StepResult) {
+		results[i] = result
+		settled++
 		e.mu.Lock()
-		if e.cancelled {
+		e.results = append(e.results, result)
+		e.mu.Unlock()
+		e.emit(StepFinished{Step: &e.Steps[i], Index: i + 1, Total: len(e.Steps), Result: result, Time: time.Now()})
+
+		for _, next := range graph.dependents[i] {
+			waiting[next]--
+			if waiting[next] > 0 {
+				continue
+			}
+			if e.isCancelled() || ctx.Err() != nil {
+				settle(next, StepResult{Step: &e.Steps[next], Status: StepCancelled, Output: "Not run (installation cancelled)"})
+				continue
+			}
+			if blocker := e.blockingDependency(graph.deps[next], results); blocker >= 0 {
+				settle(next, StepResult{
+					Step:   &e.Steps[next],
+					Status: StepBlocked,
+					Output: fmt.Sprintf("Skipped (dependency '%s' did not succeed)", e.Steps[blocker].Name),
+				})
+				continue
+			}
+			ready = append(ready, next)
+		}
+	}
+
+	for settled < len(e.Steps) {
+		for len(ready) > 0 && running < workers {
+			i := ready[0]
+			ready = ready[1:]
+			step := &e.Steps[i]
+
+			if e.isCancelled() || ctx.Err() != nil {
+				settle(i, StepResult{Step: step, Status: StepCancelled, Output: "Not run (installation cancelled)"})
+				continue
+			}
+
+			if e.Resume && e.Journal != nil && !dependencyRan(graph.deps[i], results) && e.Journal.Completed(step.Name, hashes[i]) {
+				settle(i, StepResult{
+					Step:    step,
+					Status:  StepResumed,
+					Success: true,
+					Output:  "Skipped (completed in a previous run)",
+				})
+				continue
+			}
+
+			// Check condition
+			if step.Condition != nil && !step.Condition() {
+				settle(i, StepResult{
+					Step:    step,
+					Status:  StepSkipped,
+					Success: true,
+					Output:  "Skipped (condition not met)",
+				})
+				continue
+			}
+
+			e.mu.Lock()
+			e.currentStep = i
 			e.mu.Unlock()
-			return fmt.Errorf("installation cancelled")
+
+			if e.Journal != nil {
+				if err := e.Journal.Start(step.Name, hashes[i], time.Now()); err != nil && journalErr == nil {
+					journalErr = err
+				}
+			}
+
+			e.emit(StepStarted{Step: step, Index: i + 1, Total: len(e.Steps), Time: time.Now()})
+			running++
+			go func(i int, step *Step) {
+				done <- finished{index: i, result: e.runStep(ctx, step, i)}
+			}(i, step)
 		}
-		e.currentStep = i
-		e.mu.Unlock()
 
-		// Check condition
-		if step.Condition != nil && !step.Condition() {
-			e.results = append(e.results, StepResult{
-				Step:    &step,
-				Success: true,
-				Output:  "Skipped (condition not met)",
-			})
-			continue
+		if running == 0 {
+			break
 		}
 
-		result := e.runStep(ctx, &step, i, progressCb)
-		e.results = append(e.results, result)
+		f := <-done
+		running--
+		if e.Journal != nil {
+			if err := e.Journal.Finish(f.result, hashes[f.index]); err != nil && journalErr == nil {
+				journalErr = err
+			}
+		}
+		settle(f.index, f.result)
+	}
+
+	e.mu.Lock()
+	e.results = results
+	e.mu.Unlock()
 
-		if !result.Success && !step.Optional {
-			return fmt.Errorf("step '%s' failed: %w", step.Name, result.Error)
+	if e.isCancelled() || ctx.Err() != nil {
+		return e.finishRun(start, fmt.Errorf("installation cancelled"))
+	}
+
+	var failed []string
+	var firstErr error
+	for i, result := range results {
+		if result.Status == StepFailed && !e.Steps[i].Optional {
+			failed = append(failed, e.Steps[i].Name)
+			if firstErr == nil {
+				firstErr = result.Error
+			}
 		}
 	}
+	switch len(failed) {
+	case 0:
+		err = journalErr
+	case 1:
+		err = fmt.Errorf("step '%s' failed: %w", failed[0], firstErr)
+	default:
+		err = fmt.Errorf("steps '%s' failed: %w", strings.Join(failed, "', '"), firstErr)
+	}
+	return e.finishRun(start, err)
+}
 
-	return nil
+// blockingDependency returns the first dependency that prevents a step from
+// running, or -1. Failed optional steps and skipped steps do not block.
+func (e *Executor) blockingDependency(deps []int, results []StepResult) int {
+	for _, dep := range deps {
+		switch results[dep].Status {
+		case StepBlocked:
+			return dep
+		case StepFailed:
+			if !e.Steps[dep].Optional {
+				return dep
+			}
+		}
+	}
+	return -1
 }
 
-func (e *Executor) runStep(ctx context.Context, step *Step, index int, progressCb ProgressCallback) StepResult {
-	start := time.Now()
+// dependencyRan reports whether any dependency was executed in this run, in
+// which case a resumed step has to run again
+func dependencyRan(deps []int, results []StepResult) bool {
+	for _, dep := range deps {
+		switch results[dep].Status {
+		case StepSucceeded, StepFailed:
+			return true
+		}
+	}
+	return false
+}
 
-	// Create context with timeout
-	stepCtx := ctx
-	if step.Timeout > 0 {
-		var cancel context.CancelFunc
-		stepCtx, cancel = context.WithTimeout(ctx, step.Timeout)
-		defer cancel()
+// startRun derives the context of a run so that Cancel reaches the running
+// step. The returned function releases it when the run is over.
+func (e *Executor) startRun(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	e.mu.Lock()
+	e.cancelRun = cancel
+	if e.cancelled {
+		cancel()
 	}
+	e.mu.Unlock()
 
-	// Prepare command
-	cmd := exec.CommandContext(stepCtx, step.Command, step.Args...)
-	if step.WorkDir != "" {
-		cmd.Dir = step.WorkDir
-	} else {
-		cmd.Dir = e.ProjectRoot
+	return ctx, func() {
+		e.mu.Lock()
+		e.cancelRun = nil
+		e.mu.Unlock()
+		cancel()
 	}
+}
 
-	// Set up output capture
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return StepResult{
-			Step:     step,
-			Success:  false,
-			Error:    fmt.Errorf("failed to create stdout pipe: %w", err),
-			Duration: time.Since(start),
+func (e *Executor) isCancelled() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.cancelled
+}
+
+// runStep runs a step, retrying failed attempts according to its policy
+func (e *Executor) runStep(ctx context.Context, step *Step, index int) StepResult {
+	start := time.Now()
+	maxAttempts := step.Retry.attempts()
+	result := StepResult{Step: step}
+
+	for attempt := 1; ; attempt++ {
+		a := e.runAttempt(ctx, step, index)
+		a.Attempt = attempt
+		result.Attempts = append(result.Attempts, a)
+		result.Output = a.Output
+		result.Error = a.Error
+
+		if a.Error == nil || attempt >= maxAttempts || ctx.Err() != nil || e.isCancelled() {
+			break
+		}
+		if !step.Retry.retryable(a.ExitCode, a.Output) {
+			break
 		}
-	}
 
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		return StepResult{
-			Step:     step,
-			Success:  false,
-			Error:    fmt.Errorf("failed to create stderr pipe: %w", err),
-			Duration: time.Since(start),
+		delay := step.Retry.backoff(attempt, jitterSource())
+		result.Attempts[len(result.Attempts)-1].Backoff = delay
+		e.emit(StepRetried{
+			Step:        step,
+			Index:       index + 1,
+			Total:       len(e.Steps),
+			Attempt:     attempt,
+			MaxAttempts: maxAttempts,
+			Backoff:     delay,
+			Err:         a.Error,
+			Time:        time.Now(),
+		})
+
+		sleep := e.sleep
+		if sleep == nil {
+			sleep = sleepContext
+		}
+		if err := sleep(ctx, delay); err != nil {
+			break
 		}
 	}
 
-	// Start command
-	if err := cmd.Start(); err != nil {
-		return StepResult{
-			Step:     step,
-			Success:  false,
-			Error:    fmt.Errorf("failed to start command: %w", err),
-			Duration: time.Since(start),
+	result.Success = result.Error == nil
+	switch {
+	case result.Success:
+		result.Status = StepSucceeded
+	case ctx.Err() != nil || e.isCancelled():
+		result.Status = StepCancelled
+		if len(step.OnCancel) > 0 {
+			e.runCleanup(step, index, &result)
 		}
+	default:
+		result.Status = StepFailed
 	}
+	result.Duration = time.Since(start)
+	return result
+}
 
-	// Read output in real-time
-	var output strings.Builder
-	outputChan := make(chan string, 100)
+// runCleanup runs the OnCancel command of a cancelled step. Its output is
+// appended to the step output and a failure is added to the step error.
+func (e *Executor) runCleanup(step *Step, index int, result *StepResult) {
+	ctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+	defer cancel()
 
-	go e.readOutput(stdout, outputChan)
-	go e.readOutput(stderr, outputChan)
+	dir := step.WorkDir
+	if dir == "" {
+		dir = e.ProjectRoot
+	}
 
-	// Process output and send progress updates
-	go func() {
-		for line := range outputChan {
+	var mu sync.Mutex
+	var output strings.Builder
+	emitter := func(stream Stream) func(line string) {
+		return func(line string) {
+			mu.Lock()
+			defer mu.Unlock()
 			output.WriteString(line)
 			output.WriteString("\n")
-			if progressCb != nil {
-				progressCb(index+1, len(e.Steps), step, line)
-			}
+			e.emit(StepOutput{Step: step, Index: index + 1, Total: len(e.Steps), Stream: stream, Line: line, Time: time.Now()})
 		}
-	}()
+	}
+	stdout, stderr := &lineWriter{emit: emitter(Stdout)}, &lineWriter{emit: emitter(Stderr)}
+
+	err := e.commandRunner().Run(ctx, runner.Command{
+		Name:   step.OnCancel[0],
+		Args:   step.OnCancel[1:],
+		Dir:    dir,
+		Stdout: stdout,
+		Stderr: stderr,
+	})
+	stdout.Flush()
+	stderr.Flush()
+
+	result.Output += output.String()
+	if err != nil {
+		result.Error = fmt.Errorf("%w (cleanup failed: %v)", result.Error, err)
+	}
+}
+
+// runAttempt executes a step's command once
+func (e *Executor) runAttempt(ctx context.Context, step *Step, index int) AttemptResult {
+	start := time.Now()
 
-	// Wait for command to complete
-	err = cmd.Wait()
-	close(outputChan)
+	// Create context with timeout
+	stepCtx := ctx
+	if step.Timeout > 0 {
+		var cancel context.CancelFunc
+		stepCtx, cancel = context.WithTimeout(ctx, step.Timeout)
+		defer cancel()
+	}
 
-	return StepResult{
-		Step:     step,
-		Success:  err == nil,
+	dir := step.WorkDir
+	if dir == "" {
+		dir = e.ProjectRoot
+	}
+
+	// Capture output and emit it line by line
+	var mu sync.Mutex
+	var output strings.Builder
+	emitter := func(stream Stream) func(line string) {
+		return func(line string) {
+			mu.Lock()
+			defer mu.Unlock()
+			output.WriteString(line)
+			output.WriteString("\n")
+			e.emit(StepOutput{Step: step, Index: index + 1, Total: len(e.Steps), Stream: stream, Line: line, Time: time.Now()})
+		}
+	}
+	stdout, stderr := &lineWriter{emit: emitter(Stdout)}, &lineWriter{emit: emitter(Stderr)}
+
+	// Steps run unattended, so their process group can be terminated as a
+	// whole on cancellation
+	err := e.commandRunner().Run(stepCtx, runner.Command{
+		Name:         step.Command,
+		Args:         step.Args,
+		Dir:          dir,
+		Stdout:       stdout,
+		Stderr:       stderr,
+		ProcessGroup: true,
+	})
+	stdout.Flush()
+	stderr.Flush()
+
+	return AttemptResult{
+		ExitCode: runner.ExitCode(err),
 		Output:   output.String(),
 		Error:    err,
 		Duration: time.Since(start),
 	}
 }
 
-func (e *Executor) readOutput(r io.Reader, outputChan chan<- string) {
-	scanner := bufio.NewScanner(r)
-	for scanner.Scan() {
-		outputChan <- scanner.Text()
+func (e *Executor) commandRunner() runner.CommandRunner {
+	return runner.OrDefault(e.Runner)
+}
+
+// lineWriter splits written output into lines and passes each to emit
+type lineWriter struct {
+	emit func(line string)
+	buf  []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.emit(strings.TrimSuffix(string(w.buf[:i]), "\r"))
+		w.buf = w.buf[i+1:]
 	}
+	return len(p), nil
 }
 
-func (e *Executor) runCommand(ctx context.Context, command string, args []string, progressCb ProgressCallback) error {
-	cmd := exec.CommandContext(ctx, command, args...)
-	cmd.Dir = e.ProjectRoot
+// Flush emits a trailing line without a newline
+func (w *lineWriter) Flush() {
+	if len(w.buf) > 0 {
+		w.emit(strings.TrimSuffix(string(w.buf), "\r"))
+		w.buf = nil
+	}
+}
 
+// runCommand runs the install script, passing its output to progress
+func (e *Executor) runCommand(ctx context.Context, progress *scriptProgress, command string, args, env []string) error {
 	// Open log file
 	logFile, err := os.OpenFile(e.LogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 	if err != nil {
@@ -289,67 +740,43 @@ func (e *Executor) runCommand(ctx context.Context, command string, args []string
 		defer logFile.Close()
 	}
 
-	// Set up pipes
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return fmt.Errorf("failed to create stdout pipe: %w", err)
-	}
-
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		return fmt.Errorf("failed to create stderr pipe: %w", err)
-	}
-
-	// Start command
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("failed to start command: %w", err)
-	}
-
-	// Read and process output
-	outputChan := make(chan string, 100)
-	var wg sync.WaitGroup
-
-	wg.Add(2)
-	go func() {
-		defer wg.Done()
-		e.readOutput(stdout, outputChan)
-	}()
-	go func() {
-		defer wg.Done()
-		e.readOutput(stderr, outputChan)
-	}()
-
-	// Process output
-	go func() {
-		wg.Wait()
-		close(outputChan)
-	}()
-
-	stepIndex := 0
-	for line := range outputChan {
-		// Write to log file
-		fmt.Fprintln(logFile, line)
-
-		// Parse step markers from install.sh output
-		if strings.Contains(line, "==>") || strings.Contains(line, "[STEP]") {
-			stepIndex++
-		}
-
-		// Send progress callback
-		if progressCb != nil {
-			step := &Step{Description: line}
-			progressCb(stepIndex, 10, step, line)
+	var mu sync.Mutex
+	emitter := func(stream Stream) func(line string) {
+		return func(line string) {
+			mu.Lock()
+			defer mu.Unlock()
+			fmt.Fprintln(logFile, line)
+			progress.line(stream, line)
 		}
 	}
-
-	return cmd.Wait()
+	stdout, stderr := &lineWriter{emit: emitter(Stdout)}, &lineWriter{emit: emitter(Stderr)}
+
+	err = e.commandRunner().Run(ctx, runner.Command{
+		Name:   command,
+		Args:   args,
+		Dir:    e.ProjectRoot,
+		Env:    env,
+		Stdout: stdout,
+		Stderr: stderr,
+	})
+	stdout.Flush()
+	stderr.Flush()
+	progress.close(err)
+	return err
 }
 
-// Cancel cancels the current installation
+// Cancel cancels the current installation. Steps that have not started are
+// not run, and the running commands are terminated with their process
+// groups; see runner.ExecRunner.
 func (e *Executor) Cancel() {
 	e.mu.Lock()
-	defer e.mu.Unlock()
 	e.cancelled = true
+	cancel := e.cancelRun
+	e.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
 }
 
 // GetResults returns the results of completed steps
@@ -366,16 +793,19 @@ func (e *Executor) GetCurrentStep() int {
 	return e.currentStep
 }
 
-// GenerateBashCommand returns the equivalent bash command
+// GenerateBashCommand returns the equivalent bash command, with the values
+// of credential flags redacted
 func (e *Executor) GenerateBashCommand(flags []string) string {
 	scriptPath := filepath.Join(e.ProjectRoot, "scripts", "install.sh")
-	args := append([]string{scriptPath}, flags...)
+	args := append([]string{scriptPath}, config.RedactFlags(flags)...)
 	return "bash " + strings.Join(args, " ")
 }
 
 // HealthChecker provides health check functionality
 type HealthChecker struct {
 	ProjectRoot string
+	Probes      []health.Probe
+	Runner      runner.CommandRunner // Runs the commands of the probes, runner.Default when nil
 }
 
 // HealthCheckResult contains health check results
@@ -390,90 +820,49 @@ type HealthCheckResult struct {
 	SSH             bool
 	Secrets         bool
 	Errors          []string
+	Report          *health.Report // Per-probe status, latency and error detail
 }
 
 // NewHealthChecker creates a new health checker
 func NewHealthChecker(projectRoot string) *HealthChecker {
-	return &HealthChecker{ProjectRoot: projectRoot}
+	return &HealthChecker{
+		ProjectRoot: projectRoot,
+		Probes:      health.DefaultProbes(health.Endpoints{}),
+	}
 }
 
 // Check runs the health check
 func (h *HealthChecker) Check(ctx context.Context) (*HealthCheckResult, error) {
+	checker := health.NewChecker(h.Probes...)
+	checker.Runner = h.Runner
+	return resultFromReport(checker.Run(ctx)), nil
+}
+
+// resultFromReport summarizes a probe report into the legacy result fields
+func resultFromReport(report *health.Report) *HealthCheckResult {
 	result := &HealthCheckResult{
 		Containers: make(map[string]bool),
+		Report:     report,
 	}
 
-	// Run health-check.sh
-	scriptPath := filepath.Join(h.ProjectRoot, "scripts", "health-check.sh")
-	cmd := exec.CommandContext(ctx, "bash", scriptPath, "--json")
-
-	output, err := cmd.Output()
-	if err != nil {
-		// Fall back to basic checks
-		h.basicChecks(result)
-		return result, nil
-	}
-
-	// Parse JSON output (simplified parsing)
-	outputStr := string(output)
-
-	result.Docker = strings.Contains(outputStr, `"docker":true`) ||
-		strings.Contains(outputStr, `"docker": "running"`)
-
-	result.Terminal = strings.Contains(outputStr, `"zsh":true`) ||
-		strings.Contains(outputStr, `"terminal": "installed"`)
-
-	if strings.Contains(outputStr, `"tailscale":true`) ||
-		strings.Contains(outputStr, `"tailscale": "connected"`) {
-		result.Tailscale = true
-	}
-
-	// Check individual containers
-	for _, container := range []string{"doom-tailscale", "doom-code-server", "doom-claude"} {
-		result.Containers[container] = strings.Contains(outputStr, fmt.Sprintf(`"%s": "healthy"`, container))
-	}
-
-	return result, nil
-}
+	result.Docker = report.Passed(health.ProbeDocker)
+	result.Terminal = report.Passed(health.ProbeTerminalTools)
+	result.SSH = report.Passed(health.ProbeSSHHardening)
+	result.Secrets = report.Passed(health.ProbeSecrets)
 
-func (h *HealthChecker) basicChecks(result *HealthCheckResult) {
-	// Docker check
-	if _, err := exec.LookPath("docker"); err == nil {
-		if err := exec.Command("docker", "info").Run(); err == nil {
-			result.Docker = true
+	for _, container := range health.DoomContainers {
+		if probe, ok := report.Get("container:" + container); ok && probe.Status != health.StatusSkip {
+			result.Containers[container] = probe.Status == health.StatusPass
 		}
 	}
 
-	// Terminal tools check
-	if _, err := exec.LookPath("zsh"); err == nil {
-		result.Terminal = true
-	}
-
-	// Host Tailscale check (for native-tailscale mode)
-	if _, err := exec.LookPath("tailscale"); err == nil {
-		if output, err := exec.Command("tailscale", "status").Output(); err == nil {
-			if !strings.Contains(string(output), "Tailscale is stopped") {
-				result.HostTailscale = true
-			}
-		}
-		if output, err := exec.Command("tailscale", "ip", "-4").Output(); err == nil {
-			result.HostTailscaleIP = strings.TrimSpace(string(output))
-		}
+	if probe, ok := report.Get(health.ProbeTailscaleHost); ok && probe.Status == health.StatusPass {
+		result.HostTailscale = true
+		result.HostTailscaleIP = probe.Details["ip"]
 	}
-
-	// Container status - check which containers exist
-	if result.Docker {
-		// Check for Tailscale container (only in standard tailscale mode)
-		if err := exec.Command("docker", "inspect", "--format", "{{.State.Running}}", "doom-tailscale").Run(); err == nil {
-			result.Containers["doom-tailscale"] = true
-			result.Tailscale = true
-		}
-		// Check for core containers (present in all Docker modes)
-		for _, container := range []string{"doom-code-server", "doom-claude"} {
-			if err := exec.Command("docker", "inspect", "--format", "{{.State.Running}}", container).Run(); err == nil {
-				result.Containers[container] = true
-			}
-		}
+	if probe, ok := report.Get(health.ProbeTailscaleSidecar); ok && probe.Status == health.StatusPass {
+		result.Tailscale = true
+		result.TailscaleIP = probe.Details["ip"]
 	}
 
 	// In native-tailscale mode, Tailscale status comes from host
@@ -481,4 +870,17 @@ func (h *HealthChecker) basicChecks(result *HealthCheckResult) {
 		result.Tailscale = true
 		result.TailscaleIP = result.HostTailscaleIP
 	}
+
+	for _, probe := range report.Probes {
+		if probe.Status != health.StatusFail {
+			continue
+		}
+		msg := fmt.Sprintf("%s: %s", probe.Name, probe.Message)
+		if probe.Error != "" {
+			msg += fmt.Sprintf(" (%s)", probe.Error)
+		}
+		result.Errors = append(result.Errors, msg)
+	}
+
+	return result
 }
@@ -0,0 +1,122 @@
+package executor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// stepGraph is the dependency graph of a step list, addressed by step index
+type stepGraph struct {
+	deps       [][]int // Steps each step waits for
+	dependents [][]int // Steps waiting for each step
+}
+
+// buildStepGraph resolves DependsOn names into a graph and rejects unknown
+// dependencies and cycles. When no step declares dependencies the steps form
+// a chain in list order, matching the historic sequential behaviour.
+func buildStepGraph(steps []Step) (*stepGraph, error) {
+	index := make(map[string]int, len(steps))
+	declared := false
+	for i, step := range steps {
+		if step.Name != "" {
+			if _, dup := index[step.Name]; dup {
+				return nil, fmt.Errorf("duplicate step name %q", step.Name)
+			}
+			index[step.Name] = i
+		}
+		if len(step.DependsOn) > 0 {
+			declared = true
+		}
+	}
+
+	g := &stepGraph{
+		deps:       make([][]int, len(steps)),
+		dependents: make([][]int, len(steps)),
+	}
+
+	for i, step := range steps {
+		if !declared {
+			if i > 0 {
+				g.addEdge(i-1, i)
+			}
+			continue
+		}
+		for _, name := range step.DependsOn {
+			dep, ok := index[name]
+			if !ok {
+				return nil, fmt.Errorf("step %q depends on unknown step %q", step.Name, name)
+			}
+			if dep == i {
+				return nil, fmt.Errorf("step %q depends on itself", step.Name)
+			}
+			g.addEdge(dep, i)
+		}
+	}
+
+	if cycle := g.findCycle(steps); cycle != "" {
+		return nil, fmt.Errorf("dependency cycle: %s", cycle)
+	}
+	return g, nil
+}
+
+func (g *stepGraph) addEdge(from, to int) {
+	g.deps[to] = append(g.deps[to], from)
+	g.dependents[from] = append(g.dependents[from], to)
+}
+
+// roots returns the steps without dependencies
+func (g *stepGraph) roots() []int {
+	var roots []int
+	for i, deps := range g.deps {
+		if len(deps) == 0 {
+			roots = append(roots, i)
+		}
+	}
+	return roots
+}
+
+// findCycle returns a description of a dependency cycle, or "" if there is none
+func (g *stepGraph) findCycle(steps []Step) string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(steps))
+	var path []int
+
+	var visit func(i int) string
+	visit = func(i int) string {
+		state[i] = visiting
+		path = append(path, i)
+		for _, next := range g.dependents[i] {
+			switch state[next] {
+			case visiting:
+				var names []string
+				for j := len(path) - 1; j >= 0; j-- {
+					names = append([]string{steps[path[j]].Name}, names...)
+					if path[j] == next {
+						break
+					}
+				}
+				return strings.Join(append(names, steps[next].Name), " -> ")
+			case unvisited:
+				if cycle := visit(next); cycle != "" {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[i] = visited
+		return ""
+	}
+
+	for i := range steps {
+		if state[i] == unvisited {
+			if cycle := visit(i); cycle != "" {
+				return cycle
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,176 @@
+package executor
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildStepGraphChainsUndeclaredSteps(t *testing.T) {
+	g, err := buildStepGraph([]Step{{Name: "a"}, {Name: "b"}, {Name: "c"}})
+	if err != nil {
+		t.Fatalf("buildStepGraph failed: %v", err)
+	}
+
+	roots := g.roots()
+	if len(roots) != 1 || roots[0] != 0 {
+		t.Errorf("Expected only the first step as root, got %v", roots)
+	}
+	if len(g.deps[2]) != 1 || g.deps[2][0] != 1 {
+		t.Errorf("Expected c to depend on b, got %v", g.deps[2])
+	}
+}
+
+func TestBuildStepGraphDeclared(t *testing.T) {
+	g, err := buildStepGraph([]Step{
+		{Name: "base"},
+		{Name: "tools", DependsOn: []string{"base"}},
+		{Name: "secrets", DependsOn: []string{"base"}},
+		{Name: "env", DependsOn: []string{"secrets", "tools"}},
+	})
+	if err != nil {
+		t.Fatalf("buildStepGraph failed: %v", err)
+	}
+
+	if len(g.dependents[0]) != 2 {
+		t.Errorf("Expected base to have 2 dependents, got %v", g.dependents[0])
+	}
+	if len(g.deps[3]) != 2 {
+		t.Errorf("Expected env to wait for 2 steps, got %v", g.deps[3])
+	}
+}
+
+func TestBuildStepGraphErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []Step
+		want  string
+	}{
+		{"unknown", []Step{{Name: "a", DependsOn: []string{"missing"}}}, "unknown step"},
+		{"self", []Step{{Name: "a", DependsOn: []string{"a"}}}, "itself"},
+		{"duplicate", []Step{{Name: "a"}, {Name: "a"}}, "duplicate"},
+		{"cycle", []Step{
+			{Name: "a", DependsOn: []string{"c"}},
+			{Name: "b", DependsOn: []string{"a"}},
+			{Name: "c", DependsOn: []string{"b"}},
+		}, "a -> b -> c -> a"},
+	}
+
+	for _, tc := range tests {
+		_, err := buildStepGraph(tc.steps)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: expected error containing %q, got %v", tc.name, tc.want, err)
+		}
+	}
+}
+
+func TestDefaultStepsFormValidGraph(t *testing.T) {
+	if _, err := buildStepGraph(getDefaultSteps("/test")); err != nil {
+		t.Errorf("Default steps should form a valid graph: %v", err)
+	}
+}
+
+func TestRunStepsParallel(t *testing.T) {
+	exec := &Executor{
+		ProjectRoot: t.TempDir(),
+		Steps: []Step{
+			{Name: "root", Command: "true"},
+			{Name: "slow1", Command: "sleep", Args: []string{"0.3"}, DependsOn: []string{"root"}},
+			{Name: "slow2", Command: "sleep", Args: []string{"0.3"}, DependsOn: []string{"root"}},
+			{Name: "slow3", Command: "sleep", Args: []string{"0.3"}, DependsOn: []string{"root"}},
+			{Name: "join", Command: "true", DependsOn: []string{"slow1", "slow2", "slow3"}},
+		},
+	}
+
+	start := time.Now()
+	if err := exec.RunSteps(context.Background()); err != nil {
+		t.Fatalf("RunSteps failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 800*time.Millisecond {
+		t.Errorf("Independent steps should run concurrently, took %v", elapsed)
+	}
+
+	for _, result := range exec.GetResults() {
+		if result.Status != StepSucceeded {
+			t.Errorf("%s: expected success, got %s", result.Step.Name, result.Status)
+		}
+	}
+}
+
+func TestRunStepsMaxParallel(t *testing.T) {
+	exec := &Executor{
+		ProjectRoot: t.TempDir(),
+		MaxParallel: 1,
+		Steps: []Step{
+			{Name: "a", Command: "sleep", Args: []string{"0.2"}, DependsOn: []string{}},
+			{Name: "b", Command: "sleep", Args: []string{"0.2"}, DependsOn: []string{}},
+			{Name: "c", Command: "sleep", Args: []string{"0.2"}, DependsOn: []string{"a"}},
+		},
+	}
+
+	start := time.Now()
+	if err := exec.RunSteps(context.Background()); err != nil {
+		t.Fatalf("RunSteps failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 600*time.Millisecond {
+		t.Errorf("MaxParallel=1 should serialize steps, took %v", elapsed)
+	}
+}
+
+func TestRunStepsBlocksDependentsOfFailure(t *testing.T) {
+	exec := &Executor{
+		ProjectRoot: t.TempDir(),
+		Steps: []Step{
+			{Name: "base", Command: "true"},
+			{Name: "broken", Command: "false", DependsOn: []string{"base"}},
+			{Name: "after_broken", Command: "true", DependsOn: []string{"broken"}},
+			{Name: "transitive", Command: "true", DependsOn: []string{"after_broken"}},
+			{Name: "flaky", Command: "false", Optional: true, DependsOn: []string{"base"}},
+			{Name: "after_flaky", Command: "true", DependsOn: []string{"flaky"}},
+			{Name: "independent", Command: "true", DependsOn: []string{"base"}},
+		},
+	}
+
+	err := exec.RunSteps(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Fatalf("Expected failure naming broken step, got %v", err)
+	}
+
+	expected := map[string]StepStatus{
+		"base":         StepSucceeded,
+		"broken":       StepFailed,
+		"after_broken": StepBlocked,
+		"transitive":   StepBlocked,
+		"flaky":        StepFailed,
+		"after_flaky":  StepSucceeded,
+		"independent":  StepSucceeded,
+	}
+
+	results := exec.GetResults()
+	if len(results) != len(exec.Steps) {
+		t.Fatalf("Expected %d outcomes, got %d", len(exec.Steps), len(results))
+	}
+	for i, result := range results {
+		if result.Step.Name != exec.Steps[i].Name {
+			t.Errorf("Result %d should be %s, got %s", i, exec.Steps[i].Name, result.Step.Name)
+		}
+		if result.Status != expected[result.Step.Name] {
+			t.Errorf("%s: expected %s, got %s", result.Step.Name, expected[result.Step.Name], result.Status)
+		}
+	}
+}
+
+func TestRunStepsInvalidGraph(t *testing.T) {
+	exec := &Executor{
+		ProjectRoot: t.TempDir(),
+		Steps:       []Step{{Name: "a", Command: "true", DependsOn: []string{"nope"}}},
+	}
+
+	if err := exec.RunSteps(context.Background()); err == nil {
+		t.Fatal("Expected error for unknown dependency")
+	}
+	if len(exec.GetResults()) != 0 {
+		t.Error("No step should run when the graph is invalid")
+	}
+}
@@ -0,0 +1,203 @@
+package executor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// JournalFile is the install journal location relative to the project root
+const JournalFile = ".install-journal.json"
+
+// journalVersion is bumped when the journal format changes incompatibly
+const journalVersion = 1
+
+// StepRunning marks a journal entry for a step that started but never
+// finished, e.g. because the installer was killed
+const StepRunning StepStatus = "running"
+
+// JournalEntry records the last run of a single step
+type JournalEntry struct {
+	Step         string     `json:"step"`
+	InputHash    string     `json:"input_hash"`
+	Status       StepStatus `json:"status"`
+	StartedAt    time.Time  `json:"started_at"`
+	FinishedAt   time.Time  `json:"finished_at,omitempty"`
+	OutputDigest string     `json:"output_digest,omitempty"`
+	Error        string     `json:"error,omitempty"`
+}
+
+// Journal persists step outcomes so an interrupted install can be resumed
+type Journal struct {
+	Version   int                     `json:"version"`
+	UpdatedAt time.Time               `json:"updated_at"`
+	Steps     map[string]JournalEntry `json:"steps"`
+
+	path string
+	mu   sync.Mutex
+}
+
+// LoadJournal reads the journal at path; a missing file yields an empty journal
+func LoadJournal(path string) (*Journal, error) {
+	j := &Journal{
+		Version: journalVersion,
+		Steps:   make(map[string]JournalEntry),
+		path:    path,
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return j, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read install journal: %w", err)
+	}
+
+	if err := json.Unmarshal(data, j); err != nil {
+		return nil, fmt.Errorf("failed to parse install journal: %w", err)
+	}
+	if j.Version != journalVersion {
+		return nil, fmt.Errorf("unsupported install journal version %d", j.Version)
+	}
+	if j.Steps == nil {
+		j.Steps = make(map[string]JournalEntry)
+	}
+	return j, nil
+}
+
+// Path returns the file the journal is saved to
+func (j *Journal) Path() string {
+	return j.path
+}
+
+// Entry returns the recorded entry for a step
+func (j *Journal) Entry(name string) (JournalEntry, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	entry, ok := j.Steps[name]
+	return entry, ok
+}
+
+// Completed reports whether the step succeeded previously with the same
+// input hash
+func (j *Journal) Completed(name, inputHash string) bool {
+	entry, ok := j.Entry(name)
+	return ok && entry.Status == StepSucceeded && entry.InputHash == inputHash
+}
+
+// Start records that a step with the given input hash has begun
+func (j *Journal) Start(name, inputHash string, at time.Time) error {
+	return j.record(JournalEntry{
+		Step:      name,
+		InputHash: inputHash,
+		Status:    StepRunning,
+		StartedAt: at,
+	})
+}
+
+// Finish records the outcome of a step that ran with the given input hash
+func (j *Journal) Finish(result StepResult, inputHash string) error {
+	entry := JournalEntry{
+		Step:         result.Step.Name,
+		InputHash:    inputHash,
+		Status:       result.Status,
+		FinishedAt:   time.Now(),
+		OutputDigest: digest(result.Output),
+	}
+	entry.StartedAt = entry.FinishedAt.Add(-result.Duration)
+	if result.Error != nil {
+		entry.Error = result.Error.Error()
+	}
+	return j.record(entry)
+}
+
+// Reset clears all entries and removes the journal file
+func (j *Journal) Reset() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.Steps = make(map[string]JournalEntry)
+	if err := os.Remove(j.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove install journal: %w", err)
+	}
+	return nil
+}
+
+func (j *Journal) record(entry JournalEntry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.Steps[entry.Step] = entry
+	j.UpdatedAt = time.Now()
+	return j.save()
+}
+
+// save writes the journal atomically so a crash never leaves it truncated
+func (j *Journal) save() error {
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize install journal: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(j.path), filepath.Base(j.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write install journal: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write install journal: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write install journal: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), j.path); err != nil {
+		return fmt.Errorf("failed to write install journal: %w", err)
+	}
+	return nil
+}
+
+// InputHash identifies what a step would run; a changed hash invalidates
+// the journal entry for the step. RunSteps adds the contents of the
+// Executor Inputs files to it.
+func (s *Step) InputHash() string {
+	return hashParts(append([]string{s.Name, s.Command, s.WorkDir}, s.Args...)...)
+}
+
+// hashParts hashes strings so that no two different lists collide
+func hashParts(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// digestFiles hashes the paths and contents of files. A missing file
+// hashes differently from an empty one, and unreadable files by their
+// error, so that the hash changes with them.
+func digestFiles(paths []string) string {
+	parts := make([]string, 0, 2*len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			parts = append(parts, path, digest(string(data)))
+		case os.IsNotExist(err):
+			parts = append(parts, path, "missing")
+		default:
+			parts = append(parts, path, "error: "+err.Error())
+		}
+	}
+	return hashParts(parts...)
+}
+
+func digest(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
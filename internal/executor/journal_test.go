@@ -0,0 +1,287 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/doom-coding/doom-coding/internal/runner"
+)
+
+func TestLoadJournalMissing(t *testing.T) {
+	j, err := LoadJournal(filepath.Join(t.TempDir(), JournalFile))
+	if err != nil {
+		t.Fatalf("LoadJournal failed: %v", err)
+	}
+	if len(j.Steps) != 0 {
+		t.Errorf("Expected empty journal, got %v", j.Steps)
+	}
+}
+
+func TestJournalRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), JournalFile)
+	j, _ := LoadJournal(path)
+
+	step := &Step{Name: "docker_install", Command: "bash", Args: []string{"-c", "true"}}
+	if err := j.Start(step.Name, step.InputHash(), time.Now()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if err := j.Finish(StepResult{Step: step, Status: StepFailed, Output: "boom", Error: errors.New("exit status 1")}, step.InputHash()); err != nil {
+		t.Fatalf("Finish failed: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Journal not written: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected journal mode 0600, got %v", info.Mode().Perm())
+	}
+
+	loaded, err := LoadJournal(path)
+	if err != nil {
+		t.Fatalf("LoadJournal failed: %v", err)
+	}
+	entry, ok := loaded.Entry("docker_install")
+	if !ok {
+		t.Fatal("Entry not persisted")
+	}
+	if entry.Status != StepFailed || entry.Error != "exit status 1" || !strings.HasPrefix(entry.OutputDigest, "sha256:") {
+		t.Errorf("Unexpected entry: %+v", entry)
+	}
+	if loaded.Completed(step.Name, step.InputHash()) {
+		t.Error("Failed step should not count as completed")
+	}
+}
+
+func TestJournalCompletedRequiresMatchingInputs(t *testing.T) {
+	j, _ := LoadJournal(filepath.Join(t.TempDir(), JournalFile))
+
+	step := &Step{Name: "env_config", Command: "echo", Args: []string{"a"}}
+	j.Finish(StepResult{Step: step, Status: StepSucceeded}, step.InputHash())
+	if !j.Completed(step.Name, step.InputHash()) {
+		t.Error("Succeeded step with same inputs should be completed")
+	}
+
+	changed := &Step{Name: "env_config", Command: "echo", Args: []string{"b"}}
+	if j.Completed(changed.Name, changed.InputHash()) {
+		t.Error("Changed inputs should invalidate the journal entry")
+	}
+}
+
+func TestLoadJournalRejectsUnknownVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), JournalFile)
+	os.WriteFile(path, []byte(`{"version": 99, "steps": {}}`), 0600)
+
+	if _, err := LoadJournal(path); err == nil {
+		t.Error("Expected error for unknown journal version")
+	}
+}
+
+func TestRunStepsResume(t *testing.T) {
+	dir := t.TempDir()
+	counter := filepath.Join(dir, "count")
+	flag := filepath.Join(dir, "network-ok")
+
+	steps := []Step{
+		{Name: "system_check", Command: "sh", Args: []string{"-c", "echo run >> " + counter}},
+		{Name: "services_start", Command: "test", Args: []string{"-f", flag}, DependsOn: []string{"system_check"}},
+		{Name: "health_check", Command: "true", DependsOn: []string{"services_start"}},
+	}
+
+	journal, _ := LoadJournal(filepath.Join(dir, JournalFile))
+	first := &Executor{ProjectRoot: dir, Steps: steps, Journal: journal}
+	if err := first.RunSteps(context.Background()); err == nil {
+		t.Fatal("First run should fail at services_start")
+	}
+
+	os.WriteFile(flag, nil, 0644)
+
+	journal, err := LoadJournal(filepath.Join(dir, JournalFile))
+	if err != nil {
+		t.Fatalf("LoadJournal failed: %v", err)
+	}
+	second := &Executor{ProjectRoot: dir, Steps: steps, Journal: journal, Resume: true}
+	if err := second.RunSteps(context.Background()); err != nil {
+		t.Fatalf("Resumed run failed: %v", err)
+	}
+
+	expected := []StepStatus{StepResumed, StepSucceeded, StepSucceeded}
+	for i, result := range second.GetResults() {
+		if result.Status != expected[i] {
+			t.Errorf("%s: expected %s, got %s", result.Step.Name, expected[i], result.Status)
+		}
+	}
+
+	data, _ := os.ReadFile(counter)
+	if runs := strings.Count(string(data), "run"); runs != 1 {
+		t.Errorf("Completed step should not run again, ran %d times", runs)
+	}
+}
+
+func TestRunStepsResumeRerunsDependentsOfChangedSteps(t *testing.T) {
+	dir := t.TempDir()
+	counter := filepath.Join(dir, "count")
+
+	steps := []Step{
+		{Name: "base", Command: "echo", Args: []string{"v1"}},
+		{Name: "after", Command: "sh", Args: []string{"-c", "echo run >> " + counter}, DependsOn: []string{"base"}},
+	}
+
+	journal, _ := LoadJournal(filepath.Join(dir, JournalFile))
+	if err := (&Executor{ProjectRoot: dir, Steps: steps, Journal: journal}).RunSteps(context.Background()); err != nil {
+		t.Fatalf("First run failed: %v", err)
+	}
+
+	steps[0].Args = []string{"v2"}
+	resumed := &Executor{ProjectRoot: dir, Steps: steps, Journal: journal, Resume: true}
+	if err := resumed.RunSteps(context.Background()); err != nil {
+		t.Fatalf("Resumed run failed: %v", err)
+	}
+
+	for _, result := range resumed.GetResults() {
+		if result.Status != StepSucceeded {
+			t.Errorf("%s: expected rerun, got %s", result.Step.Name, result.Status)
+		}
+	}
+	data, _ := os.ReadFile(counter)
+	if runs := strings.Count(string(data), "run"); runs != 2 {
+		t.Errorf("Dependent of a changed step should run again, ran %d times", runs)
+	}
+}
+
+func TestRunStepsResumeRerunsStepsOfChangedInputs(t *testing.T) {
+	dir := t.TempDir()
+	counter := filepath.Join(dir, "count")
+	envFile := filepath.Join(dir, ".env")
+	os.WriteFile(envFile, []byte("DEPLOYMENT_MODE=local\n"), 0600)
+
+	steps := []Step{{Name: "env_config", Command: "sh", Args: []string{"-c", "echo run >> " + counter}}}
+	journal, _ := LoadJournal(filepath.Join(dir, JournalFile))
+	run := func() *Executor {
+		exec := &Executor{ProjectRoot: dir, Steps: steps, Journal: journal, Resume: true, Inputs: []string{envFile}}
+		if err := exec.RunSteps(context.Background()); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		return exec
+	}
+
+	run()
+	if status := run().GetResults()[0].Status; status != StepResumed {
+		t.Errorf("Unchanged inputs should resume, got %s", status)
+	}
+	os.WriteFile(envFile, []byte("DEPLOYMENT_MODE=terminal-only\n"), 0600)
+	if status := run().GetResults()[0].Status; status != StepSucceeded {
+		t.Errorf("Changed .env should rerun the step, got %s", status)
+	}
+
+	data, _ := os.ReadFile(counter)
+	if runs := strings.Count(string(data), "run"); runs != 2 {
+		t.Errorf("Expected 2 runs, got %d", runs)
+	}
+}
+
+// replayJournaledInstall runs install.sh against a transcript with the
+// journal and inputs in dir
+func replayJournaledInstall(t *testing.T, dir, transcript string, exitCode int, resume bool) (*Executor, *fakeRunner, error) {
+	t.Helper()
+	journal, err := LoadJournal(filepath.Join(dir, JournalFile))
+	if err != nil {
+		t.Fatalf("LoadJournal failed: %v", err)
+	}
+	fake := &fakeRunner{runs: map[string][]fakeRun{"bash": {{exitCode: exitCode, output: transcript}}}}
+	exec := NewExecutor(dir)
+	exec.LogFile = filepath.Join(dir, "install.log")
+	exec.Runner = fake
+	exec.Journal = journal
+	exec.Resume = resume
+	exec.Inputs = []string{filepath.Join(dir, ".env")}
+	exec.sleep = skipSleep
+	err = exec.RunInstallScript(context.Background(), []string{"--unattended"})
+	return exec, fake, err
+}
+
+// resumeEnv returns the value of ResumeEnv the script was run with
+func resumeEnv(fake *fakeRunner) (string, bool) {
+	return resumeEnvOf(fake.calls[0])
+}
+
+// resumeEnvOf returns the value of ResumeEnv a command was run with
+func resumeEnvOf(cmd runner.Command) (string, bool) {
+	for _, env := range cmd.Env {
+		if value, ok := strings.CutPrefix(env, ResumeEnv+"="); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func TestRunInstallScriptJournal(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, ".env"), []byte("DEPLOYMENT_MODE=local\n"), 0600)
+	failure, err := os.ReadFile(filepath.Join("testdata", "install-failure.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, fake, err := replayJournaledInstall(t, dir, string(failure), 100, false); err == nil {
+		t.Fatal("Expected the install script to fail")
+	} else if _, ok := resumeEnv(fake); ok {
+		t.Errorf("%s should only be set when resuming", ResumeEnv)
+	}
+	journal, _ := LoadJournal(filepath.Join(dir, JournalFile))
+	for name, want := range map[string]StepStatus{"system_check": StepSucceeded, "base_packages": StepSucceeded, "docker_install": StepFailed} {
+		if entry, ok := journal.Entry(name); !ok || entry.Status != want {
+			t.Errorf("%s: expected journal status %s, got %+v", name, want, entry)
+		}
+	}
+
+	resumed := strings.Join([]string{
+		"::doom-step name=system_check status=start",
+		"::doom-step name=system_check status=done",
+		"::doom-step name=base_packages status=skip",
+		"::doom-step name=docker_install status=start",
+		"::doom-step name=docker_install status=done",
+	}, "\n") + "\n"
+	exec, fake, err := replayJournaledInstall(t, dir, resumed, 0, true)
+	if err != nil {
+		t.Fatalf("Resumed run failed: %v", err)
+	}
+	if steps, _ := resumeEnv(fake); steps != "base_packages,system_check" {
+		t.Errorf("Expected the completed steps in %s, got %q", ResumeEnv, steps)
+	}
+	if status := exec.GetResults()[1].Status; status != StepResumed {
+		t.Errorf("Skipped completed step should be resumed, got %s", status)
+	}
+	journal, _ = LoadJournal(filepath.Join(dir, JournalFile))
+	if entry, ok := journal.Entry("docker_install"); !ok || entry.Status != StepSucceeded {
+		t.Errorf("docker_install should be completed after the resumed run, got %+v", entry)
+	}
+
+	os.WriteFile(filepath.Join(dir, ".env"), []byte("DEPLOYMENT_MODE=terminal-only\n"), 0600)
+	_, fake, _ = replayJournaledInstall(t, dir, resumed, 0, true)
+	if steps, ok := resumeEnv(fake); !ok || steps != "" {
+		t.Errorf("Changed .env should resume no steps, got %q", steps)
+	}
+}
+
+func TestRunInstallScriptDryRunWritesNoJournal(t *testing.T) {
+	dir := t.TempDir()
+	fake := &fakeRunner{runs: map[string][]fakeRun{"bash": {{output: "::doom-step name=system_check status=start\n::doom-step name=system_check status=done\n"}}}}
+	exec := NewExecutor(dir)
+	exec.LogFile = filepath.Join(dir, "install.log")
+	exec.Runner = fake
+	exec.Journal, _ = LoadJournal(filepath.Join(dir, JournalFile))
+	exec.DryRun = true
+
+	if err := exec.RunInstallScript(context.Background(), nil); err != nil {
+		t.Fatalf("RunInstallScript failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, JournalFile)); !os.IsNotExist(err) {
+		t.Errorf("A dry run should not write the journal, got %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package executor
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// jsonEvent is the JSON-lines representation of an Event
+type jsonEvent struct {
+	Type        string       `json:"type"`
+	Time        time.Time    `json:"time"`
+	Step        string       `json:"step,omitempty"`
+	Index       int          `json:"index,omitempty"`
+	Total       int          `json:"total,omitempty"`
+	Stream      Stream       `json:"stream,omitempty"`
+	Line        *string      `json:"line,omitempty"`
+	Attempt     int          `json:"attempt,omitempty"`
+	MaxAttempts int          `json:"max_attempts,omitempty"`
+	BackoffMs   int64        `json:"backoff_ms,omitempty"`
+	Status      StepStatus   `json:"status,omitempty"`
+	DurationMs  int64        `json:"duration_ms,omitempty"`
+	Error       string       `json:"error,omitempty"`
+	Success     *bool        `json:"success,omitempty"`
+	Results     []jsonResult `json:"results,omitempty"`
+}
+
+// jsonResult summarizes a StepResult in a run_finished event
+type jsonResult struct {
+	Step       string     `json:"step"`
+	Status     StepStatus `json:"status"`
+	Attempts   int        `json:"attempts,omitempty"`
+	DurationMs int64      `json:"duration_ms"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// WriteJSONLines writes each event from events to w as one JSON object per
+// line until the channel is closed. After a write error the remaining
+// events are drained so the run is not blocked.
+func WriteJSONLines(w io.Writer, events <-chan Event) error {
+	enc := json.NewEncoder(w)
+	var firstErr error
+	for ev := range events {
+		if firstErr != nil {
+			continue
+		}
+		if err := enc.Encode(toJSONEvent(ev)); err != nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func toJSONEvent(ev Event) jsonEvent {
+	out := jsonEvent{Type: ev.EventType()}
+
+	switch ev := ev.(type) {
+	case StepStarted:
+		out.Time, out.Step, out.Index, out.Total = ev.Time, stepName(ev.Step), ev.Index, ev.Total
+	case StepOutput:
+		out.Time, out.Step, out.Index, out.Total = ev.Time, stepName(ev.Step), ev.Index, ev.Total
+		out.Stream = ev.Stream
+		out.Line = &ev.Line
+	case StepRetried:
+		out.Time, out.Step, out.Index, out.Total = ev.Time, stepName(ev.Step), ev.Index, ev.Total
+		out.Attempt = ev.Attempt
+		out.MaxAttempts = ev.MaxAttempts
+		out.BackoffMs = ev.Backoff.Milliseconds()
+		out.Error = errorString(ev.Err)
+	case StepFinished:
+		out.Time, out.Step, out.Index, out.Total = ev.Time, stepName(ev.Step), ev.Index, ev.Total
+		out.Status = ev.Result.Status
+		out.Attempt = len(ev.Result.Attempts)
+		out.DurationMs = ev.Result.Duration.Milliseconds()
+		out.Error = errorString(ev.Result.Error)
+	case RunFinished:
+		success := ev.Err == nil
+		out.Time = ev.Time
+		out.Success = &success
+		out.DurationMs = ev.Duration.Milliseconds()
+		out.Error = errorString(ev.Err)
+		for _, result := range ev.Results {
+			out.Results = append(out.Results, jsonResult{
+				Step:       stepName(result.Step),
+				Status:     result.Status,
+				Attempts:   len(result.Attempts),
+				DurationMs: result.Duration.Milliseconds(),
+				Error:      errorString(result.Error),
+			})
+		}
+	}
+	return out
+}
+
+func stepName(step *Step) string {
+	if step == nil {
+		return ""
+	}
+	return step.Name
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,353 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Step marker protocol
+//
+// When MarkerEnv is set to 1, install.sh announces each installation phase
+// on stdout with a line of the form
+//
+//	::doom-step name=docker_install status=start index=3 total=10
+//
+// Fields are space separated key=value pairs in any order. name and status
+// are required; status is one of start, done, skip or fail. index (1-based)
+// and total are optional, and unknown keys are ignored so the script can add
+// fields without breaking older binaries. Steps do not nest. Any line that
+// is not a valid marker is passed through as output of the open step.
+//
+// ResumeEnv lists the steps completed by a previous run, separated by
+// commas. The script skips those of them it can skip without losing state
+// later steps need, and marks them with status=skip. The executor sets it
+// when resuming a journaled install and when retrying a failed step.
+
+// MarkerPrefix starts every step marker line
+const MarkerPrefix = "::doom-step"
+
+// MarkerEnv is the environment variable that enables markers in install.sh
+const MarkerEnv = "DOOM_STEP_MARKERS"
+
+// ResumeEnv is the environment variable listing the steps install.sh may skip
+const ResumeEnv = "DOOM_RESUME_STEPS"
+
+// MarkerStatus is the state change announced by a marker
+type MarkerStatus string
+
+const (
+	MarkerStart MarkerStatus = "start"
+	MarkerDone  MarkerStatus = "done"
+	MarkerSkip  MarkerStatus = "skip"
+	MarkerFail  MarkerStatus = "fail"
+)
+
+// Marker is a parsed step marker line
+type Marker struct {
+	Name   string
+	Status MarkerStatus
+	Index  int // 0 when not given
+	Total  int // 0 when not given
+}
+
+// ParseMarker parses a step marker line. It returns false for lines that
+// are not well-formed markers, which callers treat as regular output.
+func ParseMarker(line string) (Marker, bool) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || fields[0] != MarkerPrefix {
+		return Marker{}, false
+	}
+
+	var m Marker
+	for _, field := range fields[1:] {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok || value == "" {
+			return Marker{}, false
+		}
+		switch key {
+		case "name":
+			m.Name = value
+		case "status":
+			m.Status = MarkerStatus(value)
+		case "index", "total":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return Marker{}, false
+			}
+			if key == "index" {
+				m.Index = n
+			} else {
+				m.Total = n
+			}
+		}
+	}
+
+	switch m.Status {
+	case MarkerStart, MarkerDone, MarkerSkip, MarkerFail:
+	default:
+		return Marker{}, false
+	}
+	if m.Name == "" {
+		return Marker{}, false
+	}
+	return m, true
+}
+
+// errStepReported is the error of a step the script marked as failed
+var errStepReported = errors.New("install script reported the step as failed")
+
+// scriptProgress turns install script output into step events and results
+type scriptProgress struct {
+	e       *Executor
+	ctx     context.Context // Context of the run, to tell cancellation from failure
+	total   int
+	started int // Steps started so far, used when markers carry no index
+
+	current *Step
+	index   int
+	start   time.Time
+	output  strings.Builder
+
+	// journal records the steps when set, with the input hash given by hash
+	journal    *Journal
+	hash       func(name string) string
+	journalErr error    // First error recording a step
+	resumed    []string // Steps the script was allowed to skip
+
+	// Results of earlier runs of the script are replaced by those of its
+	// retries: positions are their indexes in the executor results by step
+	// name, and attempts the attempts of each step so far
+	positions   map[string]int
+	attempts    map[string][]AttemptResult
+	failed      string // Step of the last failure
+	failedIndex int
+}
+
+func newScriptProgress(ctx context.Context, e *Executor) *scriptProgress {
+	return &scriptProgress{
+		e:         e,
+		ctx:       ctx,
+		total:     len(e.Steps),
+		positions: make(map[string]int),
+		attempts:  make(map[string][]AttemptResult),
+	}
+}
+
+// line handles one line of script output
+func (p *scriptProgress) line(stream Stream, line string) {
+	m, ok := ParseMarker(line)
+	if !ok {
+		if p.current != nil {
+			p.output.WriteString(line)
+			p.output.WriteString("\n")
+		}
+		p.e.emit(StepOutput{Step: p.current, Index: p.index, Total: p.total, Stream: stream, Line: line, Time: time.Now()})
+		return
+	}
+
+	if m.Total > 0 {
+		p.total = m.Total
+	}
+
+	switch m.Status {
+	case MarkerStart:
+		if p.current != nil {
+			p.finishStep(StepSucceeded, nil)
+		}
+		p.started++
+		p.current = p.e.lookupStep(m.Name)
+		p.index = m.Index
+		if p.index == 0 {
+			p.index = p.started
+		}
+		p.start = time.Now()
+		p.output.Reset()
+
+		p.e.mu.Lock()
+		p.e.currentStep = p.index - 1
+		p.e.mu.Unlock()
+		if p.journal != nil {
+			p.recordJournal(p.journal.Start(m.Name, p.hash(m.Name), p.start))
+		}
+		p.e.emit(StepStarted{Step: p.current, Index: p.index, Total: p.total, Time: p.start})
+
+	case MarkerDone, MarkerFail:
+		if p.current == nil || p.current.Name != m.Name {
+			// A step that was never started finishes without duration
+			p.current = p.e.lookupStep(m.Name)
+			p.index = m.Index
+			p.start = time.Now()
+			p.output.Reset()
+		}
+		switch {
+		case m.Status == MarkerDone:
+			p.finishStep(StepSucceeded, nil)
+		case p.cancelled():
+			p.finishStep(StepCancelled, p.ctx.Err())
+		default:
+			p.finishStep(StepFailed, errStepReported)
+		}
+
+	case MarkerSkip:
+		if _, ok := p.positions[m.Name]; ok {
+			// Completed by an earlier run of this install
+			return
+		}
+		step := p.e.lookupStep(m.Name)
+		if p.isResumed(m.Name) {
+			p.record(StepResult{
+				Step:    step,
+				Status:  StepResumed,
+				Success: true,
+				Output:  "Skipped (completed in a previous run)",
+			}, m.Index)
+			return
+		}
+		p.record(StepResult{
+			Step:    step,
+			Status:  StepSkipped,
+			Success: true,
+			Output:  "Skipped by install script",
+		}, m.Index)
+	}
+}
+
+// close finishes a step left open when the script exited
+func (p *scriptProgress) close(err error) {
+	if p.current == nil {
+		return
+	}
+	switch {
+	case err == nil:
+		p.finishStep(StepSucceeded, nil)
+	case p.cancelled():
+		p.finishStep(StepCancelled, err)
+	default:
+		p.finishStep(StepFailed, err)
+	}
+}
+
+// cancelled reports whether the script is being stopped by a cancel
+func (p *scriptProgress) cancelled() bool {
+	return p.ctx.Err() != nil || p.e.isCancelled()
+}
+
+func (p *scriptProgress) finishStep(status StepStatus, err error) {
+	name := p.current.Name
+	attempt := AttemptResult{
+		Attempt:  len(p.attempts[name]) + 1,
+		ExitCode: -1,
+		Output:   p.output.String(),
+		Error:    err,
+		Duration: time.Since(p.start),
+	}
+	if err == nil {
+		attempt.ExitCode = 0
+	}
+	p.attempts[name] = append(p.attempts[name], attempt)
+
+	result := StepResult{
+		Step:     p.current,
+		Status:   status,
+		Success:  status == StepSucceeded,
+		Output:   attempt.Output,
+		Error:    err,
+		Duration: attempt.Duration,
+		Attempts: append([]AttemptResult(nil), p.attempts[name]...),
+	}
+	index := p.index
+	if status == StepFailed {
+		p.failed, p.failedIndex = name, index
+	}
+	p.current = nil
+	p.index = 0
+	p.output.Reset()
+	if p.journal != nil {
+		p.recordJournal(p.journal.Finish(result, p.hash(result.Step.Name)))
+	}
+	p.record(result, index)
+}
+
+// isResumed reports whether the script was allowed to skip a step
+func (p *scriptProgress) isResumed(name string) bool {
+	for _, resumed := range p.resumed {
+		if resumed == name {
+			return true
+		}
+	}
+	return false
+}
+
+// recordJournal keeps the first error of writing the journal
+func (p *scriptProgress) recordJournal(err error) {
+	if err != nil && p.journalErr == nil {
+		p.journalErr = err
+	}
+}
+
+// failedStep returns the result of the step of the last failure
+func (p *scriptProgress) failedStep() (StepResult, bool) {
+	p.e.mu.Lock()
+	defer p.e.mu.Unlock()
+	i, ok := p.positions[p.failed]
+	if !ok || p.e.results[i].Status != StepFailed {
+		return StepResult{}, false
+	}
+	return p.e.results[i], true
+}
+
+// updateAttempt changes the last attempt of a step
+func (p *scriptProgress) updateAttempt(name string, update func(a *AttemptResult)) {
+	attempts := p.attempts[name]
+	if len(attempts) == 0 {
+		return
+	}
+	update(&attempts[len(attempts)-1])
+
+	p.e.mu.Lock()
+	defer p.e.mu.Unlock()
+	if i, ok := p.positions[name]; ok {
+		p.e.results[i].Attempts = append([]AttemptResult(nil), attempts...)
+	}
+}
+
+// completed returns the steps that succeeded or were resumed so far
+func (p *scriptProgress) completed() []string {
+	p.e.mu.Lock()
+	defer p.e.mu.Unlock()
+	var names []string
+	for _, result := range p.e.results {
+		if result.Success && result.Status != StepSkipped {
+			names = append(names, result.Step.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (p *scriptProgress) record(result StepResult, index int) {
+	p.e.mu.Lock()
+	if i, ok := p.positions[result.Step.Name]; ok {
+		p.e.results[i] = result
+	} else {
+		p.positions[result.Step.Name] = len(p.e.results)
+		p.e.results = append(p.e.results, result)
+	}
+	p.e.mu.Unlock()
+	p.e.emit(StepFinished{Step: result.Step, Index: index, Total: p.total, Result: result, Time: time.Now()})
+}
+
+// lookupStep returns the configured step with the given name, or a new step
+// for names the executor does not know
+func (e *Executor) lookupStep(name string) *Step {
+	for i := range e.Steps {
+		if e.Steps[i].Name == name {
+			return &e.Steps[i]
+		}
+	}
+	return &Step{Name: name}
+}
@@ -0,0 +1,188 @@
+package executor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseMarker(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected Marker
+		ok       bool
+	}{
+		{"::doom-step name=docker_install status=start index=3 total=10", Marker{Name: "docker_install", Status: MarkerStart, Index: 3, Total: 10}, true},
+		{"::doom-step status=done name=env_config", Marker{Name: "env_config", Status: MarkerDone}, true},
+		{"  ::doom-step name=a status=skip future=field", Marker{Name: "a", Status: MarkerSkip}, true},
+		{"::doom-step name=a status=fail\r", Marker{Name: "a", Status: MarkerFail}, true},
+		{"::doom-step name=a", Marker{}, false},
+		{"::doom-step status=start", Marker{}, false},
+		{"::doom-step name=a status=paused", Marker{}, false},
+		{"::doom-step name=a status=start index=x", Marker{}, false},
+		{"::doom-step name=a status=start total=-1", Marker{}, false},
+		{"::doom-step name= status=start", Marker{}, false},
+		{"::doom-stepname=a status=start", Marker{}, false},
+		{"echo ::doom-step name=a status=start", Marker{}, false},
+		{"==> Installing Docker", Marker{}, false},
+		{"", Marker{}, false},
+	}
+
+	for _, tc := range tests {
+		m, ok := ParseMarker(tc.line)
+		if ok != tc.ok || m != tc.expected {
+			t.Errorf("ParseMarker(%q) = %+v, %v; expected %+v, %v", tc.line, m, ok, tc.expected, tc.ok)
+		}
+	}
+}
+
+// replayInstall runs install.sh against a recorded transcript
+func replayInstall(t *testing.T, transcript string, exitCode int) (*Executor, *fakeRunner, []Event, error) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", transcript))
+	if err != nil {
+		t.Fatalf("Failed to read transcript: %v", err)
+	}
+
+	fake := &fakeRunner{runs: map[string][]fakeRun{"bash": {{exitCode: exitCode, output: string(data)}}}}
+	exec := NewExecutor("/test/project")
+	exec.LogFile = filepath.Join(t.TempDir(), "install.log")
+	exec.Runner = fake
+	exec.sleep = skipSleep
+
+	events := collectEvents(exec)
+	err = exec.RunInstallScript(context.Background(), nil)
+	return exec, fake, events(), err
+}
+
+func TestRunInstallScriptTranscript(t *testing.T) {
+	exec, fake, events, err := replayInstall(t, "install-success.txt", 0)
+	if err != nil {
+		t.Fatalf("RunInstallScript failed: %v", err)
+	}
+
+	if len(fake.calls) != 1 || len(fake.calls[0].Env) != 1 || fake.calls[0].Env[0] != MarkerEnv+"=1" {
+		t.Errorf("Install script should run with %s=1, got %+v", MarkerEnv, fake.calls)
+	}
+	if fake.calls[0].ProcessGroup {
+		t.Error("install.sh prompts on the terminal and must not run in a background process group")
+	}
+
+	var started []int
+	finished := map[string]StepStatus{}
+	for _, ev := range events {
+		switch ev := ev.(type) {
+		case StepStarted:
+			started = append(started, ev.Index)
+			if ev.Total != 10 {
+				t.Errorf("%s: expected total 10, got %d", ev.Step.Name, ev.Total)
+			}
+			if ev.Step.Description == "" {
+				t.Errorf("%s: step should be resolved to its definition", ev.Step.Name)
+			}
+		case StepOutput:
+			if ev.Line == "==> apt-get install -y curl git jq" && (ev.Step == nil || ev.Step.Name != "base_packages" || ev.Index != 2) {
+				t.Errorf("Output should belong to base_packages, got %+v", ev)
+			}
+			if ev.Line == "ℹ  Starting Doom Coding installation..." && ev.Step != nil {
+				t.Errorf("Output before the first marker should have no step, got %s", ev.Step.Name)
+			}
+			if _, ok := ParseMarker(ev.Line); ok {
+				t.Errorf("Marker passed through as output: %q", ev.Line)
+			}
+		case StepFinished:
+			finished[ev.Step.Name] = ev.Result.Status
+		}
+	}
+
+	if len(started) != 8 || started[0] != 1 || started[7] != 10 {
+		t.Errorf("Unexpected started indexes: %v", started)
+	}
+	if finished["ssh_hardening"] != StepSkipped || finished["secrets_setup"] != StepSkipped {
+		t.Errorf("Skipped steps not reported: %v", finished)
+	}
+	if finished["health_check"] != StepSucceeded || len(finished) != 10 {
+		t.Errorf("Expected all 10 steps to finish, got %v", finished)
+	}
+
+	results := exec.GetResults()
+	if len(results) != 10 {
+		t.Fatalf("Expected 10 results, got %d", len(results))
+	}
+	if results[1].Output != "⏳ Installing base packages...\n==> apt-get install -y curl git jq\n✅ Base packages installed\n" {
+		t.Errorf("Unexpected base_packages output: %q", results[1].Output)
+	}
+	if _, ok := events[len(events)-1].(RunFinished); !ok {
+		t.Errorf("Last event should be run_finished, got %T", events[len(events)-1])
+	}
+}
+
+func TestRunInstallScriptFailureTranscript(t *testing.T) {
+	exec, _, events, err := replayInstall(t, "install-failure.txt", 100)
+	if err == nil {
+		t.Fatal("Expected the install script to fail")
+	}
+
+	results := exec.GetResults()
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(results))
+	}
+	failed := results[2]
+	if failed.Step.Name != "docker_install" || failed.Status != StepFailed || failed.Error == nil {
+		t.Errorf("docker_install should have failed, got %+v", failed)
+	}
+	if exec.GetCurrentStep() != 2 {
+		t.Errorf("Current step should be docker_install, got %d", exec.GetCurrentStep())
+	}
+
+	finished, ok := events[len(events)-1].(RunFinished)
+	if !ok || finished.Err != err {
+		t.Errorf("Expected run_finished with the script error, got %+v", events[len(events)-1])
+	}
+}
+
+func TestRunInstallScriptUnterminatedStep(t *testing.T) {
+	fake := &fakeRunner{runs: map[string][]fakeRun{"bash": {{
+		exitCode: 1,
+		output:   "::doom-step name=env_config status=start\nwriting .env\n",
+	}}}}
+	exec := NewExecutor("/test/project")
+	exec.LogFile = filepath.Join(t.TempDir(), "install.log")
+	exec.Runner = fake
+
+	if err := exec.RunInstallScript(context.Background(), nil); err == nil {
+		t.Fatal("Expected the install script to fail")
+	}
+
+	results := exec.GetResults()
+	if len(results) != 1 || results[0].Status != StepFailed || results[0].Output != "writing .env\n" {
+		t.Errorf("Open step should fail with the script, got %+v", results)
+	}
+}
+
+func TestRunInstallScriptWithoutMarkers(t *testing.T) {
+	exec, _, events, err := replayInstall(t, "install-legacy.txt", 0)
+	if err != nil {
+		t.Fatalf("RunInstallScript failed: %v", err)
+	}
+
+	var lines int
+	for _, ev := range events {
+		switch ev := ev.(type) {
+		case StepOutput:
+			lines++
+			if ev.Step != nil || ev.Index != 0 {
+				t.Errorf("Unmarked output should have no step: %+v", ev)
+			}
+		case StepStarted, StepFinished:
+			t.Errorf("Unexpected %s without markers", ev.EventType())
+		}
+	}
+	if lines != 5 {
+		t.Errorf("Expected 5 output lines, got %d", lines)
+	}
+	if len(exec.GetResults()) != 0 {
+		t.Errorf("Expected no step results, got %d", len(exec.GetResults()))
+	}
+}
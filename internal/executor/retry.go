@@ -0,0 +1,98 @@
+package executor
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"regexp"
+	"time"
+)
+
+// RetryPolicy controls how a failed step is retried. RunSteps runs the
+// command of the step again; RunInstallScript runs install.sh again from
+// the step, see there.
+type RetryPolicy struct {
+	MaxAttempts    int           // Total attempts including the first; 0 or 1 disables retries
+	InitialBackoff time.Duration // Delay before the second attempt
+	Multiplier     float64       // Backoff growth per attempt, 0 for 2
+	MaxBackoff     time.Duration // Upper bound for a single delay, 0 for none
+	Jitter         float64       // Fraction of the delay to randomize, 0 to 1
+
+	// A failure is retried if its exit code is listed or its output matches
+	// one of the patterns. With neither set every failure is retried.
+	RetryableExitCodes []int
+	RetryableOutput    []*regexp.Regexp
+}
+
+// AttemptResult records a single execution of a step
+type AttemptResult struct {
+	Attempt  int // 1-based
+	ExitCode int // -1 when the process did not exit normally
+	Output   string
+	Error    error
+	Duration time.Duration
+	Backoff  time.Duration // Delay before the next attempt, 0 for the last one
+}
+
+// attempts returns the total number of attempts allowed
+func (p RetryPolicy) attempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// retryable reports whether a failed attempt qualifies for another try
+func (p RetryPolicy) retryable(exitCode int, output string) bool {
+	if len(p.RetryableExitCodes) == 0 && len(p.RetryableOutput) == 0 {
+		return true
+	}
+	for _, code := range p.RetryableExitCodes {
+		if code == exitCode {
+			return true
+		}
+	}
+	for _, re := range p.RetryableOutput {
+		if re.MatchString(output) {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the delay after the given failed attempt. random is a
+// value in [0, 1) used to apply jitter.
+func (p RetryPolicy) backoff(attempt int, random float64) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier == 0 {
+		multiplier = 2
+	}
+
+	delay := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		jitter := math.Min(p.Jitter, 1)
+		delay *= 1 - jitter + 2*jitter*random
+	}
+	return time.Duration(delay)
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// jitterSource is the random source for backoff jitter
+var jitterSource = rand.Float64
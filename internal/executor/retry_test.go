@@ -0,0 +1,313 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/doom-coding/doom-coding/internal/runner"
+)
+
+// fakeRun is a scripted outcome for one invocation
+type fakeRun struct {
+	exitCode int
+	output   string
+}
+
+// fakeRunner plays back scripted runs per command name; the last run repeats
+type fakeRunner struct {
+	mu    sync.Mutex
+	runs  map[string][]fakeRun
+	calls []runner.Command
+}
+
+func (f *fakeRunner) Run(ctx context.Context, cmd runner.Command) error {
+	f.mu.Lock()
+	f.calls = append(f.calls, cmd)
+	scripted := f.runs[cmd.Name]
+	var run fakeRun
+	if len(scripted) > 0 {
+		run = scripted[0]
+		if len(scripted) > 1 {
+			f.runs[cmd.Name] = scripted[1:]
+		}
+	}
+	f.mu.Unlock()
+
+	if cmd.Stdout != nil && run.output != "" {
+		io.WriteString(cmd.Stdout, run.output)
+	}
+	if run.exitCode != 0 {
+		return &runner.ExitError{Code: run.exitCode}
+	}
+	return nil
+}
+
+func (f *fakeRunner) LookPath(file string) (string, error) {
+	return "/usr/bin/" + file, nil
+}
+
+func (f *fakeRunner) count(name string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, call := range f.calls {
+		if call.Name == name {
+			n++
+		}
+	}
+	return n
+}
+
+// skipSleep retries without waiting
+func skipSleep(ctx context.Context, d time.Duration) error {
+	return ctx.Err()
+}
+
+// newRetryExecutor returns an executor over fake commands that records retry delays
+func newRetryExecutor(fake *fakeRunner, steps ...Step) (*Executor, *[]time.Duration) {
+	var delays []time.Duration
+	exec := &Executor{
+		ProjectRoot: "/test",
+		Steps:       steps,
+		Runner:      fake,
+		sleep: func(ctx context.Context, d time.Duration) error {
+			delays = append(delays, d)
+			return ctx.Err()
+		},
+	}
+	return exec, &delays
+}
+
+func TestRetrySucceedsAfterTransientFailures(t *testing.T) {
+	fake := &fakeRunner{runs: map[string][]fakeRun{
+		"docker": {{exitCode: 1, output: "timeout\n"}, {exitCode: 1, output: "timeout\n"}, {output: "pulled\n"}},
+	}}
+	exec, delays := newRetryExecutor(fake, Step{
+		Name:    "pull",
+		Command: "docker",
+		Retry:   RetryPolicy{MaxAttempts: 4, InitialBackoff: time.Second, Multiplier: 2},
+	})
+
+	events := collectEvents(exec)
+	err := exec.RunSteps(context.Background())
+	if err != nil {
+		t.Fatalf("RunSteps failed: %v", err)
+	}
+
+	result := exec.GetResults()[0]
+	if !result.Success || len(result.Attempts) != 3 {
+		t.Fatalf("Expected success on attempt 3, got %+v", result)
+	}
+	if result.Attempts[0].ExitCode != 1 || result.Attempts[2].ExitCode != 0 {
+		t.Errorf("Unexpected exit codes: %+v", result.Attempts)
+	}
+	if result.Output != "pulled\n" {
+		t.Errorf("Output should be from the last attempt, got %q", result.Output)
+	}
+	if !fake.calls[0].ProcessGroup {
+		t.Error("Steps should run in their own process group")
+	}
+
+	expected := []time.Duration{time.Second, 2 * time.Second}
+	if len(*delays) != 2 || (*delays)[0] != expected[0] || (*delays)[1] != expected[1] {
+		t.Errorf("Expected delays %v, got %v", expected, *delays)
+	}
+	if result.Attempts[0].Backoff != time.Second || result.Attempts[2].Backoff != 0 {
+		t.Errorf("Backoff not recorded per attempt: %+v", result.Attempts)
+	}
+
+	var retries []StepRetried
+	for _, ev := range events() {
+		if retried, ok := ev.(StepRetried); ok {
+			retries = append(retries, retried)
+		}
+	}
+	if len(retries) != 2 {
+		t.Fatalf("Expected 2 retry events, got %+v", retries)
+	}
+	if retries[1].Attempt != 2 || retries[1].MaxAttempts != 4 || retries[1].Backoff != 2*time.Second {
+		t.Errorf("Unexpected retry event: %+v", retries[1])
+	}
+}
+
+func TestRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	fake := &fakeRunner{runs: map[string][]fakeRun{"apt-get": {{exitCode: 100}}}}
+	exec, _ := newRetryExecutor(fake, Step{
+		Name:    "packages",
+		Command: "apt-get",
+		Retry:   RetryPolicy{MaxAttempts: 3},
+	})
+
+	if err := exec.RunSteps(context.Background()); err == nil {
+		t.Fatal("Expected failure after exhausting retries")
+	}
+	if n := fake.count("apt-get"); n != 3 {
+		t.Errorf("Expected 3 attempts, got %d", n)
+	}
+	if status := exec.GetResults()[0].Status; status != StepFailed {
+		t.Errorf("Expected failed status, got %s", status)
+	}
+}
+
+func TestRetryOnlyRetryableFailures(t *testing.T) {
+	tests := []struct {
+		name     string
+		run      fakeRun
+		policy   RetryPolicy
+		attempts int
+	}{
+		{"no retry by default", fakeRun{exitCode: 1}, RetryPolicy{}, 1},
+		{"listed exit code", fakeRun{exitCode: 100}, RetryPolicy{MaxAttempts: 3, RetryableExitCodes: []int{100}}, 3},
+		{"unlisted exit code", fakeRun{exitCode: 2}, RetryPolicy{MaxAttempts: 3, RetryableExitCodes: []int{100}}, 1},
+		{"matching output", fakeRun{exitCode: 1, output: "E: Could not get lock /var/lib/dpkg/lock\n"},
+			RetryPolicy{MaxAttempts: 2, RetryableOutput: []*regexp.Regexp{regexp.MustCompile(`Could not get lock`)}}, 2},
+		{"other output", fakeRun{exitCode: 1, output: "E: Unable to locate package\n"},
+			RetryPolicy{MaxAttempts: 2, RetryableOutput: []*regexp.Regexp{regexp.MustCompile(`Could not get lock`)}}, 1},
+	}
+
+	for _, tc := range tests {
+		fake := &fakeRunner{runs: map[string][]fakeRun{"cmd": {tc.run}}}
+		exec, _ := newRetryExecutor(fake, Step{Name: "step", Command: "cmd", Retry: tc.policy})
+		exec.RunSteps(context.Background())
+
+		if n := len(exec.GetResults()[0].Attempts); n != tc.attempts {
+			t.Errorf("%s: expected %d attempts, got %d", tc.name, tc.attempts, n)
+		}
+	}
+}
+
+func TestRetryStopsWhenContextCancelled(t *testing.T) {
+	fake := &fakeRunner{runs: map[string][]fakeRun{"cmd": {{exitCode: 1}}}}
+	exec := &Executor{
+		ProjectRoot: "/test",
+		Runner:      fake,
+		Steps:       []Step{{Name: "step", Command: "cmd", Retry: RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Hour}}},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	exec.RunSteps(ctx)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Backoff should be interrupted by cancellation, took %v", elapsed)
+	}
+	if n := fake.count("cmd"); n != 1 {
+		t.Errorf("Expected a single attempt, got %d", n)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: time.Second, Multiplier: 3, MaxBackoff: 5 * time.Second}
+
+	if d := policy.backoff(1, 0); d != time.Second {
+		t.Errorf("Attempt 1: expected 1s, got %v", d)
+	}
+	if d := policy.backoff(2, 0); d != 3*time.Second {
+		t.Errorf("Attempt 2: expected 3s, got %v", d)
+	}
+	if d := policy.backoff(3, 0); d != 5*time.Second {
+		t.Errorf("Attempt 3: expected cap of 5s, got %v", d)
+	}
+
+	policy = RetryPolicy{InitialBackoff: time.Second, Jitter: 0.5}
+	if d := policy.backoff(1, 0); d != 500*time.Millisecond {
+		t.Errorf("Minimum jitter: expected 500ms, got %v", d)
+	}
+	if d := policy.backoff(1, 0.999); d < 1400*time.Millisecond || d > 1500*time.Millisecond {
+		t.Errorf("Maximum jitter: expected ~1.5s, got %v", d)
+	}
+	if d := (RetryPolicy{InitialBackoff: time.Second}).backoff(2, 0); d != 2*time.Second {
+		t.Errorf("Default multiplier should be 2, got %v", d)
+	}
+}
+
+func TestRunInstallScriptRetriesFailedStep(t *testing.T) {
+	failure, err := os.ReadFile(filepath.Join("testdata", "install-failure.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resumed := strings.Join([]string{
+		"::doom-step name=base_packages status=skip index=2 total=10",
+		"::doom-step name=docker_install status=start index=3 total=10",
+		"::doom-step name=docker_install status=done index=3 total=10",
+	}, "\n") + "\n"
+	fake := &fakeRunner{runs: map[string][]fakeRun{"bash": {
+		{exitCode: 100, output: string(failure)},
+		{output: resumed},
+	}}}
+
+	exec := NewExecutor("/test/project")
+	exec.LogFile = filepath.Join(t.TempDir(), "install.log")
+	exec.Runner = fake
+	var delays []time.Duration
+	exec.sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return ctx.Err()
+	}
+
+	events := collectEvents(exec)
+	if err := exec.RunInstallScript(context.Background(), nil); err != nil {
+		t.Fatalf("Retried install should succeed, got %v", err)
+	}
+
+	if fake.count("bash") != 2 {
+		t.Fatalf("Expected install.sh to run twice, got %d", fake.count("bash"))
+	}
+	if steps, _ := resumeEnvOf(fake.calls[1]); steps != "base_packages,system_check" {
+		t.Errorf("Retry should resume the completed steps, got %q", steps)
+	}
+	if len(delays) != 1 {
+		t.Errorf("Expected one backoff, got %v", delays)
+	}
+
+	results := exec.GetResults()
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %+v", results)
+	}
+	if results[1].Status != StepSucceeded {
+		t.Errorf("base_packages should keep its result, got %s", results[1].Status)
+	}
+	install := results[2]
+	if install.Step.Name != "docker_install" || install.Status != StepSucceeded || len(install.Attempts) != 2 {
+		t.Fatalf("docker_install should succeed on attempt 2, got %+v", install)
+	}
+	if first := install.Attempts[0]; first.ExitCode != 100 || first.Backoff != delays[0] {
+		t.Errorf("Unexpected first attempt: %+v", first)
+	}
+
+	var retries []StepRetried
+	for _, ev := range events() {
+		if retried, ok := ev.(StepRetried); ok {
+			retries = append(retries, retried)
+		}
+	}
+	if len(retries) != 1 || retries[0].Step.Name != "docker_install" || retries[0].Index != 3 {
+		t.Errorf("Expected a retry event for docker_install, got %+v", retries)
+	}
+}
+
+func TestRunInstallScriptStepWithoutRetry(t *testing.T) {
+	fake := &fakeRunner{runs: map[string][]fakeRun{"bash": {{
+		exitCode: 1,
+		output:   "::doom-step name=ssh_hardening status=start\n::doom-step name=ssh_hardening status=fail\n",
+	}}}}
+	exec := NewExecutor("/test/project")
+	exec.LogFile = filepath.Join(t.TempDir(), "install.log")
+	exec.Runner = fake
+	exec.sleep = skipSleep
+
+	if err := exec.RunInstallScript(context.Background(), nil); err == nil {
+		t.Fatal("Expected the install script to fail")
+	}
+	if fake.count("bash") != 1 {
+		t.Errorf("Steps without a retry policy should not be retried, got %d runs", fake.count("bash"))
+	}
+}
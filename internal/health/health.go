@@ -0,0 +1,153 @@
+// Package health provides native health probes for a doom-coding installation
+// and a checker that aggregates them into a structured report
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/doom-coding/doom-coding/internal/runner"
+)
+
+// Status is the outcome of a single probe
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+	StatusSkip Status = "skip" // Probe does not apply to this installation
+)
+
+// Outcome is what a probe reports back to the checker
+type Outcome struct {
+	Status  Status
+	Message string
+	Details map[string]string
+	Err     error
+}
+
+// Probe checks a single aspect of the installation. Commands are run
+// through r, so probes can be checked against scripted output.
+type Probe interface {
+	Name() string
+	Check(ctx context.Context, r runner.CommandRunner) Outcome
+}
+
+// ProbeResult is the recorded result of running a probe
+type ProbeResult struct {
+	Name    string            `json:"name"`
+	Status  Status            `json:"status"`
+	Message string            `json:"message,omitempty"`
+	Error   string            `json:"error,omitempty"`
+	Latency time.Duration     `json:"latency_ns"`
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// Report contains the results of a full health check run
+type Report struct {
+	CheckedAt time.Time     `json:"checked_at"`
+	Duration  time.Duration `json:"duration_ns"`
+	Probes    []ProbeResult `json:"probes"`
+}
+
+// Checker runs a set of probes
+type Checker struct {
+	Probes  []Probe
+	Timeout time.Duration        // Per-probe timeout, 0 for none
+	Runner  runner.CommandRunner // Runs the commands of the probes, runner.Default when nil
+}
+
+// NewChecker creates a checker for the given probes
+func NewChecker(probes ...Probe) *Checker {
+	return &Checker{
+		Probes:  probes,
+		Timeout: 10 * time.Second,
+	}
+}
+
+// Run executes all probes concurrently and returns the report in probe order
+func (c *Checker) Run(ctx context.Context) *Report {
+	report := &Report{
+		CheckedAt: time.Now(),
+		Probes:    make([]ProbeResult, len(c.Probes)),
+	}
+
+	var wg sync.WaitGroup
+	for i, probe := range c.Probes {
+		wg.Add(1)
+		go func(i int, probe Probe) {
+			defer wg.Done()
+			report.Probes[i] = c.runProbe(ctx, probe)
+		}(i, probe)
+	}
+	wg.Wait()
+
+	report.Duration = time.Since(report.CheckedAt)
+	return report
+}
+
+// runProbe runs a single probe and measures its latency
+func (c *Checker) runProbe(ctx context.Context, probe Probe) ProbeResult {
+	probeCtx := ctx
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		probeCtx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	outcome := probe.Check(probeCtx, runner.OrDefault(c.Runner))
+	result := ProbeResult{
+		Name:    probe.Name(),
+		Status:  outcome.Status,
+		Message: outcome.Message,
+		Latency: time.Since(start),
+		Details: outcome.Details,
+	}
+	if outcome.Err != nil {
+		result.Error = outcome.Err.Error()
+	}
+	if result.Status == "" {
+		result.Status = StatusFail
+	}
+	return result
+}
+
+// Get returns the result for the named probe
+func (r *Report) Get(name string) (ProbeResult, bool) {
+	for _, p := range r.Probes {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return ProbeResult{}, false
+}
+
+// Passed reports whether the named probe ran and passed
+func (r *Report) Passed(name string) bool {
+	p, ok := r.Get(name)
+	return ok && p.Status == StatusPass
+}
+
+// Counts returns the number of passed, failed and warning probes
+func (r *Report) Counts() (passed, failed, warnings int) {
+	for _, p := range r.Probes {
+		switch p.Status {
+		case StatusPass:
+			passed++
+		case StatusFail:
+			failed++
+		case StatusWarn:
+			warnings++
+		}
+	}
+	return passed, failed, warnings
+}
+
+// Healthy reports whether no probe failed
+func (r *Report) Healthy() bool {
+	_, failed, _ := r.Counts()
+	return failed == 0
+}
@@ -0,0 +1,347 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/doom-coding/doom-coding/internal/runner"
+	"github.com/doom-coding/doom-coding/internal/runner/runnertest"
+)
+
+type staticProbe struct {
+	name    string
+	outcome Outcome
+	delay   time.Duration
+}
+
+func (p *staticProbe) Name() string { return p.name }
+
+func (p *staticProbe) Check(ctx context.Context, r runner.CommandRunner) Outcome {
+	if p.delay > 0 {
+		select {
+		case <-time.After(p.delay):
+		case <-ctx.Done():
+			return Outcome{Status: StatusFail, Err: ctx.Err()}
+		}
+	}
+	return p.outcome
+}
+
+func TestCheckerRunPreservesOrder(t *testing.T) {
+	checker := NewChecker(
+		&staticProbe{name: "slow", outcome: Outcome{Status: StatusPass}, delay: 20 * time.Millisecond},
+		&staticProbe{name: "fast", outcome: Outcome{Status: StatusWarn}},
+		&staticProbe{name: "broken", outcome: Outcome{Status: StatusFail, Err: errors.New("boom")}},
+	)
+
+	report := checker.Run(context.Background())
+
+	if len(report.Probes) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(report.Probes))
+	}
+	for i, name := range []string{"slow", "fast", "broken"} {
+		if report.Probes[i].Name != name {
+			t.Errorf("Expected probe %d to be %s, got %s", i, name, report.Probes[i].Name)
+		}
+	}
+	if report.Probes[0].Latency < 20*time.Millisecond {
+		t.Errorf("Latency should be measured, got %v", report.Probes[0].Latency)
+	}
+	if report.Probes[2].Error != "boom" {
+		t.Errorf("Expected error detail, got %q", report.Probes[2].Error)
+	}
+}
+
+func TestCheckerTimeout(t *testing.T) {
+	checker := NewChecker(&staticProbe{name: "hang", outcome: Outcome{Status: StatusPass}, delay: time.Minute})
+	checker.Timeout = 10 * time.Millisecond
+
+	report := checker.Run(context.Background())
+	if report.Probes[0].Status != StatusFail {
+		t.Errorf("Timed out probe should fail, got %s", report.Probes[0].Status)
+	}
+}
+
+func TestReportCounts(t *testing.T) {
+	report := &Report{Probes: []ProbeResult{
+		{Name: "a", Status: StatusPass},
+		{Name: "b", Status: StatusPass},
+		{Name: "c", Status: StatusWarn},
+		{Name: "d", Status: StatusSkip},
+	}}
+
+	passed, failed, warnings := report.Counts()
+	if passed != 2 || failed != 0 || warnings != 1 {
+		t.Errorf("Unexpected counts: passed=%d failed=%d warnings=%d", passed, failed, warnings)
+	}
+	if !report.Healthy() {
+		t.Error("Report without failures should be healthy")
+	}
+	if !report.Passed("a") || report.Passed("c") || report.Passed("missing") {
+		t.Error("Passed should only be true for passing probes")
+	}
+
+	report.Probes = append(report.Probes, ProbeResult{Name: "e", Status: StatusFail})
+	if report.Healthy() {
+		t.Error("Report with a failure should not be healthy")
+	}
+}
+
+func TestDockerProbe(t *testing.T) {
+	fake := runnertest.New()
+	fake.On("docker", "info", "--format", "{{.ServerVersion}}").Returns("24.0.7\n")
+
+	outcome := (&DockerProbe{}).Check(context.Background(), fake)
+	if outcome.Status != StatusPass {
+		t.Fatalf("Expected pass, got %s (%v)", outcome.Status, outcome.Err)
+	}
+	if outcome.Details["version"] != "24.0.7" {
+		t.Errorf("Expected version detail, got %q", outcome.Details["version"])
+	}
+
+	if outcome := (&DockerProbe{}).Check(context.Background(), runnertest.New()); outcome.Status != StatusFail {
+		t.Errorf("Missing docker should fail, got %s", outcome.Status)
+	}
+}
+
+func TestComposeProbe(t *testing.T) {
+	fake := runnertest.New()
+	fake.On("docker", "compose", "version", "--short").Returns("2.24.5\n")
+
+	outcome := (&ComposeProbe{}).Check(context.Background(), fake)
+	if outcome.Status != StatusPass || outcome.Details["version"] != "2.24.5" {
+		t.Errorf("Unexpected outcome: %+v", outcome)
+	}
+	if outcome := (&ComposeProbe{}).Check(context.Background(), runnertest.New()); outcome.Status != StatusFail {
+		t.Errorf("Missing compose plugin should fail, got %s", outcome.Status)
+	}
+}
+
+func TestContainerProbe(t *testing.T) {
+	format := "{{.State.Status}},{{if .State.Health}}{{.State.Health.Status}}{{end}}"
+	fake := runnertest.New()
+	fake.On("docker", "inspect", "--format", format, "healthy").Returns("running,healthy\n")
+	fake.On("docker", "inspect", "--format", format, "nocheck").Returns("running,\n")
+	fake.On("docker", "inspect", "--format", format, "starting").Returns("running,starting\n")
+	fake.On("docker", "inspect", "--format", format, "exited").Returns("exited,\n")
+
+	tests := []struct {
+		container string
+		optional  bool
+		expected  Status
+	}{
+		{"healthy", false, StatusPass},
+		{"nocheck", false, StatusPass},
+		{"starting", false, StatusWarn},
+		{"exited", false, StatusFail},
+		{"missing", false, StatusFail},
+		{"missing", true, StatusSkip},
+	}
+
+	for _, tc := range tests {
+		probe := &ContainerProbe{Container: tc.container, Optional: tc.optional}
+		if got := probe.Check(context.Background(), fake).Status; got != tc.expected {
+			t.Errorf("%s (optional=%v): expected %s, got %s", tc.container, tc.optional, tc.expected, got)
+		}
+	}
+}
+
+func TestTailscaleProbes(t *testing.T) {
+	fake := runnertest.New()
+	fake.On("tailscale", "status", "--json").Returns(`{"BackendState":"Running","Self":{"TailscaleIPs":["100.64.0.1","fd7a::1"]}}`)
+	fake.On("docker", "inspect", "--format", "{{.State.Status}}", "doom-tailscale").Returns("running")
+	fake.On("docker", "exec", "doom-tailscale", "tailscale", "status", "--json").Returns(`{"BackendState":"NeedsLogin"}`)
+
+	host := (&TailscaleHostProbe{}).Check(context.Background(), fake)
+	if host.Status != StatusPass || host.Details["ip"] != "100.64.0.1" {
+		t.Errorf("Unexpected host outcome: %+v", host)
+	}
+
+	sidecar := (&TailscaleSidecarProbe{Container: "doom-tailscale"}).Check(context.Background(), fake)
+	if sidecar.Status != StatusFail || !strings.Contains(sidecar.Message, "NeedsLogin") {
+		t.Errorf("Unexpected sidecar outcome: %+v", sidecar)
+	}
+
+	missing := (&TailscaleSidecarProbe{Container: "doom-other"}).Check(context.Background(), fake)
+	if missing.Status != StatusSkip {
+		t.Errorf("Missing sidecar should be skipped, got %s", missing.Status)
+	}
+}
+
+func TestTailscaleServeProbe(t *testing.T) {
+	if outcome := (&TailscaleServeProbe{}).Check(context.Background(), runnertest.New()); outcome.Status != StatusSkip {
+		t.Errorf("Serve should be skipped outside native userspace mode, got %s", outcome.Status)
+	}
+
+	fake := runnertest.New()
+	fake.On("systemctl", "is-active")
+	fake.On("tailscale", "serve", "status").Returns("No serve config\n").Once()
+	fake.On("tailscale", "serve", "status").Returns("https://host.ts.net (tailnet only)\n|-- / proxy http://127.0.0.1:8443\n")
+	if outcome := (&TailscaleServeProbe{}).Check(context.Background(), fake); outcome.Status != StatusWarn {
+		t.Errorf("Missing serve config should warn, got %s", outcome.Status)
+	}
+	if outcome := (&TailscaleServeProbe{}).Check(context.Background(), fake); outcome.Status != StatusPass {
+		t.Errorf("Expected pass, got %s: %s", outcome.Status, outcome.Message)
+	}
+}
+
+func TestHTTPProbe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ok := (&HTTPProbe{ProbeName: "code_server", URL: server.URL + "/healthz"}).Check(context.Background(), runnertest.New())
+	if ok.Status != StatusPass {
+		t.Errorf("Expected pass, got %s (%v)", ok.Status, ok.Err)
+	}
+
+	bad := (&HTTPProbe{ProbeName: "code_server", URL: server.URL + "/down"}).Check(context.Background(), runnertest.New())
+	if bad.Status != StatusFail || bad.Details["status_code"] != "503" {
+		t.Errorf("Expected failure with 503, got %+v", bad)
+	}
+}
+
+func TestTCPProbe(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("Cannot listen: %v", err)
+	}
+	addr := listener.Addr().String()
+
+	if outcome := (&TCPProbe{ProbeName: "ttyd", Address: addr}).Check(context.Background(), runnertest.New()); outcome.Status != StatusPass {
+		t.Errorf("Expected pass, got %s (%v)", outcome.Status, outcome.Err)
+	}
+
+	listener.Close()
+	if outcome := (&TCPProbe{ProbeName: "ttyd", Address: addr}).Check(context.Background(), runnertest.New()); outcome.Status != StatusFail {
+		t.Errorf("Closed port should fail, got %s", outcome.Status)
+	}
+}
+
+func TestClaudeCodeProbe(t *testing.T) {
+	fake := runnertest.New()
+	fake.On("docker", "exec", "doom-claude", "claude", "--version").Returns("1.0.0 (Claude Code)\n")
+	fake.On("claude", "--version").Returns("0.9.0 (Claude Code)\n")
+
+	container := (&ClaudeCodeProbe{Container: "doom-claude"}).Check(context.Background(), fake)
+	if container.Status != StatusPass || container.Details["location"] != "container" {
+		t.Errorf("Expected the container CLI, got %+v", container)
+	}
+	host := (&ClaudeCodeProbe{Container: "doom-other"}).Check(context.Background(), fake)
+	if host.Status != StatusPass || host.Details["version"] != "0.9.0 (Claude Code)" {
+		t.Errorf("Expected the host CLI, got %+v", host)
+	}
+	if outcome := (&ClaudeCodeProbe{Container: "doom-claude"}).Check(context.Background(), runnertest.New()); outcome.Status != StatusWarn {
+		t.Errorf("Missing CLI should warn, got %s", outcome.Status)
+	}
+}
+
+func TestSSHHardeningProbe(t *testing.T) {
+	dir := t.TempDir()
+	full := filepath.Join(dir, "full.conf")
+	partial := filepath.Join(dir, "partial.conf")
+	os.WriteFile(full, []byte("# doom\nPermitRootLogin no\nPasswordAuthentication no\n"), 0644)
+	os.WriteFile(partial, []byte("PermitRootLogin prohibit-password\nPasswordAuthentication no\n"), 0644)
+
+	tests := []struct {
+		path     string
+		expected Status
+	}{
+		{full, StatusPass},
+		{partial, StatusWarn},
+		{filepath.Join(dir, "missing.conf"), StatusWarn},
+	}
+	for _, tc := range tests {
+		if got := (&SSHHardeningProbe{ConfigPath: tc.path}).Check(context.Background(), runnertest.New()).Status; got != tc.expected {
+			t.Errorf("%s: expected %s, got %s", filepath.Base(tc.path), tc.expected, got)
+		}
+	}
+}
+
+func TestSecretsProbe(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "keys.txt")
+	os.WriteFile(keyFile, []byte("AGE-SECRET-KEY-1"), 0600)
+
+	fake := runnertest.New()
+	fake.On("sops")
+	fake.On("age")
+	if outcome := (&SecretsProbe{KeyFile: keyFile}).Check(context.Background(), fake); outcome.Status != StatusPass {
+		t.Errorf("Expected pass, got %s: %s", outcome.Status, outcome.Message)
+	}
+
+	fake.Missing("age")
+	outcome := (&SecretsProbe{KeyFile: keyFile}).Check(context.Background(), fake)
+	if outcome.Status != StatusWarn || !strings.Contains(outcome.Message, "age") {
+		t.Errorf("Expected warning about age, got %s: %s", outcome.Status, outcome.Message)
+	}
+}
+
+func TestDiskSpaceProbe(t *testing.T) {
+	df := func(availableKB string) string {
+		return "Filesystem 1024-blocks Used Available Capacity Mounted on\n/dev/sda1 102400000 1000 " + availableKB + " 1% /\n"
+	}
+	tests := []struct {
+		availableKB string
+		expected    Status
+	}{
+		{"52428800", StatusPass}, // 50 GB
+		{"8388608", StatusWarn},  // 8 GB
+		{"2097152", StatusFail},  // 2 GB
+	}
+
+	for _, tc := range tests {
+		fake := runnertest.New()
+		fake.On("df", "-Pk", "/home").Returns(df(tc.availableKB))
+		if got := (&DiskSpaceProbe{Path: "/home"}).Check(context.Background(), fake).Status; got != tc.expected {
+			t.Errorf("%s KB: expected %s, got %s", tc.availableKB, tc.expected, got)
+		}
+	}
+
+	if outcome := (&DiskSpaceProbe{Path: "/home"}).Check(context.Background(), runnertest.New()); outcome.Status != StatusSkip {
+		t.Errorf("Failing df should be skipped, got %s", outcome.Status)
+	}
+}
+
+func TestDefaultProbesEndpoints(t *testing.T) {
+	probes := DefaultProbes(Endpoints{Host: "10.0.0.5", Ports: map[string]int{"code-server": 9443}})
+
+	var url, address string
+	for _, probe := range probes {
+		switch p := probe.(type) {
+		case *HTTPProbe:
+			url = p.URL
+		case *TCPProbe:
+			address = p.Address
+		}
+	}
+	if url != "http://10.0.0.5:9443/healthz" {
+		t.Errorf("code-server URL = %q, want the configured port", url)
+	}
+	if address != "10.0.0.5:7681" {
+		t.Errorf("ttyd address = %q, want the default port", address)
+	}
+}
+
+func TestCheckerRunner(t *testing.T) {
+	fake := runnertest.New()
+	fake.On("docker", "info").Returns("25.0.1\n")
+
+	checker := NewChecker(&DockerProbe{})
+	checker.Runner = fake
+	if report := checker.Run(context.Background()); !report.Passed(ProbeDocker) {
+		t.Errorf("Probes should run their commands through the checker's runner, got %+v", report.Probes)
+	}
+}
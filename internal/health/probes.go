@@ -0,0 +1,511 @@
+package health
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/doom-coding/doom-coding/internal/runner"
+	"github.com/doom-coding/doom-coding/internal/service"
+)
+
+// Probe names used by DefaultProbes
+const (
+	ProbeDocker           = "docker"
+	ProbeDockerCompose    = "docker_compose"
+	ProbeTailscaleHost    = "tailscale_host"
+	ProbeTailscaleSidecar = "tailscale_sidecar"
+	ProbeTailscaleServe   = "tailscale_serve"
+	ProbeCodeServer       = "code_server"
+	ProbeTTYD             = "ttyd"
+	ProbeClaudeCode       = "claude_code"
+	ProbeSSHHardening     = "ssh_hardening"
+	ProbeTerminalTools    = "terminal_tools"
+	ProbeSecrets          = "secrets"
+	ProbeDiskSpace        = "disk_space"
+)
+
+// DoomContainers are the containers managed by the compose stacks
+var DoomContainers = []string{"doom-tailscale", "doom-code-server", "doom-claude"}
+
+// Endpoints are where the probes of DefaultProbes reach the services
+type Endpoints struct {
+	Host  string         // "localhost" when empty
+	Ports map[string]int // Host ports by service name, see service.DefaultPorts
+}
+
+// port returns the host port of a service, its default when not set
+func (e Endpoints) port(name string) int {
+	if port := e.Ports[name]; port > 0 {
+		return port
+	}
+	return service.DefaultPorts()[name]
+}
+
+// address returns the host and port of a service
+func (e Endpoints) address(name string) string {
+	host := e.Host
+	if host == "" {
+		host = "localhost"
+	}
+	return net.JoinHostPort(host, strconv.Itoa(e.port(name)))
+}
+
+// DefaultProbes returns the probes for a standard installation whose
+// services listen at endpoints. scripts/health-check.sh runs the same
+// checks under the same names.
+func DefaultProbes(endpoints Endpoints) []Probe {
+	probes := []Probe{&DockerProbe{}, &ComposeProbe{}}
+	for _, container := range DoomContainers {
+		probes = append(probes, &ContainerProbe{
+			Container: container,
+			// The sidecar only exists in the tailscale compose stacks
+			Optional: container == "doom-tailscale",
+		})
+	}
+	homeDir, _ := os.UserHomeDir()
+	probes = append(probes,
+		&TailscaleHostProbe{},
+		&TailscaleSidecarProbe{Container: "doom-tailscale"},
+		&TailscaleServeProbe{},
+		&HTTPProbe{ProbeName: ProbeCodeServer, URL: "http://" + endpoints.address("code-server") + "/healthz"},
+		&TCPProbe{ProbeName: ProbeTTYD, Address: endpoints.address("ttyd")},
+		&ClaudeCodeProbe{Container: "doom-claude"},
+		&SSHHardeningProbe{ConfigPath: "/etc/ssh/sshd_config.d/99-doom-hardening.conf"},
+		&ToolsProbe{ProbeName: ProbeTerminalTools, Tools: []string{"zsh", "tmux"}},
+		&SecretsProbe{KeyFile: filepath.Join(homeDir, ".config", "sops", "age", "keys.txt")},
+		&DiskSpaceProbe{Path: homeDir},
+	)
+	return probes
+}
+
+// DockerProbe checks that the Docker daemon is reachable
+type DockerProbe struct{}
+
+// Name returns the probe name
+func (p *DockerProbe) Name() string { return ProbeDocker }
+
+// Check runs the probe
+func (p *DockerProbe) Check(ctx context.Context, r runner.CommandRunner) Outcome {
+	if _, err := r.LookPath("docker"); err != nil {
+		return Outcome{Status: StatusFail, Message: "Docker not installed", Err: err}
+	}
+	output, err := runner.Output(ctx, r, "docker", "info", "--format", "{{.ServerVersion}}")
+	if err != nil {
+		return Outcome{Status: StatusFail, Message: "Docker not running or no permissions", Err: err}
+	}
+	version := strings.TrimSpace(string(output))
+	return Outcome{
+		Status:  StatusPass,
+		Message: fmt.Sprintf("Docker running (v%s)", version),
+		Details: map[string]string{"version": version},
+	}
+}
+
+// ComposeProbe checks that the Docker Compose plugin is available
+type ComposeProbe struct{}
+
+// Name returns the probe name
+func (p *ComposeProbe) Name() string { return ProbeDockerCompose }
+
+// Check runs the probe
+func (p *ComposeProbe) Check(ctx context.Context, r runner.CommandRunner) Outcome {
+	output, err := runner.Output(ctx, r, "docker", "compose", "version", "--short")
+	if err != nil {
+		return Outcome{Status: StatusFail, Message: "Docker Compose not available", Err: err}
+	}
+	version := strings.TrimPrefix(strings.TrimSpace(string(output)), "v")
+	return Outcome{
+		Status:  StatusPass,
+		Message: fmt.Sprintf("Docker Compose available (v%s)", version),
+		Details: map[string]string{"version": version},
+	}
+}
+
+// ContainerProbe checks the state and health of a single container
+type ContainerProbe struct {
+	Container string
+	Optional  bool // Skip instead of fail when the container does not exist
+}
+
+// Name returns the probe name
+func (p *ContainerProbe) Name() string { return "container:" + p.Container }
+
+// Check runs the probe
+func (p *ContainerProbe) Check(ctx context.Context, r runner.CommandRunner) Outcome {
+	output, err := runner.Output(ctx, r, "docker", "inspect",
+		"--format", "{{.State.Status}},{{if .State.Health}}{{.State.Health.Status}}{{end}}",
+		p.Container)
+	if err != nil {
+		if p.Optional {
+			return Outcome{Status: StatusSkip, Message: "Container not present"}
+		}
+		return Outcome{Status: StatusFail, Message: "Container not found", Err: err}
+	}
+
+	state, health := parseContainerState(string(output))
+	details := map[string]string{"state": state}
+	if health != "" {
+		details["health"] = health
+	}
+
+	if state != "running" {
+		return Outcome{Status: StatusFail, Message: fmt.Sprintf("Container %s", state), Details: details}
+	}
+	switch health {
+	case "", "healthy":
+		return Outcome{Status: StatusPass, Message: "Container running", Details: details}
+	default:
+		return Outcome{Status: StatusWarn, Message: fmt.Sprintf("Container running but %s", health), Details: details}
+	}
+}
+
+// parseContainerState splits "status,health" output from docker inspect
+func parseContainerState(output string) (state, health string) {
+	parts := strings.SplitN(strings.TrimSpace(output), ",", 2)
+	state = parts[0]
+	if len(parts) > 1 {
+		health = parts[1]
+	}
+	if health == "<no value>" {
+		health = ""
+	}
+	return state, health
+}
+
+// tailscaleStatus is the subset of `tailscale status --json` we use
+type tailscaleStatus struct {
+	BackendState string `json:"BackendState"`
+	Self         struct {
+		TailscaleIPs []string `json:"TailscaleIPs"`
+	} `json:"Self"`
+}
+
+// tailscaleOutcome converts a tailscale status document into an outcome
+func tailscaleOutcome(output []byte) Outcome {
+	var status tailscaleStatus
+	if err := json.Unmarshal(output, &status); err != nil {
+		return Outcome{Status: StatusFail, Message: "Unreadable tailscale status", Err: err}
+	}
+	details := map[string]string{"backend_state": status.BackendState}
+	if status.BackendState != "Running" {
+		return Outcome{
+			Status:  StatusFail,
+			Message: fmt.Sprintf("Not connected (%s)", status.BackendState),
+			Details: details,
+		}
+	}
+	message := "Connected"
+	if len(status.Self.TailscaleIPs) > 0 {
+		details["ip"] = status.Self.TailscaleIPs[0]
+		message = fmt.Sprintf("Connected (%s)", status.Self.TailscaleIPs[0])
+	}
+	return Outcome{Status: StatusPass, Message: message, Details: details}
+}
+
+// TailscaleHostProbe checks the Tailscale daemon on the host
+type TailscaleHostProbe struct{}
+
+// Name returns the probe name
+func (p *TailscaleHostProbe) Name() string { return ProbeTailscaleHost }
+
+// Check runs the probe
+func (p *TailscaleHostProbe) Check(ctx context.Context, r runner.CommandRunner) Outcome {
+	if _, err := r.LookPath("tailscale"); err != nil {
+		return Outcome{Status: StatusSkip, Message: "Tailscale not installed on host"}
+	}
+	output, err := runner.Output(ctx, r, "tailscale", "status", "--json")
+	if err != nil && len(output) == 0 {
+		return Outcome{Status: StatusFail, Message: "Tailscale not running", Err: err}
+	}
+	return tailscaleOutcome(output)
+}
+
+// TailscaleSidecarProbe checks Tailscale inside the sidecar container
+type TailscaleSidecarProbe struct {
+	Container string
+}
+
+// Name returns the probe name
+func (p *TailscaleSidecarProbe) Name() string { return ProbeTailscaleSidecar }
+
+// Check runs the probe
+func (p *TailscaleSidecarProbe) Check(ctx context.Context, r runner.CommandRunner) Outcome {
+	if _, err := runner.Output(ctx, r, "docker", "inspect", "--format", "{{.State.Status}}", p.Container); err != nil {
+		return Outcome{Status: StatusSkip, Message: "Sidecar container not present"}
+	}
+	output, err := runner.Output(ctx, r, "docker", "exec", p.Container, "tailscale", "status", "--json")
+	if err != nil && len(output) == 0 {
+		return Outcome{Status: StatusFail, Message: "Could not query sidecar", Err: err}
+	}
+	return tailscaleOutcome(output)
+}
+
+// TailscaleServeProbe checks the Tailscale Serve configuration of the native
+// userspace mode, which proxies code-server on port 443
+type TailscaleServeProbe struct{}
+
+// Name returns the probe name
+func (p *TailscaleServeProbe) Name() string { return ProbeTailscaleServe }
+
+// Check runs the probe
+func (p *TailscaleServeProbe) Check(ctx context.Context, r runner.CommandRunner) Outcome {
+	if _, err := runner.Output(ctx, r, "systemctl", "is-active", "--quiet", "tailscaled-userspace"); err != nil {
+		return Outcome{Status: StatusSkip, Message: "Native userspace mode not active"}
+	}
+	output, err := runner.Output(ctx, r, "tailscale", "serve", "status")
+	status := strings.TrimSpace(string(output))
+	if err != nil || status == "" || strings.Contains(status, "No serve config") {
+		return Outcome{Status: StatusWarn, Message: "Not configured, run ./scripts/setup-tailscale-serve.sh setup"}
+	}
+	return Outcome{Status: StatusPass, Message: "Configured"}
+}
+
+// HTTPProbe checks that an HTTP endpoint answers with a 2xx status
+type HTTPProbe struct {
+	ProbeName string
+	URL       string
+	Client    *http.Client
+}
+
+// Name returns the probe name
+func (p *HTTPProbe) Name() string { return p.ProbeName }
+
+// Check runs the probe
+func (p *HTTPProbe) Check(ctx context.Context, r runner.CommandRunner) Outcome {
+	client := p.Client
+	if client == nil {
+		client = &http.Client{
+			// code-server may sit behind a self-signed certificate
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.URL, nil)
+	if err != nil {
+		return Outcome{Status: StatusFail, Message: "Invalid URL", Err: err}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return Outcome{Status: StatusFail, Message: "Endpoint unreachable", Err: err}
+	}
+	defer resp.Body.Close()
+
+	details := map[string]string{"url": p.URL, "status_code": fmt.Sprintf("%d", resp.StatusCode)}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return Outcome{Status: StatusFail, Message: fmt.Sprintf("HTTP %d", resp.StatusCode), Details: details}
+	}
+	return Outcome{Status: StatusPass, Message: "Healthy", Details: details}
+}
+
+// TCPProbe checks that a TCP port accepts connections
+type TCPProbe struct {
+	ProbeName string
+	Address   string
+}
+
+// Name returns the probe name
+func (p *TCPProbe) Name() string { return p.ProbeName }
+
+// Check runs the probe
+func (p *TCPProbe) Check(ctx context.Context, r runner.CommandRunner) Outcome {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", p.Address)
+	if err != nil {
+		return Outcome{Status: StatusFail, Message: fmt.Sprintf("Nothing listening on %s", p.Address), Err: err}
+	}
+	conn.Close()
+	return Outcome{Status: StatusPass, Message: fmt.Sprintf("Listening on %s", p.Address)}
+}
+
+// ClaudeCodeProbe checks that the claude CLI runs, in its container or else
+// on the host
+type ClaudeCodeProbe struct {
+	Container string
+}
+
+// Name returns the probe name
+func (p *ClaudeCodeProbe) Name() string { return ProbeClaudeCode }
+
+// Check runs the probe
+func (p *ClaudeCodeProbe) Check(ctx context.Context, r runner.CommandRunner) Outcome {
+	if output, err := runner.Output(ctx, r, "docker", "exec", p.Container, "claude", "--version"); err == nil {
+		version := strings.TrimSpace(string(output))
+		return Outcome{
+			Status:  StatusPass,
+			Message: fmt.Sprintf("%s (container)", version),
+			Details: map[string]string{"version": version, "location": "container"},
+		}
+	}
+	if _, err := r.LookPath("claude"); err != nil {
+		return Outcome{Status: StatusWarn, Message: "Claude Code not found"}
+	}
+	output, err := runner.Output(ctx, r, "claude", "--version")
+	if err != nil {
+		return Outcome{Status: StatusWarn, Message: "claude command failed", Err: err}
+	}
+	version := strings.TrimSpace(string(output))
+	return Outcome{
+		Status:  StatusPass,
+		Message: fmt.Sprintf("%s (host)", version),
+		Details: map[string]string{"version": version, "location": "host"},
+	}
+}
+
+// SSHHardeningProbe checks the doom-coding sshd drop-in configuration
+type SSHHardeningProbe struct {
+	ConfigPath string
+}
+
+// Name returns the probe name
+func (p *SSHHardeningProbe) Name() string { return ProbeSSHHardening }
+
+// Check runs the probe
+func (p *SSHHardeningProbe) Check(ctx context.Context, r runner.CommandRunner) Outcome {
+	f, err := os.Open(p.ConfigPath)
+	if err != nil {
+		return Outcome{Status: StatusWarn, Message: "Hardening config not found"}
+	}
+	defer f.Close()
+
+	settings := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && !strings.HasPrefix(fields[0], "#") {
+			settings[strings.ToLower(fields[0])] = strings.ToLower(fields[1])
+		}
+	}
+
+	details := map[string]string{
+		"permit_root_login":       settings["permitrootlogin"],
+		"password_authentication": settings["passwordauthentication"],
+	}
+	if settings["permitrootlogin"] == "no" && settings["passwordauthentication"] == "no" {
+		return Outcome{Status: StatusPass, Message: "Root login disabled, key-only auth", Details: details}
+	}
+	return Outcome{Status: StatusWarn, Message: "Partial configuration", Details: details}
+}
+
+// ToolsProbe checks that a set of binaries is available on PATH
+type ToolsProbe struct {
+	ProbeName string
+	Tools     []string
+}
+
+// Name returns the probe name
+func (p *ToolsProbe) Name() string { return p.ProbeName }
+
+// Check runs the probe
+func (p *ToolsProbe) Check(ctx context.Context, r runner.CommandRunner) Outcome {
+	details := make(map[string]string)
+	var missing []string
+	for _, tool := range p.Tools {
+		if path, err := r.LookPath(tool); err == nil {
+			details[tool] = path
+		} else {
+			missing = append(missing, tool)
+		}
+	}
+
+	switch {
+	case len(missing) == 0:
+		return Outcome{Status: StatusPass, Message: fmt.Sprintf("All installed (%d/%d)", len(p.Tools), len(p.Tools)), Details: details}
+	case len(missing) < len(p.Tools):
+		return Outcome{Status: StatusWarn, Message: fmt.Sprintf("Missing: %s", strings.Join(missing, ", ")), Details: details}
+	default:
+		return Outcome{Status: StatusFail, Message: "Not installed", Details: details}
+	}
+}
+
+// SecretsProbe checks the SOPS/age tooling and encryption key
+type SecretsProbe struct {
+	KeyFile string
+}
+
+// Name returns the probe name
+func (p *SecretsProbe) Name() string { return ProbeSecrets }
+
+// Check runs the probe
+func (p *SecretsProbe) Check(ctx context.Context, r runner.CommandRunner) Outcome {
+	details := make(map[string]string)
+	var missing []string
+
+	for _, tool := range []string{"sops", "age"} {
+		if _, err := r.LookPath(tool); err == nil {
+			details[tool] = "installed"
+		} else {
+			details[tool] = "missing"
+			missing = append(missing, tool)
+		}
+	}
+	if _, err := os.Stat(p.KeyFile); err == nil {
+		details["key_file"] = "present"
+	} else {
+		details["key_file"] = "missing"
+		missing = append(missing, "encryption key")
+	}
+
+	if len(missing) > 0 {
+		return Outcome{
+			Status:  StatusWarn,
+			Message: fmt.Sprintf("Missing: %s", strings.Join(missing, ", ")),
+			Details: details,
+		}
+	}
+	return Outcome{Status: StatusPass, Message: "SOPS, age and key present", Details: details}
+}
+
+// DiskSpaceProbe checks the free space of the file system holding Path
+type DiskSpaceProbe struct {
+	Path string
+}
+
+// Disk space thresholds in GB, below which DiskSpaceProbe warns or fails
+const (
+	diskSpaceLowGB      = 10
+	diskSpaceCriticalGB = 5
+)
+
+// Name returns the probe name
+func (p *DiskSpaceProbe) Name() string { return ProbeDiskSpace }
+
+// Check runs the probe
+func (p *DiskSpaceProbe) Check(ctx context.Context, r runner.CommandRunner) Outcome {
+	output, err := runner.Output(ctx, r, "df", "-Pk", p.Path)
+	if err != nil {
+		return Outcome{Status: StatusSkip, Message: "Could not query disk space", Err: err}
+	}
+	// The second line holds the file system: name, size, used, available, ...
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	var fields []string
+	if len(lines) >= 2 {
+		fields = strings.Fields(lines[1])
+	}
+	if len(fields) < 4 {
+		return Outcome{Status: StatusSkip, Message: "Unreadable df output"}
+	}
+	availableKB, err := strconv.ParseInt(fields[3], 10, 64)
+	if err != nil {
+		return Outcome{Status: StatusSkip, Message: "Unreadable df output", Err: err}
+	}
+
+	availableGB := availableKB / (1024 * 1024)
+	details := map[string]string{"path": p.Path, "available_gb": strconv.FormatInt(availableGB, 10)}
+	switch {
+	case availableGB > diskSpaceLowGB:
+		return Outcome{Status: StatusPass, Message: fmt.Sprintf("%dGB available", availableGB), Details: details}
+	case availableGB > diskSpaceCriticalGB:
+		return Outcome{Status: StatusWarn, Message: fmt.Sprintf("%dGB available (low)", availableGB), Details: details}
+	default:
+		return Outcome{Status: StatusFail, Message: fmt.Sprintf("%dGB available (critical)", availableGB), Details: details}
+	}
+}
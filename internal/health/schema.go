@@ -0,0 +1,277 @@
+package health
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SchemaVersion is the current version of the health document schema.
+// Version 1 was the bare {passed, failed, warnings, healthy} summary printed
+// by health-check.sh before the schema was versioned.
+const SchemaVersion = 2
+
+// Sources that produce health documents
+const (
+	SourceScript = "health-check.sh"
+	SourceGo     = "doom-tui"
+)
+
+// ErrUnsupportedVersion is returned when a document is newer than this build understands
+var ErrUnsupportedVersion = errors.New("unsupported health schema version")
+
+// Document is the JSON representation of a health check run shared by
+// health-check.sh --json and doom-tui status --json
+type Document struct {
+	SchemaVersion int       `json:"schema_version"`
+	GeneratedAt   time.Time `json:"generated_at"`
+	Source        string    `json:"source"`
+	State         State     `json:"state,omitempty"`
+	Healthy       bool      `json:"healthy"`
+	Passed        int       `json:"passed"`
+	Failed        int       `json:"failed"`
+	Warnings      int       `json:"warnings"`
+	Checks        []Check   `json:"checks"`
+	Services      []Service `json:"services,omitempty"`
+}
+
+// State is the overall verdict for an installation
+type State string
+
+const (
+	StateHealthy  State = "healthy"
+	StateDegraded State = "degraded"
+	StateDown     State = "down"
+)
+
+// Service is the lifecycle state of a managed container
+type Service struct {
+	Name      string `json:"name"`
+	Container string `json:"container"`
+	State     string `json:"state"`
+	Port      int    `json:"port,omitempty"`
+}
+
+// Check is a single entry in a health document
+type Check struct {
+	Name      string            `json:"name"`
+	Status    Status            `json:"status"`
+	Message   string            `json:"message,omitempty"`
+	Error     string            `json:"error,omitempty"`
+	LatencyMS int64             `json:"latency_ms,omitempty"`
+	Details   map[string]string `json:"details,omitempty"`
+}
+
+// legacySummary is the schema version 1 document
+type legacySummary struct {
+	Passed   int  `json:"passed"`
+	Failed   int  `json:"failed"`
+	Warnings int  `json:"warnings"`
+	Healthy  bool `json:"healthy"`
+}
+
+// Document converts the report into the versioned JSON document
+func (r *Report) Document() *Document {
+	passed, failed, warnings := r.Counts()
+	doc := &Document{
+		SchemaVersion: SchemaVersion,
+		GeneratedAt:   r.CheckedAt.UTC().Truncate(time.Second),
+		Source:        SourceGo,
+		Healthy:       failed == 0,
+		Passed:        passed,
+		Failed:        failed,
+		Warnings:      warnings,
+		Checks:        make([]Check, 0, len(r.Probes)),
+	}
+	for _, p := range r.Probes {
+		doc.Checks = append(doc.Checks, Check{
+			Name:      p.Name,
+			Status:    p.Status,
+			Message:   p.Message,
+			Error:     p.Error,
+			LatencyMS: p.Latency.Milliseconds(),
+			Details:   p.Details,
+		})
+	}
+	return doc
+}
+
+// JSON renders the report as a versioned health document
+func (r *Report) JSON() ([]byte, error) {
+	return json.MarshalIndent(r.Document(), "", "    ")
+}
+
+// Decode parses a health document, migrating older schema versions to the
+// current one and rejecting documents from newer releases
+func Decode(data []byte) (*Document, error) {
+	var probe struct {
+		SchemaVersion *int `json:"schema_version"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse health document: %w", err)
+	}
+
+	version := 1
+	if probe.SchemaVersion != nil {
+		version = *probe.SchemaVersion
+	}
+
+	switch {
+	case version == 1:
+		return migrateV1(data)
+	case version == SchemaVersion:
+		return decodeCurrent(data)
+	case version > SchemaVersion:
+		return nil, fmt.Errorf("%w: %d (this build supports up to %d)", ErrUnsupportedVersion, version, SchemaVersion)
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, version)
+	}
+}
+
+// decodeCurrent strictly decodes and validates a current-version document
+func decodeCurrent(data []byte) (*Document, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	var doc Document
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid health document: %w", err)
+	}
+	if err := doc.Validate(); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// migrateV1 upgrades a bare summary document to the current schema
+func migrateV1(data []byte) (*Document, error) {
+	var legacy legacySummary
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return nil, fmt.Errorf("invalid version 1 health document: %w", err)
+	}
+	return &Document{
+		SchemaVersion: SchemaVersion,
+		Source:        SourceScript,
+		Healthy:       legacy.Healthy,
+		Passed:        legacy.Passed,
+		Failed:        legacy.Failed,
+		Warnings:      legacy.Warnings,
+		Checks:        []Check{},
+	}, nil
+}
+
+// Validate checks the document against the schema constraints that
+// encoding/json cannot express
+func (d *Document) Validate() error {
+	if d.SchemaVersion != SchemaVersion {
+		return fmt.Errorf("%w: %d", ErrUnsupportedVersion, d.SchemaVersion)
+	}
+	if d.Source == "" {
+		return fmt.Errorf("invalid health document: source is required")
+	}
+	if d.Checks == nil {
+		return fmt.Errorf("invalid health document: checks is required")
+	}
+
+	var passed, failed, warnings int
+	for i, c := range d.Checks {
+		if c.Name == "" {
+			return fmt.Errorf("invalid health document: checks[%d].name is required", i)
+		}
+		switch c.Status {
+		case StatusPass:
+			passed++
+		case StatusFail:
+			failed++
+		case StatusWarn:
+			warnings++
+		case StatusSkip:
+		default:
+			return fmt.Errorf("invalid health document: checks[%d].status %q is not one of pass, warn, fail, skip", i, c.Status)
+		}
+	}
+
+	switch d.State {
+	case "", StateHealthy, StateDegraded, StateDown:
+	default:
+		return fmt.Errorf("invalid health document: state %q is not one of healthy, degraded, down", d.State)
+	}
+
+	if passed != d.Passed || failed != d.Failed || warnings != d.Warnings {
+		return fmt.Errorf("invalid health document: counts %d/%d/%d do not match checks %d/%d/%d",
+			d.Passed, d.Failed, d.Warnings, passed, failed, warnings)
+	}
+	if d.Healthy != (d.Failed == 0) {
+		return fmt.Errorf("invalid health document: healthy=%t with %d failures", d.Healthy, d.Failed)
+	}
+	return nil
+}
+
+// JSONSchema returns the JSON Schema describing the current document version.
+// The checked-in copy in schemas/ is regenerated with
+// go test ./internal/health -run TestJSONSchemaFile -update
+func JSONSchema() ([]byte, error) {
+	str := map[string]interface{}{"type": "string"}
+	count := map[string]interface{}{"type": "integer", "minimum": 0}
+
+	check := map[string]interface{}{
+		"type":                 "object",
+		"additionalProperties": false,
+		"required":             []string{"name", "status"},
+		"properties": map[string]interface{}{
+			"name":       map[string]interface{}{"type": "string", "minLength": 1},
+			"status":     map[string]interface{}{"enum": []Status{StatusPass, StatusWarn, StatusFail, StatusSkip}},
+			"message":    str,
+			"error":      str,
+			"latency_ms": count,
+			"details": map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": str,
+			},
+		},
+	}
+
+	service := map[string]interface{}{
+		"type":                 "object",
+		"additionalProperties": false,
+		"required":             []string{"name", "container", "state"},
+		"properties": map[string]interface{}{
+			"name":      str,
+			"container": str,
+			"state":     str,
+			"port":      count,
+		},
+	}
+
+	schema := map[string]interface{}{
+		"$schema":              "https://json-schema.org/draft/2020-12/schema",
+		"$id":                  fmt.Sprintf("https://github.com/LL4nc33/doom-coding/schemas/health-check.schema.json#v%d", SchemaVersion),
+		"title":                "Doom Coding health check",
+		"type":                 "object",
+		"additionalProperties": false,
+		"required": []string{
+			"schema_version", "generated_at", "source", "healthy",
+			"passed", "failed", "warnings", "checks",
+		},
+		"properties": map[string]interface{}{
+			"schema_version": map[string]interface{}{"const": SchemaVersion},
+			"generated_at":   map[string]interface{}{"type": "string", "format": "date-time"},
+			"source":         map[string]interface{}{"type": "string", "minLength": 1},
+			"state":          map[string]interface{}{"enum": []State{StateHealthy, StateDegraded, StateDown}},
+			"healthy":        map[string]interface{}{"type": "boolean"},
+			"passed":         count,
+			"failed":         count,
+			"warnings":       count,
+			"checks":         map[string]interface{}{"type": "array", "items": check},
+			"services":       map[string]interface{}{"type": "array", "items": service},
+		},
+	}
+
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
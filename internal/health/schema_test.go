@@ -0,0 +1,173 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "regenerate schemas/health-check.schema.json")
+
+const schemaFile = "../../schemas/health-check.schema.json"
+
+func TestJSONSchemaFile(t *testing.T) {
+	want, err := JSONSchema()
+	if err != nil {
+		t.Fatalf("JSONSchema failed: %v", err)
+	}
+
+	if *update {
+		if err := os.WriteFile(schemaFile, want, 0644); err != nil {
+			t.Fatalf("Failed to write schema: %v", err)
+		}
+	}
+
+	got, err := os.ReadFile(schemaFile)
+	if err != nil {
+		t.Fatalf("Failed to read schema file: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s is out of date; run go test ./internal/health -run TestJSONSchemaFile -update", schemaFile)
+	}
+}
+
+func TestReportDocumentRoundTrip(t *testing.T) {
+	report := &Report{
+		CheckedAt: time.Now(),
+		Probes: []ProbeResult{
+			{Name: "docker", Status: StatusPass, Latency: 15 * time.Millisecond, Details: map[string]string{"version": "24.0.7"}},
+			{Name: "ttyd", Status: StatusFail, Message: "Nothing listening", Error: "connection refused"},
+			{Name: "tailscale_sidecar", Status: StatusSkip},
+		},
+	}
+
+	data, err := report.JSON()
+	if err != nil {
+		t.Fatalf("JSON failed: %v", err)
+	}
+
+	doc, err := Decode(data)
+	if err != nil {
+		t.Fatalf("Decode failed: %v\n%s", err, data)
+	}
+	if doc.SchemaVersion != SchemaVersion || doc.Source != SourceGo {
+		t.Errorf("Unexpected header: version=%d source=%q", doc.SchemaVersion, doc.Source)
+	}
+	if doc.Healthy || doc.Passed != 1 || doc.Failed != 1 {
+		t.Errorf("Unexpected counts: %+v", doc)
+	}
+	if len(doc.Checks) != 3 || doc.Checks[0].LatencyMS != 15 {
+		t.Errorf("Unexpected checks: %+v", doc.Checks)
+	}
+}
+
+func TestDecodeMigratesVersion1(t *testing.T) {
+	legacy := []byte(`{
+    "passed": 9,
+    "failed": 0,
+    "warnings": 3,
+    "healthy": true
+}`)
+
+	doc, err := Decode(legacy)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if doc.SchemaVersion != SchemaVersion {
+		t.Errorf("Expected migration to version %d, got %d", SchemaVersion, doc.SchemaVersion)
+	}
+	if doc.Passed != 9 || doc.Warnings != 3 || !doc.Healthy {
+		t.Errorf("Counts not preserved: %+v", doc)
+	}
+	if doc.Checks == nil {
+		t.Error("Migrated document should have an empty checks list")
+	}
+}
+
+func TestDecodeRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+	}{
+		{"newer version", `{"schema_version": 99, "source": "x", "checks": []}`},
+		{"unknown field", `{"schema_version": 2, "generated_at": "2024-01-01T00:00:00Z", "source": "x", "healthy": true, "passed": 0, "failed": 0, "warnings": 0, "checks": [], "extra": 1}`},
+		{"bad status", `{"schema_version": 2, "generated_at": "2024-01-01T00:00:00Z", "source": "x", "healthy": true, "passed": 0, "failed": 0, "warnings": 0, "checks": [{"name": "a", "status": "running"}]}`},
+		{"count mismatch", `{"schema_version": 2, "generated_at": "2024-01-01T00:00:00Z", "source": "x", "healthy": true, "passed": 2, "failed": 0, "warnings": 0, "checks": [{"name": "a", "status": "pass"}]}`},
+		{"healthy with failures", `{"schema_version": 2, "generated_at": "2024-01-01T00:00:00Z", "source": "x", "healthy": true, "passed": 0, "failed": 1, "warnings": 0, "checks": [{"name": "a", "status": "fail"}]}`},
+		{"not json", `passed=1`},
+	}
+
+	for _, tc := range tests {
+		if _, err := Decode([]byte(tc.doc)); err == nil {
+			t.Errorf("%s: expected error", tc.name)
+		}
+	}
+
+	_, err := Decode([]byte(`{"schema_version": 3}`))
+	if !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("Expected ErrUnsupportedVersion, got %v", err)
+	}
+}
+
+// runHealthCheckScript returns the document of health-check.sh --json
+func runHealthCheckScript(t *testing.T) []byte {
+	t.Helper()
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+
+	script, err := filepath.Abs("../../scripts/health-check.sh")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	// The script exits non-zero when checks fail; only the document matters here
+	output, _ := exec.CommandContext(ctx, "bash", script, "--json").Output()
+	if len(output) == 0 {
+		t.Skip("health-check.sh produced no output in this environment")
+	}
+	return output
+}
+
+func TestHealthCheckScriptMatchesSchema(t *testing.T) {
+	output := runHealthCheckScript(t)
+
+	doc, err := Decode(output)
+	if err != nil {
+		t.Fatalf("health-check.sh --json does not match schema: %v\n%s", err, output)
+	}
+	if doc.Source != SourceScript {
+		t.Errorf("Expected source %q, got %q", SourceScript, doc.Source)
+	}
+	if len(doc.Checks) == 0 {
+		t.Error("Script should report individual checks")
+	}
+}
+
+func TestHealthCheckScriptCheckNames(t *testing.T) {
+	doc, err := Decode(runHealthCheckScript(t))
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+
+	var script, probes []string
+	for _, check := range doc.Checks {
+		script = append(script, check.Name)
+	}
+	for _, probe := range DefaultProbes(Endpoints{}) {
+		probes = append(probes, probe.Name())
+	}
+	if strings.Join(script, " ") != strings.Join(probes, " ") {
+		t.Errorf("health-check.sh and DefaultProbes report different checks\nscript: %v\nprobes: %v", script, probes)
+	}
+}
@@ -0,0 +1,99 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// terminalHelperEnv makes the test binary run TestTerminalHelper
+const terminalHelperEnv = "RUNNER_TERMINAL_HELPER"
+
+// openPTY returns the master and the path of a new pseudo terminal
+func openPTY(t *testing.T) (*os.File, string) {
+	t.Helper()
+	fd, err := unix.Open("/dev/ptmx", unix.O_RDWR|unix.O_NOCTTY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		t.Skipf("No pseudo terminals: %v", err)
+	}
+	master := os.NewFile(uintptr(fd), "/dev/ptmx")
+	t.Cleanup(func() { master.Close() })
+	if err := unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil {
+		t.Skipf("Failed to unlock pseudo terminal: %v", err)
+	}
+	n, err := unix.IoctlGetInt(fd, unix.TIOCGPTN)
+	if err != nil {
+		t.Skipf("Failed to get pseudo terminal number: %v", err)
+	}
+	return master, fmt.Sprintf("/dev/pts/%d", n)
+}
+
+// TestTerminalHelper runs stty through ExecRunner when the test binary is
+// started by TestExecRunnerTerminalCommand on a pseudo terminal
+func TestTerminalHelper(t *testing.T) {
+	if os.Getenv(terminalHelperEnv) != "1" {
+		t.Skip("Only run by TestExecRunnerTerminalCommand")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := (ExecRunner{GracePeriod: time.Second}).Run(ctx, Command{Name: "stty", Args: []string{"-echo"}, Stdin: os.Stdin}); err != nil {
+		fmt.Println("stty failed:", err)
+		os.Exit(1)
+	}
+	fmt.Println("stty done")
+}
+
+func TestExecRunnerTerminalCommand(t *testing.T) {
+	if _, err := exec.LookPath("stty"); err != nil {
+		t.Skip("stty not installed")
+	}
+	master, name := openPTY(t)
+	tty, err := os.OpenFile(name, os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("Failed to open %s: %v", name, err)
+	}
+	defer tty.Close()
+
+	// The helper is the foreground process of a new session on the terminal,
+	// as doom-tui is when run from a shell
+	cmd := exec.Command(os.Args[0], "-test.run=^TestTerminalHelper$", "-test.v")
+	cmd.Env = append(os.Environ(), terminalHelperEnv+"=1")
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = tty, tty, tty
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true, Ctty: 0}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	tty.Close()
+
+	// Reading the master fails once the helper has exited and the terminal
+	// has no other users
+	var out bytes.Buffer
+	read := make(chan struct{})
+	go func() {
+		io.Copy(&out, master)
+		close(read)
+	}()
+	select {
+	case <-read:
+	case <-time.After(20 * time.Second):
+		cmd.Process.Kill()
+		t.Fatal("Helper did not exit")
+	}
+	err = cmd.Wait()
+
+	if !strings.Contains(out.String(), "stty done") {
+		t.Errorf("stty should complete on the terminal, helper printed:\n%s", out.String())
+	}
+	if err != nil {
+		t.Errorf("Helper failed: %v", err)
+	}
+}
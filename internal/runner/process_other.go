@@ -0,0 +1,27 @@
+//go:build !unix
+
+package runner
+
+import (
+	"os/exec"
+	"time"
+)
+
+// setProcessGroup is a no-op where process groups are not supported
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// terminateGroup kills the process; its children are not reached
+func terminateGroup(cmd *exec.Cmd, grace time.Duration) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	return cmd.Process.Kill()
+}
+
+// terminateProcess kills the process
+func terminateProcess(cmd *exec.Cmd, grace time.Duration) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	return cmd.Process.Kill()
+}
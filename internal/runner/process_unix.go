@@ -0,0 +1,77 @@
+//go:build unix
+
+package runner
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// groupPollInterval is how often terminateGroup and terminateProcess check
+// whether the command exited
+const groupPollInterval = 50 * time.Millisecond
+
+// setProcessGroup starts the command in its own process group so that
+// cancellation reaches every process it spawns
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// terminateGroup sends SIGTERM to the command's process group and SIGKILL
+// to whatever is left after grace. It returns once the group is gone or
+// has been killed.
+func terminateGroup(cmd *exec.Cmd, grace time.Duration) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	pgid := cmd.Process.Pid
+
+	if err := syscall.Kill(-pgid, syscall.SIGTERM); err != nil {
+		if errors.Is(err, syscall.ESRCH) {
+			return nil
+		}
+		return cmd.Process.Kill()
+	}
+
+	deadline := time.Now().Add(grace)
+	for time.Now().Before(deadline) {
+		time.Sleep(groupPollInterval)
+		if !groupAlive(pgid) {
+			return nil
+		}
+	}
+	return syscall.Kill(-pgid, syscall.SIGKILL)
+}
+
+// groupAlive reports whether any process in the group is still running.
+// The group leader is reaped by Cmd.Wait as soon as it exits.
+func groupAlive(pgid int) bool {
+	return syscall.Kill(-pgid, 0) == nil
+}
+
+// terminateProcess sends SIGTERM to the command and SIGKILL if it is still
+// running after grace. Its children are left to exit with it.
+func terminateProcess(cmd *exec.Cmd, grace time.Duration) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	if err := cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		if errors.Is(err, os.ErrProcessDone) {
+			return nil
+		}
+		return cmd.Process.Kill()
+	}
+
+	// Signal fails once Cmd.Wait has reaped the process
+	deadline := time.Now().Add(grace)
+	for time.Now().Before(deadline) {
+		time.Sleep(groupPollInterval)
+		if cmd.Process.Signal(syscall.Signal(0)) != nil {
+			return nil
+		}
+	}
+	return cmd.Process.Kill()
+}
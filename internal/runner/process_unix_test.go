@@ -0,0 +1,103 @@
+//go:build unix
+
+package runner
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// startAndCancel runs script, in its own process group when group is set,
+// cancels it once it printed a line and returns the printed line and how
+// long Run took after the cancel
+func startAndCancel(t *testing.T, r ExecRunner, group bool, script string) (string, time.Duration) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var out lockedBuffer
+	done := make(chan error)
+	go func() {
+		done <- r.Run(ctx, Command{Name: "sh", Args: []string{"-c", script}, Stdout: &out, ProcessGroup: group})
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(out.String(), "\n") {
+		if time.Now().After(deadline) {
+			t.Fatal("Command did not start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	cancelled := time.Now()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("Cancelled command should fail")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+	return strings.TrimSpace(out.String()), time.Since(cancelled)
+}
+
+func TestExecRunnerCancelKillsProcessGroup(t *testing.T) {
+	line, _ := startAndCancel(t, ExecRunner{}, true, "sleep 30 & echo $!; wait")
+
+	pid, err := strconv.Atoi(line)
+	if err != nil {
+		t.Fatalf("Unexpected output %q", line)
+	}
+	if err := syscall.Kill(pid, 0); err == nil {
+		t.Errorf("Grandchild %d should have been terminated", pid)
+	}
+}
+
+func TestExecRunnerCancelKillsAfterGracePeriod(t *testing.T) {
+	for _, group := range []bool{true, false} {
+		t.Run("group="+strconv.FormatBool(group), func(t *testing.T) {
+			grace := 300 * time.Millisecond
+			_, took := startAndCancel(t, ExecRunner{GracePeriod: grace}, group, `trap "" TERM; echo ready; exec sleep 30`)
+
+			if took < grace {
+				t.Errorf("SIGKILL should wait for the grace period, took %s", took)
+			}
+			if took > grace+2*time.Second {
+				t.Errorf("Command ignoring SIGTERM should be killed after the grace period, took %s", took)
+			}
+		})
+	}
+}
+
+func TestExecRunnerCancelTerminatesProcess(t *testing.T) {
+	_, took := startAndCancel(t, ExecRunner{}, false, "echo ready; exec sleep 30")
+
+	if took > 2*time.Second {
+		t.Errorf("Command should exit on SIGTERM, took %s", took)
+	}
+}
+
+// lockedBuffer is a bytes.Buffer safe for a concurrent writer and reader
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,142 @@
+// Package runner abstracts process execution so callers can be tested
+// against scripted command output instead of a real host
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// Command describes a process to run
+type Command struct {
+	Name   string
+	Args   []string
+	Dir    string
+	Env    []string  // Appended to the current environment
+	Stdin  io.Reader // Optional
+	Stdout io.Writer // Optional, written as the process produces output
+	Stderr io.Writer // Optional
+
+	// ProcessGroup runs the command in its own process group, so that
+	// cancellation also terminates every process it spawns. The group is
+	// in the background of the controlling terminal, where commands that
+	// read from or configure the terminal (stty, sudo, read < /dev/tty)
+	// are stopped, so only non-interactive commands may set it.
+	ProcessGroup bool
+}
+
+// CommandRunner executes commands
+type CommandRunner interface {
+	// Run starts the command and waits for it to exit. A non-zero exit is
+	// reported as an error whose exit code is available through ExitCode.
+	Run(ctx context.Context, cmd Command) error
+	// LookPath searches for an executable in PATH
+	LookPath(file string) (string, error)
+}
+
+// ExecRunner runs commands on the local host with os/exec. When the
+// context is cancelled the command receives SIGTERM, and SIGKILL if it is
+// still running after the grace period. Commands with ProcessGroup set get
+// the signals for their whole process group.
+type ExecRunner struct {
+	GracePeriod time.Duration // Time between SIGTERM and SIGKILL, 0 for DefaultGracePeriod
+}
+
+// DefaultGracePeriod is how long a cancelled command may take to exit
+const DefaultGracePeriod = 10 * time.Second
+
+// waitDelay bounds how long Run waits for output after the process exits,
+// so grandchildren holding the pipes open cannot hang a step
+const waitDelay = 5 * time.Second
+
+// Run implements CommandRunner
+func (r ExecRunner) Run(ctx context.Context, c Command) error {
+	cmd := exec.CommandContext(ctx, c.Name, c.Args...)
+	cmd.Dir = c.Dir
+	if len(c.Env) > 0 {
+		cmd.Env = append(cmd.Environ(), c.Env...)
+	}
+	cmd.Stdin = c.Stdin
+	cmd.Stdout = c.Stdout
+	cmd.Stderr = c.Stderr
+	cmd.WaitDelay = waitDelay
+
+	grace := r.GracePeriod
+	if grace <= 0 {
+		grace = DefaultGracePeriod
+	}
+	if c.ProcessGroup {
+		setProcessGroup(cmd)
+		cmd.Cancel = func() error {
+			return terminateGroup(cmd, grace)
+		}
+	} else {
+		cmd.Cancel = func() error {
+			return terminateProcess(cmd, grace)
+		}
+	}
+	return cmd.Run()
+}
+
+// LookPath implements CommandRunner
+func (ExecRunner) LookPath(file string) (string, error) {
+	return exec.LookPath(file)
+}
+
+// Default is the runner used when none is injected
+var Default CommandRunner = ExecRunner{}
+
+// OrDefault returns r, or Default when r is nil
+func OrDefault(r CommandRunner) CommandRunner {
+	if r == nil {
+		return Default
+	}
+	return r
+}
+
+// ExitError is a non-zero exit reported by a runner other than ExecRunner
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return "exit status " + strconv.Itoa(e.Code)
+}
+
+// ExitCode returns the exit code of the process
+func (e *ExitError) ExitCode() int {
+	return e.Code
+}
+
+// ExitCode extracts the process exit code from a Run error. It returns 0 for
+// a nil error and -1 when the process did not exit normally (e.g. it could
+// not be started or was killed by a signal).
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var coded interface{ ExitCode() int }
+	if errors.As(err, &coded) {
+		return coded.ExitCode()
+	}
+	return -1
+}
+
+// Output runs a command and returns its stdout
+func Output(ctx context.Context, r CommandRunner, name string, args ...string) ([]byte, error) {
+	var stdout bytes.Buffer
+	err := r.Run(ctx, Command{Name: name, Args: args, Stdout: &stdout})
+	return stdout.Bytes(), err
+}
+
+// CombinedOutput runs a command and returns its stdout and stderr interleaved
+func CombinedOutput(ctx context.Context, r CommandRunner, name string, args ...string) ([]byte, error) {
+	var out bytes.Buffer
+	err := r.Run(ctx, Command{Name: name, Args: args, Stdout: &out, Stderr: &out})
+	return out.Bytes(), err
+}
@@ -0,0 +1,78 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExecRunnerOutput(t *testing.T) {
+	out, err := Output(context.Background(), ExecRunner{}, "echo", "hello")
+	if err != nil {
+		t.Fatalf("Output failed: %v", err)
+	}
+	if strings.TrimSpace(string(out)) != "hello" {
+		t.Errorf("Expected hello, got %q", out)
+	}
+}
+
+func TestExecRunnerExitCode(t *testing.T) {
+	err := ExecRunner{}.Run(context.Background(), Command{Name: "sh", Args: []string{"-c", "exit 3"}})
+	if code := ExitCode(err); code != 3 {
+		t.Errorf("Expected exit code 3, got %d (%v)", code, err)
+	}
+
+	err = ExecRunner{}.Run(context.Background(), Command{Name: "definitely-not-a-command"})
+	if code := ExitCode(err); code != -1 {
+		t.Errorf("Expected -1 for a command that cannot start, got %d", code)
+	}
+}
+
+func TestExecRunnerDirAndEnv(t *testing.T) {
+	dir := t.TempDir()
+	out, err := CombinedOutput(context.Background(), &envRunner{dir: dir, env: []string{"DOOM_TEST=1"}}, "sh", "-c", "pwd; echo $DOOM_TEST")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if !strings.Contains(string(out), dir) || !strings.HasSuffix(strings.TrimSpace(string(out)), "1") {
+		t.Errorf("Dir or Env not applied: %q", out)
+	}
+}
+
+// envRunner sets Dir and Env on every command
+type envRunner struct {
+	dir string
+	env []string
+}
+
+func (r *envRunner) Run(ctx context.Context, cmd Command) error {
+	cmd.Dir, cmd.Env = r.dir, r.env
+	return ExecRunner{}.Run(ctx, cmd)
+}
+
+func (r *envRunner) LookPath(file string) (string, error) {
+	return ExecRunner{}.LookPath(file)
+}
+
+func TestExitCode(t *testing.T) {
+	if ExitCode(nil) != 0 {
+		t.Error("nil error should be exit code 0")
+	}
+	if ExitCode(&ExitError{Code: 42}) != 42 {
+		t.Error("ExitError code not returned")
+	}
+	if ExitCode(errors.New("boom")) != -1 {
+		t.Error("Unknown error should be -1")
+	}
+}
+
+func TestOrDefault(t *testing.T) {
+	if OrDefault(nil) != Default {
+		t.Error("nil runner should fall back to Default")
+	}
+	custom := &envRunner{}
+	if OrDefault(custom) != CommandRunner(custom) {
+		t.Error("Custom runner should be kept")
+	}
+}
@@ -0,0 +1,279 @@
+// Package runnertest provides a scripted CommandRunner for tests and a
+// recorder that captures real command output for later replay
+package runnertest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/doom-coding/doom-coding/internal/runner"
+)
+
+// Interaction is a recorded command invocation and its result
+type Interaction struct {
+	Args     []string `json:"args"`
+	Stdout   string   `json:"stdout,omitempty"`
+	Stderr   string   `json:"stderr,omitempty"`
+	ExitCode int      `json:"exit_code"`
+}
+
+// Response is a scripted result for commands matching an argv prefix
+type Response struct {
+	args     []string
+	exact    bool
+	stdout   string
+	stderr   string
+	exitCode int
+	err      error
+	times    int // Remaining uses, 0 for unlimited, -1 once exhausted
+}
+
+// Returns sets the stdout of the response
+func (r *Response) Returns(stdout string) *Response {
+	r.stdout = stdout
+	return r
+}
+
+// Stderr sets the stderr of the response
+func (r *Response) Stderr(stderr string) *Response {
+	r.stderr = stderr
+	return r
+}
+
+// Exit sets a non-zero exit code
+func (r *Response) Exit(code int) *Response {
+	r.exitCode = code
+	return r
+}
+
+// Fails makes the command fail to start with err
+func (r *Response) Fails(err error) *Response {
+	r.err = err
+	return r
+}
+
+// Once limits the response to a single use so later responses for the same
+// command can script a sequence
+func (r *Response) Once() *Response {
+	return r.Times(1)
+}
+
+// Times limits the response to n uses
+func (r *Response) Times(n int) *Response {
+	r.times = n
+	return r
+}
+
+// Exactly requires the full argv to match instead of a prefix
+func (r *Response) Exactly() *Response {
+	r.exact = true
+	return r
+}
+
+func (r *Response) matches(argv []string) bool {
+	if len(argv) < len(r.args) || (r.exact && len(argv) != len(r.args)) {
+		return false
+	}
+	for i, arg := range r.args {
+		if argv[i] != arg {
+			return false
+		}
+	}
+	return true
+}
+
+// Fake is a CommandRunner that replays scripted responses and records every
+// call. Responses are matched in registration order; commands without a
+// response exit with status 127 and are listed by Unmatched.
+type Fake struct {
+	// Paths overrides LookPath results; a missing entry falls back to
+	// whether any response is registered for the command
+	Paths map[string]string
+
+	mu        sync.Mutex
+	responses []*Response
+	calls     []runner.Command
+	unmatched []string
+}
+
+// New creates an empty fake
+func New() *Fake {
+	return &Fake{Paths: make(map[string]string)}
+}
+
+// On registers a response for commands whose argv starts with args
+func (f *Fake) On(args ...string) *Response {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r := &Response{args: args}
+	f.responses = append(f.responses, r)
+	return r
+}
+
+// Replay registers recorded interactions to be returned once each, in order
+func (f *Fake) Replay(interactions ...Interaction) {
+	for _, in := range interactions {
+		f.On(in.Args...).Returns(in.Stdout).Stderr(in.Stderr).Exit(in.ExitCode).Exactly().Once()
+	}
+}
+
+// Missing makes LookPath fail for the given commands
+func (f *Fake) Missing(files ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, file := range files {
+		f.Paths[file] = ""
+	}
+}
+
+// Run implements runner.CommandRunner
+func (f *Fake) Run(ctx context.Context, cmd runner.Command) error {
+	argv := append([]string{cmd.Name}, cmd.Args...)
+
+	f.mu.Lock()
+	f.calls = append(f.calls, cmd)
+	var resp *Response
+	for _, r := range f.responses {
+		if r.times < 0 || !r.matches(argv) {
+			continue
+		}
+		resp = r
+		if r.times > 0 {
+			r.times--
+			if r.times == 0 {
+				r.times = -1 // Exhausted
+			}
+		}
+		break
+	}
+	if resp == nil {
+		f.unmatched = append(f.unmatched, strings.Join(argv, " "))
+	}
+	f.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if resp == nil {
+		return &runner.ExitError{Code: 127}
+	}
+	if resp.err != nil {
+		return resp.err
+	}
+	if cmd.Stdout != nil && resp.stdout != "" {
+		io.WriteString(cmd.Stdout, resp.stdout)
+	}
+	if cmd.Stderr != nil && resp.stderr != "" {
+		io.WriteString(cmd.Stderr, resp.stderr)
+	}
+	if resp.exitCode != 0 {
+		return &runner.ExitError{Code: resp.exitCode}
+	}
+	return nil
+}
+
+// LookPath implements runner.CommandRunner
+func (f *Fake) LookPath(file string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if path, ok := f.Paths[file]; ok {
+		if path == "" {
+			return "", errors.New("exec: \"" + file + "\": executable file not found in $PATH")
+		}
+		return path, nil
+	}
+	for _, r := range f.responses {
+		if len(r.args) > 0 && r.args[0] == file {
+			return "/usr/bin/" + file, nil
+		}
+	}
+	return "", errors.New("exec: \"" + file + "\": executable file not found in $PATH")
+}
+
+// Calls returns the recorded commands
+func (f *Fake) Calls() []runner.Command {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]runner.Command(nil), f.calls...)
+}
+
+// Commands returns the recorded commands as space-joined argv strings
+func (f *Fake) Commands() []string {
+	var out []string
+	for _, c := range f.Calls() {
+		out = append(out, strings.Join(append([]string{c.Name}, c.Args...), " "))
+	}
+	return out
+}
+
+// Count returns how many recorded commands start with args
+func (f *Fake) Count(args ...string) int {
+	prefix := &Response{args: args}
+	n := 0
+	for _, c := range f.Calls() {
+		if prefix.matches(append([]string{c.Name}, c.Args...)) {
+			n++
+		}
+	}
+	return n
+}
+
+// Unmatched returns commands that had no scripted response
+func (f *Fake) Unmatched() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.unmatched...)
+}
+
+// Recorder wraps a CommandRunner and records each interaction so it can be
+// saved as a transcript and replayed with Fake.Replay
+type Recorder struct {
+	Runner runner.CommandRunner
+
+	mu           sync.Mutex
+	interactions []Interaction
+}
+
+// Run implements runner.CommandRunner
+func (r *Recorder) Run(ctx context.Context, cmd runner.Command) error {
+	var stdout, stderr bytes.Buffer
+	recorded := cmd
+	recorded.Stdout = teeWriter(cmd.Stdout, &stdout)
+	recorded.Stderr = teeWriter(cmd.Stderr, &stderr)
+
+	err := runner.OrDefault(r.Runner).Run(ctx, recorded)
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, Interaction{
+		Args:     append([]string{cmd.Name}, cmd.Args...),
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		ExitCode: runner.ExitCode(err),
+	})
+	r.mu.Unlock()
+	return err
+}
+
+// LookPath implements runner.CommandRunner
+func (r *Recorder) LookPath(file string) (string, error) {
+	return runner.OrDefault(r.Runner).LookPath(file)
+}
+
+// Interactions returns everything recorded so far
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Interaction(nil), r.interactions...)
+}
+
+func teeWriter(w io.Writer, buf *bytes.Buffer) io.Writer {
+	if w == nil {
+		return buf
+	}
+	return io.MultiWriter(w, buf)
+}
@@ -0,0 +1,121 @@
+package runnertest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/doom-coding/doom-coding/internal/runner"
+)
+
+func run(t *testing.T, r runner.CommandRunner, args ...string) (string, error) {
+	t.Helper()
+	var stdout bytes.Buffer
+	err := r.Run(context.Background(), runner.Command{Name: args[0], Args: args[1:], Stdout: &stdout})
+	return stdout.String(), err
+}
+
+func TestFakeSequence(t *testing.T) {
+	f := New()
+	f.On("docker", "inspect").Returns("starting").Once()
+	f.On("docker", "inspect").Returns("healthy")
+
+	for i, want := range []string{"starting", "healthy", "healthy"} {
+		out, err := run(t, f, "docker", "inspect", "--format", "x", "c")
+		if err != nil || out != want {
+			t.Errorf("call %d: got %q, %v; want %q", i, out, err, want)
+		}
+	}
+	if f.Count("docker", "inspect") != 3 {
+		t.Errorf("Expected 3 recorded calls, got %d", f.Count("docker", "inspect"))
+	}
+}
+
+func TestFakeExitAndFailure(t *testing.T) {
+	f := New()
+	f.On("false").Stderr("nope").Exit(3)
+	f.On("broken").Fails(errors.New("exec format error"))
+
+	_, err := run(t, f, "false")
+	if runner.ExitCode(err) != 3 {
+		t.Errorf("Expected exit code 3, got %v", err)
+	}
+	if _, err := run(t, f, "broken"); err == nil || runner.ExitCode(err) != -1 {
+		t.Errorf("Expected start failure, got %v", err)
+	}
+}
+
+func TestFakeExactly(t *testing.T) {
+	f := New()
+	f.On("docker", "inspect", "c").Exactly().Returns("exact")
+
+	if out, _ := run(t, f, "docker", "inspect", "c"); out != "exact" {
+		t.Errorf("Exact argv should match, got %q", out)
+	}
+	if _, err := run(t, f, "docker", "inspect", "c", "--extra"); runner.ExitCode(err) != 127 {
+		t.Errorf("Longer argv should not match an exact response, got %v", err)
+	}
+	if got := f.Unmatched(); !reflect.DeepEqual(got, []string{"docker inspect c --extra"}) {
+		t.Errorf("Unexpected unmatched list %q", got)
+	}
+}
+
+func TestFakeLookPath(t *testing.T) {
+	f := New()
+	f.On("docker", "info")
+	f.Paths["zsh"] = "/bin/zsh"
+	f.Missing("tmux")
+
+	if path, err := f.LookPath("docker"); err != nil || path != "/usr/bin/docker" {
+		t.Errorf("Scripted command should be found, got %q, %v", path, err)
+	}
+	if path, _ := f.LookPath("zsh"); path != "/bin/zsh" {
+		t.Errorf("Paths override not used, got %q", path)
+	}
+	if _, err := f.LookPath("tmux"); err == nil {
+		t.Error("Missing command should not be found")
+	}
+	if _, err := f.LookPath("tailscale"); err == nil {
+		t.Error("Unscripted command should not be found")
+	}
+}
+
+func TestFakeCancelledContext(t *testing.T) {
+	f := New()
+	f.On("sleep")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := f.Run(ctx, runner.Command{Name: "sleep"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestRecorderReplay(t *testing.T) {
+	rec := &Recorder{Runner: runner.ExecRunner{}}
+	if out, err := run(t, rec, "echo", "hello"); err != nil || out != "hello\n" {
+		t.Fatalf("Recorder should pass output through, got %q, %v", out, err)
+	}
+	run(t, rec, "sh", "-c", "echo oops >&2; exit 2")
+
+	transcript := rec.Interactions()
+	if len(transcript) != 2 || transcript[1].ExitCode != 2 || transcript[1].Stderr != "oops\n" {
+		t.Fatalf("Unexpected transcript %+v", transcript)
+	}
+
+	f := New()
+	f.Replay(transcript...)
+	if out, err := run(t, f, "echo", "hello"); err != nil || out != "hello\n" {
+		t.Errorf("Replay returned %q, %v", out, err)
+	}
+	var stderr bytes.Buffer
+	err := f.Run(context.Background(), runner.Command{Name: "sh", Args: []string{"-c", "echo oops >&2; exit 2"}, Stderr: &stderr})
+	if runner.ExitCode(err) != 2 || stderr.String() != "oops\n" {
+		t.Errorf("Replay returned %q, %v", stderr.String(), err)
+	}
+	if _, err := run(t, f, "echo", "hello"); runner.ExitCode(err) != 127 {
+		t.Error("Replayed interactions should be used once")
+	}
+}
@@ -0,0 +1,58 @@
+//go:build unix
+
+package secrets
+
+import (
+	"fmt"
+	"os"
+)
+
+// chown gives f to uid and gid, -1 keeps the current one
+func chown(f *os.File, uid, gid int) error {
+	if uid < 0 && gid < 0 {
+		return nil
+	}
+	return f.Chown(uid, gid)
+}
+
+// owner returns the user and group to give new files to, see ownerAs
+func owner(uid, gid int) (int, int, error) {
+	groups, err := os.Getgroups()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get the groups of the current user: %w", err)
+	}
+	return ownerAs(os.Geteuid(), os.Getegid(), groups, uid, gid)
+}
+
+// ownerAs returns the user and group to give new files to when running as
+// euid and egid with the supplementary groups. Only root can give files to
+// other users, so without root uid has to be the current user and gid one
+// of its groups; they are -1 when the files already get them. The error
+// says how to change PUID and PGID instead.
+func ownerAs(euid, egid int, groups []int, uid, gid int) (int, int, error) {
+	if euid == 0 {
+		return uid, gid, nil
+	}
+	if uid == euid {
+		uid = -1
+	}
+	if gid == egid {
+		gid = -1
+	}
+	if uid >= 0 {
+		return 0, 0, fmt.Errorf("the secret files have to be owned by PUID %d, which needs root: run as root or set PUID to %d, the current user", uid, euid)
+	}
+	if gid >= 0 && !containsID(groups, gid) {
+		return 0, 0, fmt.Errorf("the secret files have to belong to PGID %d, which needs root: run as root or set PGID to %d, the current group", gid, egid)
+	}
+	return uid, gid, nil
+}
+
+func containsID(ids []int, id int) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+//go:build unix
+
+package secrets
+
+import (
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestManagerOwner(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("Only root can give files to other users")
+	}
+	m := NewManager(t.TempDir())
+	m.UID, m.GID = 1234, 5678
+	if _, err := m.Write(map[string]string{CodeServerPassword: "code-secret"}); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(m.Path(CodeServerPassword))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stat := info.Sys().(*syscall.Stat_t)
+	if stat.Uid != 1234 || stat.Gid != 5678 {
+		t.Errorf("Expected owner 1234:5678, got %d:%d", stat.Uid, stat.Gid)
+	}
+}
+
+func TestOwnerAs(t *testing.T) {
+	tests := map[string]struct {
+		euid, uid, gid   int
+		wantUID, wantGID int
+		wantErr          string
+	}{
+		"root":          {euid: 0, uid: 1234, gid: 5678, wantUID: 1234, wantGID: 5678},
+		"current owner": {euid: 1000, uid: 1000, gid: 1000, wantUID: -1, wantGID: -1},
+		"kept owner":    {euid: 1000, uid: -1, gid: -1, wantUID: -1, wantGID: -1},
+		"other group":   {euid: 1000, uid: 1000, gid: 27, wantUID: -1, wantGID: 27},
+		"other user":    {euid: 1000, uid: 1001, gid: 1000, wantErr: "run as root or set PUID to 1000"},
+		"foreign group": {euid: 1000, uid: 1000, gid: 5678, wantErr: "run as root or set PGID to 1000"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			uid, gid, err := ownerAs(tt.euid, 1000, []int{1000, 27}, tt.uid, tt.gid)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected an error with %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil || uid != tt.wantUID || gid != tt.wantGID {
+				t.Errorf("ownerAs = %d, %d, %v, want %d, %d", uid, gid, err, tt.wantUID, tt.wantGID)
+			}
+		})
+	}
+}
+
+func TestManagerOwnerNonRoot(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("Root can give files to other users")
+	}
+	m := NewManager(t.TempDir())
+	m.UID, m.GID = os.Geteuid(), os.Getegid()
+	if _, err := m.Write(map[string]string{CodeServerPassword: "code-secret"}); err != nil {
+		t.Fatalf("Files of the current user should be written without root: %v", err)
+	}
+
+	other := NewManager(t.TempDir())
+	other.UID, other.GID = os.Geteuid()+1, os.Getegid()
+	if _, err := other.Write(map[string]string{CodeServerPassword: "code-secret"}); err == nil || !strings.Contains(err.Error(), "run as root") {
+		t.Errorf("Expected an error saying how to fix PUID, got %v", err)
+	}
+	if _, err := os.Stat(other.Dir); !os.IsNotExist(err) {
+		t.Errorf("Nothing should be written when the owner cannot be set, got %v", err)
+	}
+}
@@ -0,0 +1,187 @@
+// Package secrets manages the docker compose secrets of a doom-coding
+// installation: one file per secret in the secrets directory, readable only
+// by the user the containers run as
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Dir is the secrets directory relative to the project root
+const Dir = "secrets"
+
+// Secrets used by the compose files, each read from Dir/<name>.txt
+const (
+	TailscaleAuthKey   = "tailscale_auth_key"   // TS_AUTHKEY of the tailscale container
+	CodeServerPassword = "code_server_password" // PASSWORD of code-server
+	SudoPassword       = "sudo_password"        // SUDO_PASSWORD of code-server
+	AnthropicAPIKey    = "anthropic_api_key"    // ANTHROPIC_API_KEY of the claude container
+)
+
+// Names are the secrets a Manager knows about
+var Names = []string{TailscaleAuthKey, CodeServerPassword, SudoPassword, AnthropicAPIKey}
+
+// Required are the secrets that cannot be empty: code-server accepts anyone
+// with an empty password
+var Required = []string{CodeServerPassword}
+
+// Manager writes the secret files of a project
+type Manager struct {
+	// Dir is where the secret files are
+	Dir string
+
+	// UID and GID own the secret files, -1 keeps the user and group of the
+	// current process
+	UID, GID int
+}
+
+// NewManager returns a manager for the secrets of the project at
+// projectRoot, owned by the current user
+func NewManager(projectRoot string) *Manager {
+	return &Manager{Dir: filepath.Join(projectRoot, Dir), UID: -1, GID: -1}
+}
+
+// Path returns the file of a secret
+func (m *Manager) Path(name string) string {
+	return filepath.Join(m.Dir, name+".txt")
+}
+
+// Write replaces the secret files with values, a value per secret name.
+// Empty values are written as empty files, so the compose files can still
+// mount them, except for the Required secrets. The files of the other
+// known secrets are stale, they are removed and their names returned. When
+// a value is missing or the files cannot be given to UID and GID, nothing
+// is written.
+func (m *Manager) Write(values map[string]string) ([]string, error) {
+	for _, name := range sortedNames(values) {
+		if !known(name) {
+			return nil, fmt.Errorf("unknown secret %q", name)
+		}
+		if values[name] == "" && contains(Required, name) {
+			return nil, fmt.Errorf("secret %s is empty", name)
+		}
+	}
+	uid, gid, err := owner(m.UID, m.GID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(m.Dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create secrets directory: %w", err)
+	}
+	// The directory may have been created by install.sh or an older version
+	if err := os.Chmod(m.Dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to restrict secrets directory: %w", err)
+	}
+
+	for _, name := range sortedNames(values) {
+		if err := m.writeFile(name, values[name], uid, gid); err != nil {
+			return nil, err
+		}
+	}
+
+	stale, err := m.Stale(values)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range stale {
+		if err := os.Remove(m.Path(name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to remove stale secret: %w", err)
+		}
+	}
+	return stale, nil
+}
+
+// writeFile replaces the file of a secret, owned by uid and gid. The value
+// is written to a temporary file that is renamed, so a secret is never
+// partly written and never readable by others.
+func (m *Manager) writeFile(name, value string, uid, gid int) error {
+	path := m.Path(name)
+	f, err := os.CreateTemp(m.Dir, "."+name+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	defer os.Remove(f.Name()) // Fails once renamed
+
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := chown(f, uid, gid); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to give %s to %d:%d: %w", path, uid, gid, err)
+	}
+	if _, err := f.WriteString(value); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// Stale returns the known secrets that have a file but are not in values
+func (m *Manager) Stale(values map[string]string) ([]string, error) {
+	var stale []string
+	for _, name := range Names {
+		if _, ok := values[name]; ok {
+			continue
+		}
+		_, err := os.Lstat(m.Path(name))
+		switch {
+		case err == nil:
+			stale = append(stale, name)
+		case !errors.Is(err, fs.ErrNotExist):
+			return nil, err
+		}
+	}
+	return stale, nil
+}
+
+// Read returns the values of the secrets that have a file. Trailing line
+// breaks, as left by editors and echo, are removed.
+func (m *Manager) Read() (map[string]string, error) {
+	values := make(map[string]string)
+	for _, name := range Names {
+		data, err := os.ReadFile(m.Path(name))
+		switch {
+		case err == nil:
+			values[name] = strings.TrimRight(string(data), "\r\n")
+		case !errors.Is(err, fs.ErrNotExist):
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+func known(name string) bool {
+	return contains(Names, name)
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedNames(values map[string]string) []string {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
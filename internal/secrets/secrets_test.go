@@ -0,0 +1,91 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestManagerWrite(t *testing.T) {
+	m := NewManager(t.TempDir())
+	stale, err := m.Write(map[string]string{
+		CodeServerPassword: "code-secret",
+		SudoPassword:       "",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stale) != 0 {
+		t.Errorf("Expected no stale secrets, got %v", stale)
+	}
+
+	values, err := m.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 2 || values[CodeServerPassword] != "code-secret" || values[SudoPassword] != "" {
+		t.Errorf("Read returned %v", values)
+	}
+
+	entries, err := os.ReadDir(m.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("Temporary files should not be left behind, got %v", entries)
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("Expected %s permissions 0600, got %o", entry.Name(), info.Mode().Perm())
+		}
+	}
+}
+
+func TestManagerStale(t *testing.T) {
+	m := NewManager(t.TempDir())
+	if _, err := m.Write(map[string]string{TailscaleAuthKey: "tskey-auth-old", AnthropicAPIKey: "sk-ant-old"}); err != nil {
+		t.Fatal(err)
+	}
+	// Not ours, kept
+	if err := os.WriteFile(filepath.Join(m.Dir, "secrets.enc.yaml"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	values := map[string]string{AnthropicAPIKey: "sk-ant-new"}
+	if stale, err := m.Stale(values); err != nil || len(stale) != 1 || stale[0] != TailscaleAuthKey {
+		t.Errorf("Expected tailscale_auth_key to be stale, got %v, %v", stale, err)
+	}
+	if _, err := m.Write(values); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(m.Path(TailscaleAuthKey)); !os.IsNotExist(err) {
+		t.Error("Stale secret files should be removed")
+	}
+	if _, err := os.Stat(filepath.Join(m.Dir, "secrets.enc.yaml")); err != nil {
+		t.Errorf("Other files should be kept: %v", err)
+	}
+}
+
+func TestManagerUnknownSecret(t *testing.T) {
+	m := NewManager(t.TempDir())
+	if _, err := m.Write(map[string]string{"../passwd": "x"}); err == nil {
+		t.Error("Unknown secrets should be rejected")
+	}
+	if _, err := os.Stat(m.Dir); !os.IsNotExist(err) {
+		t.Error("Nothing should be written for unknown secrets")
+	}
+}
+
+func TestManagerRequiredSecret(t *testing.T) {
+	m := NewManager(t.TempDir())
+	if _, err := m.Write(map[string]string{CodeServerPassword: "", SudoPassword: "sudo-secret"}); err == nil {
+		t.Error("An empty code-server password should be rejected")
+	}
+	if _, err := os.Stat(m.Dir); !os.IsNotExist(err) {
+		t.Error("Nothing should be written for an empty required secret")
+	}
+}
@@ -3,11 +3,14 @@ package service
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/doom-coding/doom-coding/internal/runner"
+	"gopkg.in/yaml.v3"
 )
 
 // LifecycleManager handles clean service startup and shutdown
@@ -18,6 +21,59 @@ type LifecycleManager struct {
 	logger       *Logger
 	timeout      time.Duration
 	healthChecks bool
+	pollInterval time.Duration // How often container health is polled
+}
+
+// composeService is a service of the compose stacks
+type composeService struct {
+	name      string // Display name
+	service   string // Name in the compose files
+	container string
+	port      int
+}
+
+// composeServices are the services whose health is checked, in start order
+var composeServices = []composeService{
+	{"Tailscale", "tailscale", "doom-tailscale", 0},
+	{"code-server", "code-server", "doom-code-server", 8443},
+	{"Claude", "claude", "doom-claude", 7681},
+}
+
+// findComposeService looks up a service by its compose name
+func findComposeService(name string) (composeService, bool) {
+	for _, c := range composeServices {
+		if c.service == name {
+			return c, true
+		}
+	}
+	return composeService{}, false
+}
+
+// services returns the checked services the compose file defines. Without
+// a compose file, e.g. for terminal-only installs, there are none; when the
+// file cannot be read all of them are checked.
+func (lm *LifecycleManager) services() []composeService {
+	if lm.composeFile == "" {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join(lm.projectRoot, lm.composeFile))
+	if err != nil {
+		return composeServices
+	}
+	var doc struct {
+		Services map[string]yaml.Node `yaml:"services"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return composeServices
+	}
+
+	var services []composeService
+	for _, c := range composeServices {
+		if _, ok := doc.Services[c.service]; ok {
+			services = append(services, c)
+		}
+	}
+	return services
 }
 
 // NewLifecycleManager creates a new lifecycle manager
@@ -28,6 +84,7 @@ func NewLifecycleManager(manager *Manager, projectRoot, composeFile string) *Lif
 		composeFile:  composeFile,
 		timeout:      2 * time.Minute,
 		healthChecks: true,
+		pollInterval: 2 * time.Second,
 	}
 }
 
@@ -46,6 +103,11 @@ func (lm *LifecycleManager) SetHealthChecks(enabled bool) {
 	lm.healthChecks = enabled
 }
 
+// SetPollInterval sets how often container health is polled
+func (lm *LifecycleManager) SetPollInterval(interval time.Duration) {
+	lm.pollInterval = interval
+}
+
 // StartupResult contains the result of starting services
 type StartupResult struct {
 	Success        bool
@@ -81,7 +143,7 @@ func (lm *LifecycleManager) PreStartCheck(ctx context.Context) (*MigrationPlan,
 	lm.log(LogInfo, "startup", "Running pre-start checks...")
 
 	// Check Docker
-	if err := exec.CommandContext(ctx, "docker", "info").Run(); err != nil {
+	if err := lm.manager.run(ctx, "docker", "info"); err != nil {
 		return nil, fmt.Errorf("docker is not running or not accessible: %w", err)
 	}
 	lm.log(LogDebug, "startup", "Docker is available")
@@ -187,74 +249,43 @@ func (lm *LifecycleManager) Start(ctx context.Context, plan *MigrationPlan) (*St
 // pullImages pulls the container images with filtered output
 func (lm *LifecycleManager) pullImages(ctx context.Context) error {
 	composePath := filepath.Join(lm.projectRoot, lm.composeFile)
-	cmd := exec.CommandContext(ctx, "docker", "compose", "-f", composePath, "pull")
-	cmd.Dir = lm.projectRoot
-
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return err
-	}
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		return err
-	}
-
-	if err := cmd.Start(); err != nil {
-		return err
-	}
-
-	// Process output through filter
-	if lm.logger != nil {
-		go lm.logger.NewStreamFilter("docker-pull", stdout).Process()
-		go lm.logger.NewStreamFilter("docker-pull", stderr).Process()
-	}
-
-	return cmd.Wait()
+	return lm.runFiltered(ctx, "docker-pull", "docker", "compose", "-f", composePath, "pull")
 }
 
 // startServices starts the containers
 func (lm *LifecycleManager) startServices(ctx context.Context) error {
 	composePath := filepath.Join(lm.projectRoot, lm.composeFile)
-	cmd := exec.CommandContext(ctx, "docker", "compose", "-f", composePath, "up", "-d")
-	cmd.Dir = lm.projectRoot
-
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return err
-	}
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		return err
-	}
-
-	if err := cmd.Start(); err != nil {
-		return err
-	}
-
-	// Process output through filter
-	if lm.logger != nil {
-		go lm.logger.NewStreamFilter("docker-up", stdout).Process()
-		go lm.logger.NewStreamFilter("docker-up", stderr).Process()
-	}
+	return lm.runFiltered(ctx, "docker-up", "docker", "compose", "-f", composePath, "up", "-d")
+}
 
-	return cmd.Wait()
+// runFiltered runs a command in the project root, passing its output
+// through the logger's stream filter
+func (lm *LifecycleManager) runFiltered(ctx context.Context, source, name string, args ...string) error {
+	cmd := runner.Command{Name: name, Args: args, Dir: lm.projectRoot}
+	if lm.logger == nil {
+		return lm.manager.runner.Run(ctx, cmd)
+	}
+
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		lm.logger.NewStreamFilter(source, pr).Process()
+		io.Copy(io.Discard, pr) // Keep draining if the filter stopped early
+	}()
+
+	cmd.Stdout, cmd.Stderr = pw, pw
+	err := lm.manager.runner.Run(ctx, cmd)
+	pw.Close()
+	<-done
+	return err
 }
 
 // waitForHealth waits for services to become healthy
 func (lm *LifecycleManager) waitForHealth(ctx context.Context) []ServiceStatus {
-	containers := []struct {
-		name      string
-		container string
-		port      int
-	}{
-		{"Tailscale", "doom-tailscale", 0},
-		{"code-server", "doom-code-server", 8443},
-		{"Claude", "doom-claude", 7681},
-	}
-
 	var statuses []ServiceStatus
 
-	for _, c := range containers {
+	for _, c := range lm.services() {
 		status := ServiceStatus{
 			Name:      c.name,
 			Container: c.container,
@@ -262,7 +293,7 @@ func (lm *LifecycleManager) waitForHealth(ctx context.Context) []ServiceStatus {
 		}
 
 		// Check if container exists
-		if err := exec.CommandContext(ctx, "docker", "inspect", c.container).Run(); err != nil {
+		if err := lm.manager.run(ctx, "docker", "inspect", c.container); err != nil {
 			status.State = StateStopped
 			status.Error = "Container not found"
 			statuses = append(statuses, status)
@@ -290,7 +321,7 @@ func (lm *LifecycleManager) waitForHealth(ctx context.Context) []ServiceStatus {
 
 // waitForContainerHealth waits for a specific container to be healthy
 func (lm *LifecycleManager) waitForContainerHealth(ctx context.Context, container string) ServiceState {
-	ticker := time.NewTicker(2 * time.Second)
+	ticker := time.NewTicker(lm.pollInterval)
 	defer ticker.Stop()
 
 	for {
@@ -299,9 +330,9 @@ func (lm *LifecycleManager) waitForContainerHealth(ctx context.Context, containe
 			return StateUnhealthy
 		case <-ticker.C:
 			// Check container state
-			output, err := exec.CommandContext(ctx, "docker", "inspect",
+			output, err := lm.manager.output(ctx, "docker", "inspect",
 				"--format", "{{.State.Status}},{{.State.Health.Status}}",
-				container).Output()
+				container)
 			if err != nil {
 				continue
 			}
@@ -338,7 +369,7 @@ func (lm *LifecycleManager) getAccessURLs(ctx context.Context) map[string]string
 	urls := make(map[string]string)
 
 	// Try Tailscale IP first
-	if output, err := exec.CommandContext(ctx, "tailscale", "ip", "-4").Output(); err == nil {
+	if output, err := lm.manager.output(ctx, "tailscale", "ip", "-4"); err == nil {
 		tsIP := strings.TrimSpace(string(output))
 		if tsIP != "" {
 			urls["code-server"] = fmt.Sprintf("https://%s:8443", tsIP)
@@ -348,8 +379,8 @@ func (lm *LifecycleManager) getAccessURLs(ctx context.Context) map[string]string
 	}
 
 	// Check container Tailscale
-	if output, err := exec.CommandContext(ctx, "docker", "exec", "doom-tailscale",
-		"tailscale", "ip", "-4").Output(); err == nil {
+	if output, err := lm.manager.output(ctx, "docker", "exec", "doom-tailscale",
+		"tailscale", "ip", "-4"); err == nil {
 		tsIP := strings.TrimSpace(string(output))
 		if tsIP != "" {
 			urls["code-server"] = fmt.Sprintf("https://%s:8443", tsIP)
@@ -359,7 +390,7 @@ func (lm *LifecycleManager) getAccessURLs(ctx context.Context) map[string]string
 	}
 
 	// Fallback to local IPs
-	if output, err := exec.Command("hostname", "-I").Output(); err == nil {
+	if output, err := lm.manager.output(ctx, "hostname", "-I"); err == nil {
 		ips := strings.Fields(string(output))
 		if len(ips) > 0 {
 			localIP := ips[0]
@@ -390,12 +421,16 @@ func (lm *LifecycleManager) Stop(ctx context.Context) (*ShutdownResult, error) {
 	lm.log(LogInfo, "shutdown", "Stopping services...")
 
 	composePath := filepath.Join(lm.projectRoot, lm.composeFile)
-	cmd := exec.CommandContext(ctx, "docker", "compose", "-f", composePath, "down")
-	cmd.Dir = lm.projectRoot
-
-	output, err := cmd.CombinedOutput()
+	var output strings.Builder
+	err := lm.manager.runner.Run(ctx, runner.Command{
+		Name:   "docker",
+		Args:   []string{"compose", "-f", composePath, "down"},
+		Dir:    lm.projectRoot,
+		Stdout: &output,
+		Stderr: &output,
+	})
 	if err != nil {
-		result.Errors = append(result.Errors, fmt.Sprintf("Stop failed: %v\n%s", err, string(output)))
+		result.Errors = append(result.Errors, fmt.Sprintf("Stop failed: %v\n%s", err, output.String()))
 	}
 
 	// Verify containers stopped
@@ -404,8 +439,8 @@ func (lm *LifecycleManager) Stop(ctx context.Context) (*ShutdownResult, error) {
 			Container: containerName,
 		}
 
-		output, err := exec.CommandContext(ctx, "docker", "inspect",
-			"--format", "{{.State.Status}}", containerName).Output()
+		output, err := lm.manager.output(ctx, "docker", "inspect",
+			"--format", "{{.State.Status}}", containerName)
 		if err != nil {
 			// Container doesn't exist, which is fine
 			status.State = StateStopped
@@ -414,7 +449,7 @@ func (lm *LifecycleManager) Stop(ctx context.Context) (*ShutdownResult, error) {
 			if state == "running" {
 				status.State = StateRunning
 				// Force stop
-				exec.CommandContext(ctx, "docker", "stop", "-t", "5", containerName).Run()
+				lm.manager.run(ctx, "docker", "stop", "-t", "5", containerName)
 			} else {
 				status.State = StateStopped
 			}
@@ -448,30 +483,61 @@ func (lm *LifecycleManager) Restart(ctx context.Context) (*StartupResult, error)
 	return lm.Start(ctx, nil)
 }
 
+// RestartServices recreates the given compose services without their
+// dependencies and waits for them to be healthy. Containers are recreated
+// rather than restarted, as a container keeps the secret files it was
+// created with. The services are only reported unhealthy, not an error.
+func (lm *LifecycleManager) RestartServices(ctx context.Context, services ...string) ([]ServiceStatus, error) {
+	var targets []composeService
+	for _, name := range services {
+		c, ok := findComposeService(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown service %q", name)
+		}
+		targets = append(targets, c)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, lm.timeout)
+	defer cancel()
+
+	lm.log(LogInfo, "restart", fmt.Sprintf("Recreating %s...", strings.Join(services, ", ")))
+	composePath := filepath.Join(lm.projectRoot, lm.composeFile)
+	args := append([]string{"compose", "-f", composePath, "up", "-d", "--force-recreate", "--no-deps"}, services...)
+	if err := lm.runFiltered(ctx, "docker-up", "docker", args...); err != nil {
+		return nil, fmt.Errorf("failed to recreate %s: %w", strings.Join(services, ", "), err)
+	}
+
+	var statuses []ServiceStatus
+	for _, c := range targets {
+		status := ServiceStatus{
+			Name:      c.name,
+			Container: c.container,
+			Port:      c.port,
+			State:     lm.waitForContainerHealth(ctx, c.container),
+		}
+		if status.State != StateHealthy && status.State != StateRunning {
+			status.Error = fmt.Sprintf("%s is %s", c.name, status.State)
+			lm.log(LogWarning, "restart", status.Error)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
 // Status returns the current status of all services
 func (lm *LifecycleManager) Status(ctx context.Context) []ServiceStatus {
 	var statuses []ServiceStatus
 
-	containers := []struct {
-		name      string
-		container string
-		port      int
-	}{
-		{"Tailscale", "doom-tailscale", 0},
-		{"code-server", "doom-code-server", 8443},
-		{"Claude", "doom-claude", 7681},
-	}
-
-	for _, c := range containers {
+	for _, c := range lm.services() {
 		status := ServiceStatus{
 			Name:      c.name,
 			Container: c.container,
 			Port:      c.port,
 		}
 
-		output, err := exec.CommandContext(ctx, "docker", "inspect",
+		output, err := lm.manager.output(ctx, "docker", "inspect",
 			"--format", "{{.State.Status}},{{.State.Health.Status}}",
-			c.container).Output()
+			c.container)
 		if err != nil {
 			status.State = StateStopped
 			statuses = append(statuses, status)
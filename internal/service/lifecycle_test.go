@@ -0,0 +1,298 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/doom-coding/doom-coding/internal/runner/runnertest"
+)
+
+const healthFormat = "{{.State.Status}},{{.State.Health.Status}}"
+
+// newTestLifecycle creates a lifecycle manager over a temp project root
+// with a compose file, running commands through fake
+func newTestLifecycle(t *testing.T, fake *runnertest.Fake) (*LifecycleManager, string) {
+	t.Helper()
+	root := t.TempDir()
+	compose := "services:\n  tailscale: {}\n  code-server: {}\n  claude: {}\n"
+	if err := os.WriteFile(filepath.Join(root, "docker-compose.yml"), []byte(compose), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewManager(root)
+	m.SetRunner(fake)
+	lm := NewLifecycleManager(m, root, "docker-compose.yml")
+	lm.pollInterval = time.Millisecond
+	lm.SetTimeout(5 * time.Second)
+	return lm, filepath.Join(root, "docker-compose.yml")
+}
+
+func TestLifecycleStartWaitsForHealth(t *testing.T) {
+	fake := runnertest.New()
+	lm, composePath := newTestLifecycle(t, fake)
+
+	fake.On("docker", "compose", "-f", composePath, "pull").Returns("Pulling code-server ... done\n")
+	fake.On("docker", "compose", "-f", composePath, "up", "-d").Returns("Container doom-code-server Started\n")
+
+	fake.On("docker", "inspect", "doom-tailscale").Exactly()
+	fake.On("docker", "inspect", "--format", healthFormat, "doom-tailscale").Returns("running,<no value>\n")
+
+	fake.On("docker", "inspect", "doom-code-server").Exactly()
+	fake.On("docker", "inspect", "--format", healthFormat, "doom-code-server").Returns("running,starting\n").Times(2)
+	fake.On("docker", "inspect", "--format", healthFormat, "doom-code-server").Returns("running,healthy\n")
+
+	fake.On("docker", "inspect", "doom-claude").Exactly().Stderr("Error: No such object: doom-claude\n").Exit(1)
+
+	fake.On("tailscale", "ip", "-4").Returns("100.64.0.7\n")
+
+	result, err := lm.Start(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if !result.Success {
+		t.Errorf("Expected success, got errors %v", result.Errors)
+	}
+
+	want := map[string]ServiceState{
+		"doom-tailscale":   StateRunning,
+		"doom-code-server": StateHealthy,
+		"doom-claude":      StateStopped,
+	}
+	if len(result.Services) != len(want) {
+		t.Fatalf("Expected %d services, got %d", len(want), len(result.Services))
+	}
+	for _, svc := range result.Services {
+		if svc.State != want[svc.Container] {
+			t.Errorf("%s: expected %s, got %s", svc.Container, want[svc.Container], svc.State)
+		}
+	}
+
+	if n := fake.Count("docker", "inspect", "--format", healthFormat, "doom-code-server"); n != 3 {
+		t.Errorf("Expected code-server to be polled 3 times, got %d", n)
+	}
+	if result.AccessURLs["code-server"] != "https://100.64.0.7:8443" {
+		t.Errorf("Unexpected access URL %q", result.AccessURLs["code-server"])
+	}
+	if unmatched := fake.Unmatched(); len(unmatched) > 0 {
+		t.Errorf("Unexpected commands: %v", unmatched)
+	}
+
+	for _, call := range fake.Calls() {
+		if call.Name == "docker" && len(call.Args) > 0 && call.Args[0] == "compose" && call.Dir != lm.projectRoot {
+			t.Errorf("compose should run in the project root, got dir %q", call.Dir)
+		}
+	}
+}
+
+func TestLifecycleStartFiltersOutputThroughLogger(t *testing.T) {
+	fake := runnertest.New()
+	lm, composePath := newTestLifecycle(t, fake)
+	lm.SetHealthChecks(false)
+
+	var logFile bytes.Buffer
+	lm.SetLogger(NewLogger(&logFile, nil))
+
+	fake.On("docker", "compose", "-f", composePath, "pull").Returns("Pulling code-server\n")
+	fake.On("docker", "compose", "-f", composePath, "up", "-d").Returns("Container doom-claude Started\n")
+	fake.On("tailscale", "ip", "-4").Returns("100.64.0.7\n")
+
+	if _, err := lm.Start(context.Background(), nil); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	for _, want := range []string{"[docker-pull] Pulling code-server", "[docker-up] Container doom-claude Started"} {
+		if !strings.Contains(logFile.String(), want) {
+			t.Errorf("Expected %q in log, got:\n%s", want, logFile.String())
+		}
+	}
+}
+
+func TestLifecycleStartFailure(t *testing.T) {
+	fake := runnertest.New()
+	lm, composePath := newTestLifecycle(t, fake)
+
+	fake.On("docker", "compose", "-f", composePath, "pull").Returns("")
+	fake.On("docker", "compose", "-f", composePath, "up", "-d").Stderr("port is already allocated\n").Exit(1)
+
+	result, err := lm.Start(context.Background(), nil)
+	if err == nil {
+		t.Fatal("Expected error when compose up fails")
+	}
+	if result.Success || len(result.Errors) == 0 {
+		t.Error("Expected failed result with errors")
+	}
+	if fake.Count("docker", "inspect") != 0 {
+		t.Error("Health checks should not run after a failed start")
+	}
+}
+
+func TestLifecycleStop(t *testing.T) {
+	fake := runnertest.New()
+	lm, composePath := newTestLifecycle(t, fake)
+
+	fake.On("docker", "compose", "-f", composePath, "down").Returns("Container doom-claude Removed\n")
+	fake.On("docker", "inspect", "--format", "{{.State.Status}}", "doom-tailscale").Exit(1)
+	fake.On("docker", "inspect", "--format", "{{.State.Status}}", "doom-code-server").Returns("exited\n")
+	fake.On("docker", "inspect", "--format", "{{.State.Status}}", "doom-claude").Returns("running\n")
+	fake.On("docker", "stop", "-t", "5", "doom-claude")
+
+	result, err := lm.Stop(context.Background())
+	if err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	if !result.Success {
+		t.Errorf("Expected success, got errors %v", result.Errors)
+	}
+	if fake.Count("docker", "stop", "-t", "5", "doom-claude") != 1 {
+		t.Error("Expected a still-running container to be force stopped")
+	}
+	if fake.Count("docker", "stop") != 1 {
+		t.Error("Stopped containers should not be stopped again")
+	}
+	if unmatched := fake.Unmatched(); len(unmatched) > 0 {
+		t.Errorf("Unexpected commands: %v", unmatched)
+	}
+}
+
+func TestLifecycleStopComposeFailure(t *testing.T) {
+	fake := runnertest.New()
+	lm, composePath := newTestLifecycle(t, fake)
+
+	fake.On("docker", "compose", "-f", composePath, "down").Stderr("no configuration file provided\n").Exit(14)
+	fake.On("docker", "inspect").Exit(1)
+
+	result, err := lm.Stop(context.Background())
+	if err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	if result.Success || len(result.Errors) != 1 {
+		t.Fatalf("Expected one error, got %v", result.Errors)
+	}
+	if want := "no configuration file provided"; !strings.Contains(result.Errors[0], want) {
+		t.Errorf("Expected compose output in error, got %q", result.Errors[0])
+	}
+}
+
+func TestLifecycleStatus(t *testing.T) {
+	fake := runnertest.New()
+	lm, _ := newTestLifecycle(t, fake)
+
+	fake.On("docker", "inspect", "--format", healthFormat, "doom-tailscale").Returns("running,<no value>\n")
+	fake.On("docker", "inspect", "--format", healthFormat, "doom-code-server").Returns("running,unhealthy\n")
+	fake.On("docker", "inspect", "--format", healthFormat, "doom-claude").Exit(1)
+
+	want := []ServiceState{StateRunning, StateUnhealthy, StateStopped}
+	statuses := lm.Status(context.Background())
+	if len(statuses) != len(want) {
+		t.Fatalf("Expected %d statuses, got %d", len(want), len(statuses))
+	}
+	for i, status := range statuses {
+		if status.State != want[i] {
+			t.Errorf("%s: expected %s, got %s", status.Container, want[i], status.State)
+		}
+	}
+}
+
+func TestLifecycleStatusComposeFile(t *testing.T) {
+	fake := runnertest.New()
+	lm, _ := newTestLifecycle(t, fake)
+	lxc := "services:\n  code-server: {}\n  claude: {}\nvolumes:\n  claude-config: {}\n"
+	if err := os.WriteFile(filepath.Join(lm.projectRoot, "docker-compose.lxc.yml"), []byte(lxc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fake.On("docker", "inspect", "--format", healthFormat, "doom-code-server").Returns("running,healthy\n")
+	fake.On("docker", "inspect", "--format", healthFormat, "doom-claude").Returns("running,healthy\n")
+
+	lm.composeFile = "docker-compose.lxc.yml"
+	statuses := lm.Status(context.Background())
+	if len(statuses) != 2 || statuses[0].Container != "doom-code-server" || statuses[1].Container != "doom-claude" {
+		t.Errorf("Only the services of the compose file should be reported, got %+v", statuses)
+	}
+	if fake.Count("docker", "inspect", "--format", healthFormat, "doom-tailscale") != 0 {
+		t.Error("The Tailscale container is not part of the local stack")
+	}
+
+	lm.composeFile = ""
+	if statuses := lm.Status(context.Background()); len(statuses) != 0 {
+		t.Errorf("Terminal-only installs have no services, got %+v", statuses)
+	}
+}
+
+func TestLifecycleRestartServices(t *testing.T) {
+	fake := runnertest.New()
+	lm, composePath := newTestLifecycle(t, fake)
+
+	fake.On("docker", "compose", "-f", composePath, "up", "-d", "--force-recreate", "--no-deps", "code-server").Returns("")
+	fake.On("docker", "inspect", "--format", healthFormat, "doom-code-server").Returns("running,starting\n").Once()
+	fake.On("docker", "inspect", "--format", healthFormat, "doom-code-server").Returns("running,healthy\n")
+
+	statuses, err := lm.RestartServices(context.Background(), "code-server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 1 || statuses[0].State != StateHealthy || statuses[0].Container != "doom-code-server" {
+		t.Errorf("Expected a healthy code-server, got %+v", statuses)
+	}
+	if fake.Count("docker", "inspect", "--format", healthFormat, "doom-claude") != 0 {
+		t.Error("Only the restarted services should be checked")
+	}
+}
+
+func TestLifecycleRestartServicesUnhealthy(t *testing.T) {
+	fake := runnertest.New()
+	lm, composePath := newTestLifecycle(t, fake)
+
+	fake.On("docker", "compose", "-f", composePath, "up", "-d", "--force-recreate", "--no-deps", "tailscale").Returns("")
+	fake.On("docker", "inspect", "--format", healthFormat, "doom-tailscale").Returns("restarting,unhealthy\n")
+
+	statuses, err := lm.RestartServices(context.Background(), "tailscale")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 1 || statuses[0].State != StateStopped || statuses[0].Error == "" {
+		t.Errorf("Expected a stopped tailscale with an error, got %+v", statuses)
+	}
+
+	if _, err := lm.RestartServices(context.Background(), "nginx"); err == nil {
+		t.Error("Unknown services should be rejected")
+	}
+}
+
+func TestManagerContainerExists(t *testing.T) {
+	fake := runnertest.New()
+	lm, _ := newTestLifecycle(t, fake)
+
+	fake.On("docker", "inspect", "--format", "{{.Id}}", "doom-code-server").Returns("4f2a\n")
+	fake.On("docker", "inspect", "--format", "{{.Id}}", "doom-claude").Exit(1)
+
+	if !lm.manager.ContainerExists(context.Background(), "doom-code-server") {
+		t.Error("doom-code-server should exist")
+	}
+	if lm.manager.ContainerExists(context.Background(), "doom-claude") {
+		t.Error("doom-claude should not exist")
+	}
+}
+
+func TestManagerComposeFile(t *testing.T) {
+	fake := runnertest.New()
+	lm, _ := newTestLifecycle(t, fake)
+
+	fake.On("docker", "inspect", "--format", composeFilesFormat, "doom-code-server").
+		Returns(filepath.Join(lm.projectRoot, "docker-compose.lxc.yml") + "\n")
+	fake.On("docker", "inspect", "--format", composeFilesFormat, "doom-claude").Returns("/srv/other/docker-compose.yml\n")
+	fake.On("docker", "inspect", "--format", composeFilesFormat, "doom-tailscale").Exit(1)
+
+	if file, ok := lm.manager.ComposeFile(context.Background(), "doom-code-server"); !ok || file != "docker-compose.lxc.yml" {
+		t.Errorf("ComposeFile = %q, %v", file, ok)
+	}
+	for _, container := range []string{"doom-claude", "doom-tailscale"} {
+		if file, ok := lm.manager.ComposeFile(context.Background(), container); ok {
+			t.Errorf("%s: expected no compose file of this project, got %q", container, file)
+		}
+	}
+}
@@ -7,10 +7,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
-	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/doom-coding/doom-coding/internal/runner"
 )
 
 // ServiceState represents the current state of a service
@@ -81,6 +83,7 @@ type Manager struct {
 	portRange        PortRange
 	doomContainers   []string
 	verbose          bool
+	runner           runner.CommandRunner
 }
 
 // PortRange defines the range for dynamic port allocation
@@ -92,21 +95,28 @@ type PortRange struct {
 // DefaultPortRange is the range used for automatic port allocation
 var DefaultPortRange = PortRange{Start: 8000, End: 9000}
 
+// DefaultPorts returns the host ports the doom-coding services use by
+// default, keyed by service name
+func DefaultPorts() map[string]int {
+	return map[string]int{
+		"code-server": 8443,
+		"ttyd":        7681,
+		"tailscale":   0, // No port exposed directly
+	}
+}
+
 // NewManager creates a new service manager
 func NewManager(projectRoot string) *Manager {
 	return &Manager{
-		projectRoot: projectRoot,
-		defaultPorts: map[string]int{
-			"code-server": 8443,
-			"ttyd":        7681,
-			"tailscale":   0, // No port exposed directly
-		},
+		projectRoot:  projectRoot,
+		defaultPorts: DefaultPorts(),
 		portRange: DefaultPortRange,
 		doomContainers: []string{
 			"doom-tailscale",
 			"doom-code-server",
 			"doom-claude",
 		},
+		runner: runner.Default,
 	}
 }
 
@@ -115,6 +125,22 @@ func (m *Manager) SetVerbose(verbose bool) {
 	m.verbose = verbose
 }
 
+// SetRunner sets how commands are executed. The lifecycle manager and
+// migrator built on this manager use the same runner.
+func (m *Manager) SetRunner(r runner.CommandRunner) {
+	m.runner = runner.OrDefault(r)
+}
+
+// run executes a command and discards its output
+func (m *Manager) run(ctx context.Context, name string, args ...string) error {
+	return m.runner.Run(ctx, runner.Command{Name: name, Args: args})
+}
+
+// output executes a command and returns its stdout
+func (m *Manager) output(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return runner.Output(ctx, m.runner, name, args...)
+}
+
 // DetectExistingServices scans for all doom-coding related services
 func (m *Manager) DetectExistingServices(ctx context.Context) ([]ServiceInfo, error) {
 	var services []ServiceInfo
@@ -142,13 +168,12 @@ func (m *Manager) DetectExistingServices(ctx context.Context) ([]ServiceInfo, er
 // detectDockerServices finds all Docker containers with doom-coding labels
 func (m *Manager) detectDockerServices(ctx context.Context) ([]ServiceInfo, error) {
 	// Check if Docker is available
-	if err := exec.CommandContext(ctx, "docker", "info").Run(); err != nil {
+	if err := m.run(ctx, "docker", "info"); err != nil {
 		return nil, fmt.Errorf("docker not available: %w", err)
 	}
 
 	// List containers with doom-coding labels or names
-	cmd := exec.CommandContext(ctx, "docker", "ps", "-a", "--format", "{{json .}}")
-	output, err := cmd.Output()
+	output, err := m.output(ctx, "docker", "ps", "-a", "--format", "{{json .}}")
 	if err != nil {
 		return nil, fmt.Errorf("failed to list containers: %w", err)
 	}
@@ -264,13 +289,12 @@ func (m *Manager) checkPort(ctx context.Context, port int) *ServiceInfo {
 	}
 
 	// Use lsof to identify the process (Linux)
-	cmd := exec.CommandContext(ctx, "lsof", "-i", fmt.Sprintf(":%d", port), "-P", "-n", "-t")
-	if output, err := cmd.Output(); err == nil {
+	if output, err := m.output(ctx, "lsof", "-i", fmt.Sprintf(":%d", port), "-P", "-n", "-t"); err == nil {
 		pid, _ := strconv.Atoi(strings.TrimSpace(string(output)))
 		if pid > 0 {
 			svc.PID = pid
 			// Get process name
-			if name, err := exec.CommandContext(ctx, "ps", "-p", strconv.Itoa(pid), "-o", "comm=").Output(); err == nil {
+			if name, err := m.output(ctx, "ps", "-p", strconv.Itoa(pid), "-o", "comm="); err == nil {
 				svc.ProcessName = strings.TrimSpace(string(name))
 			}
 		}
@@ -278,8 +302,7 @@ func (m *Manager) checkPort(ctx context.Context, port int) *ServiceInfo {
 
 	// Try ss as fallback
 	if svc.PID == 0 {
-		cmd = exec.CommandContext(ctx, "ss", "-tlpn", fmt.Sprintf("sport = :%d", port))
-		if output, err := cmd.Output(); err == nil {
+		if output, err := m.output(ctx, "ss", "-tlpn", fmt.Sprintf("sport = :%d", port)); err == nil {
 			svc.Name = fmt.Sprintf("Process on port %d", port)
 			if strings.Contains(string(output), "code-server") {
 				svc.Type = TypeCodeServer
@@ -303,7 +326,7 @@ func (m *Manager) checkPort(ctx context.Context, port int) *ServiceInfo {
 // detectHostTailscale checks for Tailscale running on the host
 func (m *Manager) detectHostTailscale(ctx context.Context) *ServiceInfo {
 	// Check if tailscale command exists
-	if _, err := exec.LookPath("tailscale"); err != nil {
+	if _, err := m.runner.LookPath("tailscale"); err != nil {
 		return nil
 	}
 
@@ -313,8 +336,7 @@ func (m *Manager) detectHostTailscale(ctx context.Context) *ServiceInfo {
 	}
 
 	// Check status
-	cmd := exec.CommandContext(ctx, "tailscale", "status", "--json")
-	output, err := cmd.Output()
+	output, err := m.output(ctx, "tailscale", "status", "--json")
 	if err != nil {
 		svc.State = StateStopped
 		return svc
@@ -436,29 +458,56 @@ func (m *Manager) FindAvailablePorts(ctx context.Context) (map[string]int, error
 func (m *Manager) StopDoomServices(ctx context.Context, timeout time.Duration) error {
 	for _, containerName := range m.doomContainers {
 		// Check if container exists
-		if err := exec.CommandContext(ctx, "docker", "inspect", containerName).Run(); err != nil {
+		if err := m.run(ctx, "docker", "inspect", containerName); err != nil {
 			continue // Container doesn't exist
 		}
 
 		// Stop with timeout
 		stopCtx, cancel := context.WithTimeout(ctx, timeout)
-		cmd := exec.CommandContext(stopCtx, "docker", "stop", "-t", strconv.Itoa(int(timeout.Seconds())), containerName)
-		err := cmd.Run()
+		err := m.run(stopCtx, "docker", "stop", "-t", strconv.Itoa(int(timeout.Seconds())), containerName)
 		cancel()
 
 		if err != nil {
 			// Force kill if graceful stop failed
-			exec.CommandContext(ctx, "docker", "kill", containerName).Run()
+			m.run(ctx, "docker", "kill", containerName)
 		}
 	}
 
 	return nil
 }
 
+// composeFilesFormat reads the compose files docker compose records on the
+// containers it creates
+const composeFilesFormat = `{{index .Config.Labels "com.docker.compose.project.config_files"}}`
+
+// ComposeFile returns the compose file a container was created from,
+// relative to the project root. ok is false when the container does not
+// exist or was not created by docker compose from this project.
+func (m *Manager) ComposeFile(ctx context.Context, container string) (file string, ok bool) {
+	output, err := m.output(ctx, "docker", "inspect", "--format", composeFilesFormat, container)
+	if err != nil {
+		return "", false
+	}
+	first, _, _ := strings.Cut(strings.TrimSpace(string(output)), ",")
+	if first == "" || first == "<no value>" {
+		return "", false
+	}
+	rel, err := filepath.Rel(m.projectRoot, first)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", false
+	}
+	return rel, true
+}
+
+// ContainerExists reports whether docker knows a container, running or not
+func (m *Manager) ContainerExists(ctx context.Context, container string) bool {
+	return m.run(ctx, "docker", "inspect", "--format", "{{.Id}}", container) == nil
+}
+
 // RemoveDoomContainers removes all doom-coding containers
 func (m *Manager) RemoveDoomContainers(ctx context.Context) error {
 	for _, containerName := range m.doomContainers {
-		exec.CommandContext(ctx, "docker", "rm", "-f", containerName).Run()
+		m.run(ctx, "docker", "rm", "-f", containerName)
 	}
 	return nil
 }
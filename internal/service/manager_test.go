@@ -2,8 +2,11 @@ package service
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/doom-coding/doom-coding/internal/runner/runnertest"
 )
 
 func TestNewManager(t *testing.T) {
@@ -286,3 +289,97 @@ func containsStringHelper(s, substr string) bool {
 	}
 	return false
 }
+
+func TestDetectExistingServicesWithFake(t *testing.T) {
+	fake := runnertest.New()
+	fake.On("docker", "info").Returns("Server Version: 24.0.7\n")
+	fake.On("docker", "ps", "-a", "--format", "{{json .}}").Returns(
+		`{"ID":"a1b2c3","Names":"doom-code-server","State":"running","Ports":"0.0.0.0:8443->8443/tcp","Labels":"com.doom-coding.service=code-server","Image":"lscr.io/linuxserver/code-server"}` + "\n" +
+			`{"ID":"d4e5f6","Names":"doom-tailscale","State":"exited","Labels":"","Image":"tailscale/tailscale"}` + "\n" +
+			`{"ID":"0a0b0c","Names":"postgres","State":"running","Labels":"","Image":"postgres:16"}` + "\n" +
+			"not json\n")
+	fake.On("tailscale", "status", "--json").Returns(`{"BackendState":"Running","Version":"1.56.1"}`)
+	fake.Missing("lsof", "ss", "ps")
+
+	m := NewManager("/tmp/test")
+	m.SetRunner(fake)
+	services, err := m.DetectExistingServices(context.Background())
+	if err != nil {
+		t.Fatalf("DetectExistingServices failed: %v", err)
+	}
+
+	byName := make(map[string]ServiceInfo)
+	for _, svc := range services {
+		byName[svc.Name] = svc
+	}
+
+	codeServer, ok := byName["doom-code-server"]
+	if !ok {
+		t.Fatalf("doom-code-server not detected in %+v", services)
+	}
+	if codeServer.Type != TypeCodeServer || codeServer.State != StateRunning || !codeServer.IsDoomManaged {
+		t.Errorf("Unexpected code-server info %+v", codeServer)
+	}
+	if codeServer.Port != 8443 || codeServer.Labels["com.doom-coding.service"] != "code-server" {
+		t.Errorf("Expected port and labels parsed, got %+v", codeServer)
+	}
+
+	if ts := byName["doom-tailscale"]; ts.Type != TypeTailscale || ts.State != StateStopped {
+		t.Errorf("Unexpected tailscale container info %+v", ts)
+	}
+	if _, ok := byName["postgres"]; ok {
+		t.Error("Unrelated containers should be ignored")
+	}
+	if host := byName["Host Tailscale"]; host.State != StateRunning || host.Version != "1.56.1" {
+		t.Errorf("Unexpected host tailscale info %+v", host)
+	}
+}
+
+func TestDetectExistingServicesDockerUnavailable(t *testing.T) {
+	fake := runnertest.New()
+	fake.On("docker", "info").Stderr("Cannot connect to the Docker daemon\n").Exit(1)
+	fake.Missing("tailscale")
+
+	m := NewManager("/tmp/test")
+	m.SetRunner(fake)
+	services, err := m.DetectExistingServices(context.Background())
+	if err != nil {
+		t.Fatalf("DetectExistingServices failed: %v", err)
+	}
+	for _, svc := range services {
+		if svc.ContainerID != "" || svc.Type == TypeTailscale {
+			t.Errorf("Unexpected service without docker or tailscale: %+v", svc)
+		}
+	}
+	if fake.Count("docker", "ps") != 0 {
+		t.Error("Containers should not be listed when docker is unavailable")
+	}
+}
+
+func TestStopDoomServices(t *testing.T) {
+	fake := runnertest.New()
+	fake.On("docker", "inspect", "doom-tailscale").Exit(1)
+	fake.On("docker", "inspect")
+	fake.On("docker", "stop", "-t", "10", "doom-claude").Exit(1)
+	fake.On("docker", "stop", "-t", "10")
+	fake.On("docker", "kill", "doom-claude")
+
+	m := NewManager("/tmp/test")
+	m.SetRunner(fake)
+	if err := m.StopDoomServices(context.Background(), 10*time.Second); err != nil {
+		t.Fatalf("StopDoomServices failed: %v", err)
+	}
+
+	want := []string{
+		"docker inspect doom-tailscale",
+		"docker inspect doom-code-server",
+		"docker stop -t 10 doom-code-server",
+		"docker inspect doom-claude",
+		"docker stop -t 10 doom-claude",
+		"docker kill doom-claude",
+	}
+	got := fake.Commands()
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected commands:\ngot  %q\nwant %q", got, want)
+	}
+}
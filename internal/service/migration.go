@@ -4,10 +4,11 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/doom-coding/doom-coding/internal/runner"
 )
 
 // MigrationStrategy defines how to handle an existing installation
@@ -74,6 +75,12 @@ func NewMigrator(manager *Manager, projectRoot string) *Migrator {
 	}
 }
 
+// combinedOutput runs a command through the manager's runner and returns
+// its stdout and stderr
+func (m *Migrator) combinedOutput(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return runner.CombinedOutput(ctx, m.manager.runner, name, args...)
+}
+
 // SetDryRun enables dry-run mode
 func (m *Migrator) SetDryRun(dryRun bool) {
 	m.dryRun = dryRun
@@ -322,7 +329,7 @@ func (m *Migrator) executeAction(ctx context.Context, action MigrationAction) Ac
 		}
 
 	case "stop":
-		output, err := exec.CommandContext(ctx, "docker", "stop", "-t", "30", action.Target).CombinedOutput()
+		output, err := m.combinedOutput(ctx, "docker", "stop", "-t", "30", action.Target)
 		if err != nil {
 			result.Error = fmt.Sprintf("%v: %s", err, string(output))
 		} else {
@@ -331,7 +338,7 @@ func (m *Migrator) executeAction(ctx context.Context, action MigrationAction) Ac
 		}
 
 	case "remove":
-		output, err := exec.CommandContext(ctx, "docker", "rm", "-f", action.Target).CombinedOutput()
+		output, err := m.combinedOutput(ctx, "docker", "rm", "-f", action.Target)
 		if err != nil {
 			result.Error = fmt.Sprintf("%v: %s", err, string(output))
 		} else {
@@ -342,7 +349,7 @@ func (m *Migrator) executeAction(ctx context.Context, action MigrationAction) Ac
 	case "pull":
 		// Pull images via docker compose
 		composeFile := filepath.Join(m.projectRoot, "docker-compose.yml")
-		output, err := exec.CommandContext(ctx, "docker", "compose", "-f", composeFile, "pull").CombinedOutput()
+		output, err := m.combinedOutput(ctx, "docker", "compose", "-f", composeFile, "pull")
 		if err != nil {
 			result.Error = fmt.Sprintf("%v: %s", err, string(output))
 		} else {
@@ -361,7 +368,7 @@ func (m *Migrator) executeAction(ctx context.Context, action MigrationAction) Ac
 
 	case "start":
 		composeFile := filepath.Join(m.projectRoot, "docker-compose.yml")
-		output, err := exec.CommandContext(ctx, "docker", "compose", "-f", composeFile, "up", "-d").CombinedOutput()
+		output, err := m.combinedOutput(ctx, "docker", "compose", "-f", composeFile, "up", "-d")
 		if err != nil {
 			result.Error = fmt.Sprintf("%v: %s", err, string(output))
 		} else {
@@ -410,7 +417,7 @@ func (m *Migrator) backup(ctx context.Context, target string) error {
 		for _, path := range configPaths {
 			if _, err := os.Stat(path); err == nil {
 				// Copy recursively
-				exec.CommandContext(ctx, "cp", "-r", path, filepath.Join(backupPath, "code-server")).Run()
+				m.manager.run(ctx, "cp", "-r", path, filepath.Join(backupPath, "code-server"))
 				break
 			}
 		}
@@ -424,13 +431,11 @@ func (m *Migrator) backupVolume(ctx context.Context, volumeName, backupPath stri
 	tarPath := filepath.Join(backupPath, volumeName+".tar")
 
 	// Use a temporary container to access the volume
-	cmd := exec.CommandContext(ctx, "docker", "run", "--rm",
+	return m.manager.run(ctx, "docker", "run", "--rm",
 		"-v", volumeName+":/data",
 		"-v", backupPath+":/backup",
 		"alpine",
-		"tar", "cvf", "/backup/"+volumeName+".tar", "-C", "/data", ".")
-
-	return cmd.Run()
+		"tar", "cvf", "/backup/"+filepath.Base(tarPath), "-C", "/data", ".")
 }
 
 // migrateData migrates data from old installation
@@ -446,10 +451,9 @@ func (m *Migrator) migrateData(ctx context.Context, target string) error {
 		for _, source := range sourcePaths {
 			if _, err := os.Stat(source); err == nil {
 				// Copy to doom-coding volume via docker cp
-				cmd := exec.CommandContext(ctx, "docker", "cp",
+				if err := m.manager.run(ctx, "docker", "cp",
 					source,
-					"doom-code-server:/config/.local/share/code-server/")
-				if err := cmd.Run(); err != nil {
+					"doom-code-server:/config/.local/share/code-server/"); err != nil {
 					return fmt.Errorf("failed to migrate extensions: %w", err)
 				}
 				return nil
@@ -465,10 +469,9 @@ func (m *Migrator) migrateData(ctx context.Context, target string) error {
 
 		for _, source := range sourcePaths {
 			if _, err := os.Stat(source); err == nil {
-				cmd := exec.CommandContext(ctx, "docker", "cp",
+				if err := m.manager.run(ctx, "docker", "cp",
 					source,
-					"doom-code-server:/config/.local/share/code-server/User/")
-				if err := cmd.Run(); err != nil {
+					"doom-code-server:/config/.local/share/code-server/User/"); err != nil {
 					return fmt.Errorf("failed to migrate settings: %w", err)
 				}
 				return nil
@@ -491,7 +494,7 @@ func (m *Migrator) Rollback(ctx context.Context, result *MigrationResult) error
 		switch action.Action.Type {
 		case "stop":
 			// Restart the container
-			exec.CommandContext(ctx, "docker", "start", action.Action.Target).Run()
+			m.manager.run(ctx, "docker", "start", action.Action.Target)
 		case "remove":
 			// Cannot easily restore a removed container
 			// Would need to restore from backup
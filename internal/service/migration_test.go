@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/doom-coding/doom-coding/internal/runner/runnertest"
+)
+
+func TestMigratorExecuteAndRollback(t *testing.T) {
+	fake := runnertest.New()
+	fake.On("docker", "stop", "-t", "30", "old-code-server").Returns("old-code-server\n")
+	fake.On("docker", "rm", "-f", "old-code-server").Stderr("removal in progress\n").Exit(1)
+	fake.On("docker", "start", "old-code-server")
+
+	m := NewManager("/tmp/test")
+	m.SetRunner(fake)
+	migrator := NewMigrator(m, "/tmp/test")
+
+	plan := &MigrationPlan{Actions: []MigrationAction{
+		{Order: 1, Type: "stop", Target: "old-code-server", Description: "Stop old container", Reversible: true},
+		{Order: 2, Type: "remove", Target: "old-code-server", Description: "Remove old container"},
+		{Order: 3, Type: "start", Description: "Start doom-coding"},
+	}}
+
+	result, err := migrator.Execute(context.Background(), plan)
+	if err == nil {
+		t.Fatal("Expected failure from the remove action")
+	}
+	if len(result.Actions) != 2 || !result.Actions[0].Success || result.Actions[1].Success {
+		t.Fatalf("Unexpected action results %+v", result.Actions)
+	}
+	if !strings.Contains(result.Actions[1].Error, "removal in progress") {
+		t.Errorf("Expected command output in error, got %q", result.Actions[1].Error)
+	}
+	if fake.Count("docker", "compose") != 0 {
+		t.Error("Actions after a failure should not run")
+	}
+
+	if err := migrator.Rollback(context.Background(), result); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	if fake.Count("docker", "start", "old-code-server") != 1 {
+		t.Error("Rollback should restart the stopped container")
+	}
+}
+
+func TestMigratorDryRun(t *testing.T) {
+	fake := runnertest.New()
+	m := NewManager("/tmp/test")
+	m.SetRunner(fake)
+	migrator := NewMigrator(m, "/tmp/test")
+	migrator.SetDryRun(true)
+
+	plan := &MigrationPlan{Actions: []MigrationAction{{Type: "stop", Target: "old-code-server"}}}
+	result, err := migrator.Execute(context.Background(), plan)
+	if err != nil || !result.Success {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+	if len(fake.Calls()) != 0 {
+		t.Errorf("Dry run should not run commands, got %v", fake.Commands())
+	}
+}
@@ -2,14 +2,15 @@ package system
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"net"
 	"os"
-	"os/exec"
 	"os/user"
-	"path/filepath"
 	"runtime"
 	"strings"
+
+	"github.com/doom-coding/doom-coding/internal/runner"
 )
 
 // SystemInfo contains detected system information
@@ -54,6 +55,13 @@ type SystemInfo struct {
 
 // DetectSystem performs comprehensive system detection
 func DetectSystem() (*SystemInfo, error) {
+	return DetectSystemWith(runner.Default)
+}
+
+// DetectSystemWith performs system detection, running external commands
+// through r
+func DetectSystemWith(r runner.CommandRunner) (*SystemInfo, error) {
+	r = runner.OrDefault(r)
 	info := &SystemInfo{
 		OS:   runtime.GOOS,
 		Arch: runtime.GOARCH,
@@ -70,7 +78,7 @@ func DetectSystem() (*SystemInfo, error) {
 	}
 
 	// Detect distribution
-	detectDistribution(info)
+	detectDistribution(info, r)
 
 	// Container detection
 	detectContainer(info)
@@ -79,18 +87,23 @@ func DetectSystem() (*SystemInfo, error) {
 	detectDevices(info)
 
 	// Software detection
-	detectSoftware(info)
+	detectSoftware(info, r)
 
 	// Network detection
 	detectNetwork(info)
 
 	// Resource detection
-	detectResources(info)
+	detectResources(info, r)
 
 	return info, nil
 }
 
-func detectDistribution(info *SystemInfo) {
+// commandOutput runs a detection command and returns its stdout
+func commandOutput(r runner.CommandRunner, name string, args ...string) ([]byte, error) {
+	return runner.Output(context.Background(), r, name, args...)
+}
+
+func detectDistribution(info *SystemInfo, r runner.CommandRunner) {
 	// Try /etc/os-release first (most common)
 	if data, err := os.ReadFile("/etc/os-release"); err == nil {
 		scanner := bufio.NewScanner(strings.NewReader(string(data)))
@@ -106,7 +119,7 @@ func detectDistribution(info *SystemInfo) {
 
 	// Fallback to lsb_release
 	if info.Distribution == "" {
-		if output, err := exec.Command("lsb_release", "-si").Output(); err == nil {
+		if output, err := commandOutput(r, "lsb_release", "-si"); err == nil {
 			info.Distribution = strings.TrimSpace(string(output))
 		}
 	}
@@ -171,45 +184,45 @@ func detectDevices(info *SystemInfo) {
 	}
 }
 
-func detectSoftware(info *SystemInfo) {
+func detectSoftware(info *SystemInfo, r runner.CommandRunner) {
 	// Docker
-	if path, err := exec.LookPath("docker"); err == nil {
+	if path, err := r.LookPath("docker"); err == nil {
 		info.DockerInstalled = true
 		// Get version
-		if output, err := exec.Command(path, "--version").Output(); err == nil {
+		if output, err := commandOutput(r, path, "--version"); err == nil {
 			parts := strings.Split(string(output), ",")
 			if len(parts) > 0 {
 				info.DockerVersion = strings.TrimSpace(parts[0])
 			}
 		}
 		// Check if running
-		if err := exec.Command(path, "info").Run(); err == nil {
+		if err := r.Run(context.Background(), runner.Command{Name: path, Args: []string{"info"}}); err == nil {
 			info.DockerRunning = true
 		}
 	}
 
 	// Tailscale
-	if _, err := exec.LookPath("tailscale"); err == nil {
+	if _, err := r.LookPath("tailscale"); err == nil {
 		info.TailscaleInstalled = true
 		// Check if connected
-		if output, err := exec.Command("tailscale", "status", "--json").Output(); err == nil {
+		if output, err := commandOutput(r, "tailscale", "status", "--json"); err == nil {
 			if strings.Contains(string(output), `"BackendState":"Running"`) {
 				info.TailscaleRunning = true
 			}
 		}
 		// Get IP
-		if output, err := exec.Command("tailscale", "ip", "-4").Output(); err == nil {
+		if output, err := commandOutput(r, "tailscale", "ip", "-4"); err == nil {
 			info.TailscaleIP = strings.TrimSpace(string(output))
 		}
 	}
 
 	// Zsh
-	if _, err := exec.LookPath("zsh"); err == nil {
+	if _, err := r.LookPath("zsh"); err == nil {
 		info.ZshInstalled = true
 	}
 
 	// Tmux
-	if _, err := exec.LookPath("tmux"); err == nil {
+	if _, err := r.LookPath("tmux"); err == nil {
 		info.TmuxInstalled = true
 	}
 }
@@ -244,10 +257,10 @@ func detectNetwork(info *SystemInfo) {
 	}
 }
 
-func detectResources(info *SystemInfo) {
+func detectResources(info *SystemInfo, r runner.CommandRunner) {
 	// Disk space
 	var stat syscallStatfs
-	if err := statfs(".", &stat); err == nil {
+	if err := statfs(r, ".", &stat); err == nil {
 		info.DiskFreeGB = float64(stat.Bavail*uint64(stat.Bsize)) / (1024 * 1024 * 1024)
 	}
 
@@ -365,9 +378,9 @@ type syscallStatfs struct {
 	Bavail  uint64
 }
 
-func statfs(path string, stat *syscallStatfs) error {
+func statfs(r runner.CommandRunner, path string, stat *syscallStatfs) error {
 	// Use df command as a portable fallback
-	output, err := exec.Command("df", "-B1", path).Output()
+	output, err := commandOutput(r, "df", "-B1", path)
 	if err != nil {
 		return err
 	}
@@ -4,6 +4,9 @@ import (
 	"os"
 	"strings"
 	"testing"
+
+	"github.com/doom-coding/doom-coding/internal/runner"
+	"github.com/doom-coding/doom-coding/internal/runner/runnertest"
 )
 
 func TestDetectSystem(t *testing.T) {
@@ -346,7 +349,7 @@ func TestParseHexIP(t *testing.T) {
 
 func TestDetectDistribution(t *testing.T) {
 	info := &SystemInfo{}
-	detectDistribution(info)
+	detectDistribution(info, runner.Default)
 
 	// On Linux systems, we should get some distribution info
 	if info.OS == "linux" {
@@ -367,7 +370,7 @@ func TestDetectDevices(t *testing.T) {
 
 func TestDetectSoftware(t *testing.T) {
 	info := &SystemInfo{}
-	detectSoftware(info)
+	detectSoftware(info, runner.Default)
 
 	// Just verify it doesn't panic and returns reasonable values
 	t.Logf("Docker: installed=%v, running=%v, version=%s",
@@ -378,6 +381,79 @@ func TestDetectSoftware(t *testing.T) {
 		info.ZshInstalled, info.TmuxInstalled)
 }
 
+func TestDetectSoftwareWithFake(t *testing.T) {
+	fake := runnertest.New()
+	fake.Paths["docker"] = "/usr/bin/docker"
+	fake.Paths["zsh"] = "/bin/zsh"
+	fake.Missing("tmux")
+	fake.On("/usr/bin/docker", "--version").Returns("Docker version 24.0.7, build afdd53b\n")
+	fake.On("/usr/bin/docker", "info").Returns("Server Version: 24.0.7\n")
+	fake.On("tailscale", "status", "--json").Returns(`{"BackendState":"Running","Self":{}}`)
+	fake.On("tailscale", "ip", "-4").Returns("100.64.0.7\n")
+
+	info := &SystemInfo{}
+	detectSoftware(info, fake)
+
+	if !info.DockerInstalled || !info.DockerRunning {
+		t.Errorf("Expected docker installed and running, got %+v", info)
+	}
+	if info.DockerVersion != "Docker version 24.0.7" {
+		t.Errorf("Unexpected docker version %q", info.DockerVersion)
+	}
+	if !info.TailscaleInstalled || !info.TailscaleRunning || info.TailscaleIP != "100.64.0.7" {
+		t.Errorf("Unexpected tailscale detection: installed=%v running=%v ip=%q",
+			info.TailscaleInstalled, info.TailscaleRunning, info.TailscaleIP)
+	}
+	if !info.ZshInstalled || info.TmuxInstalled {
+		t.Errorf("Expected zsh only, got zsh=%v tmux=%v", info.ZshInstalled, info.TmuxInstalled)
+	}
+	if unmatched := fake.Unmatched(); len(unmatched) > 0 {
+		t.Errorf("Unexpected commands: %v", unmatched)
+	}
+}
+
+func TestDetectSoftwareDockerStopped(t *testing.T) {
+	fake := runnertest.New()
+	fake.Missing("tailscale", "zsh", "tmux")
+	fake.On("docker", "--version").Returns("Docker version 24.0.7, build afdd53b\n")
+	fake.On("docker", "info").Stderr("Cannot connect to the Docker daemon\n").Exit(1)
+
+	info := &SystemInfo{}
+	detectSoftware(info, fake)
+
+	if !info.DockerInstalled || info.DockerRunning {
+		t.Errorf("Expected docker installed but not running, got installed=%v running=%v",
+			info.DockerInstalled, info.DockerRunning)
+	}
+	if info.TailscaleInstalled {
+		t.Error("Tailscale should not be detected")
+	}
+	if fake.Count("tailscale") != 0 {
+		t.Error("Tailscale commands should not run when it is not installed")
+	}
+}
+
+func TestStatfsWithFake(t *testing.T) {
+	fake := runnertest.New()
+	fake.On("df", "-B1", "/data").Returns(
+		"Filesystem     1B-blocks        Used   Available Use% Mounted on\n" +
+			"/dev/sda1   100000000000 40000000000 53687091200  43% /data\n")
+
+	var stat syscallStatfs
+	if err := statfs(fake, "/data", &stat); err != nil {
+		t.Fatalf("statfs failed: %v", err)
+	}
+	if stat.Bavail != 53687091200 || stat.Bsize != 1 {
+		t.Errorf("Unexpected stat %+v", stat)
+	}
+
+	fake = runnertest.New()
+	fake.On("df").Returns("Filesystem\n")
+	if err := statfs(fake, "/data", &stat); err == nil {
+		t.Error("Expected error for truncated df output")
+	}
+}
+
 func TestDetectNetwork(t *testing.T) {
 	info := &SystemInfo{}
 	detectNetwork(info)
@@ -401,7 +477,7 @@ func TestDetectNetwork(t *testing.T) {
 
 func TestDetectResources(t *testing.T) {
 	info := &SystemInfo{}
-	detectResources(info)
+	detectResources(info, runner.Default)
 
 	// Should have positive values on most systems
 	t.Logf("DiskFreeGB: %.2f", info.DiskFreeGB)
@@ -418,7 +494,7 @@ func TestDetectResources(t *testing.T) {
 
 func TestStatfs(t *testing.T) {
 	var stat syscallStatfs
-	err := statfs(".", &stat)
+	err := statfs(runner.Default, ".", &stat)
 
 	// On most systems this should succeed for the current directory
 	if err != nil {
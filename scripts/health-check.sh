@@ -28,30 +28,66 @@ WARNINGS=0
 OUTPUT_FORMAT="human"  # human or json
 SHOW_QR=false
 
+# JSON output (see schemas/health-check.schema.json)
+readonly SCHEMA_VERSION=2
+# Check names match the probes of internal/health (DefaultProbes)
+readonly DOOM_CONTAINERS=(doom-tailscale doom-code-server doom-claude)
+readonly TTYD_PORT="${TTYD_PORT:-7681}"
+CURRENT_CHECK="unknown"
+CHECK_ENTRIES=()
+
 # ===========================================
 # LOGGING
 # ===========================================
+# Escape a string for embedding in a JSON document
+json_escape() {
+    local s="$1"
+    s="${s//\\/\\\\}"
+    s="${s//\"/\\\"}"
+    s="${s//$'\n'/\\n}"
+    s="${s//$'\r'/\\r}"
+    s="${s//$'\t'/\\t}"
+    printf '%s' "$s"
+}
+
+# Record a check result for JSON output
+record_check() {
+    local status="$1"
+    shift
+    CHECK_ENTRIES+=("{\"name\": \"$(json_escape "$CURRENT_CHECK")\", \"status\": \"${status}\", \"message\": \"$(json_escape "$*")\"}")
+}
+
 log_pass() {
-    ((PASSED++))
+    PASSED=$((PASSED + 1))
+    record_check pass "$*"
     if [[ "$OUTPUT_FORMAT" == "human" ]]; then
         echo -e "${GREEN}✅${NC} $*"
     fi
 }
 
 log_fail() {
-    ((FAILED++))
+    FAILED=$((FAILED + 1))
+    record_check fail "$*"
     if [[ "$OUTPUT_FORMAT" == "human" ]]; then
         echo -e "${RED}❌${NC} $*"
     fi
 }
 
 log_warn() {
-    ((WARNINGS++))
+    WARNINGS=$((WARNINGS + 1))
+    record_check warn "$*"
     if [[ "$OUTPUT_FORMAT" == "human" ]]; then
         echo -e "${YELLOW}⚠${NC}  $*"
     fi
 }
 
+log_skip() {
+    record_check skip "$*"
+    if [[ "$OUTPUT_FORMAT" == "human" ]]; then
+        echo -e "${BLUE}-${NC}  $*"
+    fi
+}
+
 log_info() {
     if [[ "$OUTPUT_FORMAT" == "human" ]]; then
         echo -e "${BLUE}ℹ${NC}  $*"
@@ -175,102 +211,77 @@ check_docker_compose() {
     fi
 }
 
-check_containers() {
-    cd "$PROJECT_DIR" 2>/dev/null || return 1
-
-    if [[ ! -f "docker-compose.yml" ]]; then
-        log_warn "docker-compose.yml not found"
-        return 0
+check_container() {
+    local name="$1"
+    local output
+    if ! output=$(docker inspect --format '{{.State.Status}},{{if .State.Health}}{{.State.Health.Status}}{{end}}' "$name" 2>/dev/null); then
+        # The sidecar only exists in the tailscale compose stacks
+        if [[ "$name" == "doom-tailscale" ]]; then
+            log_skip "Container $name: Not present"
+            return 0
+        fi
+        log_fail "Container $name: Not found"
+        return 1
     fi
 
-    local containers
-    containers=$(docker compose ps --format json 2>/dev/null || echo "")
-
-    if [[ -z "$containers" ]]; then
-        log_warn "No containers running"
-        return 0
+    local state="${output%%,*}"
+    local health="${output#*,}"
+    if [[ "$state" != "running" ]]; then
+        log_fail "Container $name: $state"
+        return 1
     fi
+    if [[ -z "$health" || "$health" == "healthy" ]]; then
+        log_pass "Container $name: Running${health:+ ($health)}"
+    else
+        log_warn "Container $name: Running but $health"
+    fi
+}
 
-    # Check each container
-    local running=0
-    local total=0
-
-    while IFS= read -r line; do
-        [[ -z "$line" ]] && continue
-        ((total++))
-
-        local name state health
-        name=$(echo "$line" | jq -r '.Name // .name // "unknown"' 2>/dev/null || echo "unknown")
-        state=$(echo "$line" | jq -r '.State // .state // "unknown"' 2>/dev/null || echo "unknown")
-        health=$(echo "$line" | jq -r '.Health // .health // "N/A"' 2>/dev/null || echo "N/A")
+# Report a `tailscale status --json` document
+tailscale_status_result() {
+    local label="$1" status="$2"
+    local backend_state
+    backend_state=$(echo "$status" | jq -r '.BackendState // "Unknown"' 2>/dev/null || echo "Unknown")
 
-        if [[ "$state" == "running" ]]; then
-            ((running++))
-            if [[ "$health" == "healthy" || "$health" == "N/A" ]]; then
-                log_pass "Container $name: Running ($health)"
-            else
-                log_warn "Container $name: Running but $health"
-            fi
-        else
-            log_fail "Container $name: $state"
-        fi
-    done <<< "$containers"
-
-    if [[ $running -eq $total && $total -gt 0 ]]; then
-        log_pass "All containers running ($running/$total)"
-    elif [[ $total -eq 0 ]]; then
-        log_warn "No containers defined"
-    else
-        log_warn "Some containers not running ($running/$total)"
+    if [[ "$backend_state" == "Running" ]]; then
+        local ip
+        ip=$(echo "$status" | jq -r '.Self.TailscaleIPs[0] // "N/A"' 2>/dev/null || echo "N/A")
+        log_pass "$label: Connected ($ip)"
+        return 0
     fi
+    log_fail "$label: Not connected ($backend_state)"
+    return 1
 }
 
-check_tailscale() {
+check_tailscale_host() {
     # First check for native userspace mode (systemd service)
-    if systemctl is-active tailscaled-userspace &>/dev/null; then
+    if is_native_userspace_mode; then
         local ip
         ip=$(tailscale ip -4 2>/dev/null || echo "N/A")
         log_pass "Tailscale (native userspace): Running ($ip)"
         return 0
     fi
 
-    if command -v tailscale &>/dev/null; then
-        local status
-        status=$(tailscale status --json 2>/dev/null || echo "{}")
-
-        local backend_state
-        backend_state=$(echo "$status" | jq -r '.BackendState // "Unknown"' 2>/dev/null || echo "Unknown")
+    if ! command -v tailscale &>/dev/null; then
+        log_skip "Tailscale: Not installed on host"
+        return 0
+    fi
+    tailscale_status_result "Tailscale" "$(tailscale status --json 2>/dev/null || echo "{}")"
+}
 
-        if [[ "$backend_state" == "Running" ]]; then
-            local ip
-            ip=$(echo "$status" | jq -r '.Self.TailscaleIPs[0] // "N/A"' 2>/dev/null || echo "N/A")
-            log_pass "Tailscale: Connected ($ip)"
-            return 0
-        else
-            log_fail "Tailscale: Not connected ($backend_state)"
-            return 1
-        fi
-    else
-        # Check if running in container
-        if docker ps --format '{{.Names}}' 2>/dev/null | grep -q "tailscale"; then
-            local container_status
-            container_status=$(docker inspect doom-tailscale --format '{{.State.Health.Status}}' 2>/dev/null || echo "unknown")
-            if [[ "$container_status" == "healthy" ]]; then
-                log_pass "Tailscale (container): Healthy"
-                return 0
-            else
-                log_warn "Tailscale (container): $container_status"
-                return 0
-            fi
-        fi
-        log_warn "Tailscale: Not installed on host"
+check_tailscale_sidecar() {
+    if ! docker inspect --format '{{.State.Status}}' doom-tailscale &>/dev/null; then
+        log_skip "Tailscale (container): Sidecar not present"
         return 0
     fi
+    tailscale_status_result "Tailscale (container)" \
+        "$(docker exec doom-tailscale tailscale status --json 2>/dev/null || echo "{}")"
 }
 
 check_tailscale_serve() {
     # Only check if native userspace mode is active
     if ! is_native_userspace_mode; then
+        log_skip "Tailscale Serve: Native userspace mode not active"
         return 0
     fi
 
@@ -307,6 +318,15 @@ check_code_server() {
     fi
 }
 
+check_ttyd() {
+    if (exec 3<>"/dev/tcp/localhost/$TTYD_PORT") 2>/dev/null; then
+        log_pass "ttyd: Listening on localhost:$TTYD_PORT"
+        return 0
+    fi
+    log_fail "ttyd: Nothing listening on localhost:$TTYD_PORT"
+    return 1
+}
+
 check_claude_code() {
     # Check in container first
     if docker ps --format '{{.Names}}' 2>/dev/null | grep -q "doom-claude"; then
@@ -359,37 +379,37 @@ check_terminal_tools() {
 
     # Check zsh
     if command -v zsh &>/dev/null; then
-        ((tools_found++))
+        tools_found=$((tools_found + 1))
     else
-        ((tools_missing++))
+        tools_missing=$((tools_missing + 1))
     fi
 
     # Check tmux
     if command -v tmux &>/dev/null; then
-        ((tools_found++))
+        tools_found=$((tools_found + 1))
     else
-        ((tools_missing++))
+        tools_missing=$((tools_missing + 1))
     fi
 
     # Check Oh My Zsh
     if [[ -d "$HOME/.oh-my-zsh" ]]; then
-        ((tools_found++))
+        tools_found=$((tools_found + 1))
     else
-        ((tools_missing++))
+        tools_missing=$((tools_missing + 1))
     fi
 
     # Check NVM
     if [[ -d "${NVM_DIR:-$HOME/.nvm}" ]]; then
-        ((tools_found++))
+        tools_found=$((tools_found + 1))
     else
-        ((tools_missing++))
+        tools_missing=$((tools_missing + 1))
     fi
 
     # Check pyenv
     if [[ -d "${PYENV_ROOT:-$HOME/.pyenv}" ]]; then
-        ((tools_found++))
+        tools_found=$((tools_found + 1))
     else
-        ((tools_missing++))
+        tools_missing=$((tools_missing + 1))
     fi
 
     if [[ $tools_missing -eq 0 ]]; then
@@ -402,26 +422,18 @@ check_terminal_tools() {
 }
 
 check_secrets() {
-    # Check SOPS
-    if command -v sops &>/dev/null; then
-        log_pass "SOPS: Installed"
-    else
-        log_warn "SOPS: Not installed"
-    fi
+    local missing=()
 
-    # Check age
-    if command -v age &>/dev/null; then
-        log_pass "age: Installed"
-    else
-        log_warn "age: Not installed"
-    fi
+    command -v sops &>/dev/null || missing+=("sops")
+    command -v age &>/dev/null || missing+=("age")
+    [[ -f "$HOME/.config/sops/age/keys.txt" ]] || missing+=("encryption key")
 
-    # Check key file
-    local key_file="$HOME/.config/sops/age/keys.txt"
-    if [[ -f "$key_file" ]]; then
-        log_pass "Encryption key: Present"
+    if [[ ${#missing[@]} -eq 0 ]]; then
+        log_pass "Secrets: SOPS, age and key present"
     else
-        log_warn "Encryption key: Not found"
+        local list
+        list=$(printf ', %s' "${missing[@]}")
+        log_warn "Secrets: Missing: ${list:2}"
     fi
 }
 
@@ -429,14 +441,14 @@ check_disk_space() {
     local available
     available=$(df -BG "$PROJECT_DIR" 2>/dev/null | awk 'NR==2 {print $4}' | tr -d 'G')
 
-    if [[ -n "$available" ]]; then
-        if [[ "$available" -gt 10 ]]; then
-            log_pass "Disk Space: ${available}GB available"
-        elif [[ "$available" -gt 5 ]]; then
-            log_warn "Disk Space: ${available}GB available (low)"
-        else
-            log_fail "Disk Space: ${available}GB available (critical)"
-        fi
+    if [[ -z "$available" ]]; then
+        log_skip "Disk Space: Could not query"
+    elif [[ "$available" -gt 10 ]]; then
+        log_pass "Disk Space: ${available}GB available"
+    elif [[ "$available" -gt 5 ]]; then
+        log_warn "Disk Space: ${available}GB available (low)"
+    else
+        log_fail "Disk Space: ${available}GB available (critical)"
     fi
 }
 
@@ -473,16 +485,37 @@ print_summary_human() {
 }
 
 print_summary_json() {
+    local healthy="false"
+    [[ $FAILED -eq 0 ]] && healthy="true"
+
+    local checks="" i
+    for i in "${!CHECK_ENTRIES[@]}"; do
+        [[ $i -gt 0 ]] && checks+=","
+        checks+=$'\n'"        ${CHECK_ENTRIES[$i]}"
+    done
+    [[ -n "$checks" ]] && checks+=$'\n'"    "
+
     cat << EOF
 {
+    "schema_version": $SCHEMA_VERSION,
+    "generated_at": "$(date -u +%Y-%m-%dT%H:%M:%SZ)",
+    "source": "health-check.sh",
+    "healthy": $healthy,
     "passed": $PASSED,
     "failed": $FAILED,
     "warnings": $WARNINGS,
-    "healthy": $([ $FAILED -eq 0 ] && echo "true" || echo "false")
+    "checks": [${checks}]
 }
 EOF
 }
 
+# Run a check function with its arguments, attributing its result to the
+# given check name
+run_check() {
+    CURRENT_CHECK="$1"
+    "${@:2}" || true
+}
+
 # ===========================================
 # MAIN
 # ===========================================
@@ -502,7 +535,7 @@ main() {
                 echo "Usage: $0 [--json] [--qr]"
                 echo ""
                 echo "Options:"
-                echo "  --json    Output in JSON format"
+                echo "  --json    Output in JSON format (schema version $SCHEMA_VERSION)"
                 echo "  --qr      Show access QR code after health check"
                 echo "  --help    Show this help"
                 exit 0
@@ -520,17 +553,22 @@ main() {
     fi
 
     # Run all checks
-    check_docker
-    check_docker_compose
-    check_containers
-    check_tailscale
-    check_tailscale_serve
-    check_code_server
-    check_claude_code
-    check_ssh_hardening
-    check_terminal_tools
-    check_secrets
-    check_disk_space
+    run_check docker check_docker
+    run_check docker_compose check_docker_compose
+    local container
+    for container in "${DOOM_CONTAINERS[@]}"; do
+        run_check "container:$container" check_container "$container"
+    done
+    run_check tailscale_host check_tailscale_host
+    run_check tailscale_sidecar check_tailscale_sidecar
+    run_check tailscale_serve check_tailscale_serve
+    run_check code_server check_code_server
+    run_check ttyd check_ttyd
+    run_check claude_code check_claude_code
+    run_check ssh_hardening check_ssh_hardening
+    run_check terminal_tools check_terminal_tools
+    run_check secrets check_secrets
+    run_check disk_space check_disk_space
 
     # Print summary
     if [[ "$OUTPUT_FORMAT" == "human" ]]; then
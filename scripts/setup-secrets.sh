@@ -260,6 +260,15 @@ export_secrets() {
         log_success "Exported: code_server_password.txt"
     fi
 
+    local sudo_pass
+    sudo_pass=$(sops --decrypt --extract '["code_server"]["sudo_password"]' "$secrets_file" 2>/dev/null || echo "")
+
+    if [[ -n "$sudo_pass" ]]; then
+        echo "$sudo_pass" > "$SECRETS_DIR/sudo_password.txt"
+        chmod 600 "$SECRETS_DIR/sudo_password.txt"
+        log_success "Exported: sudo_password.txt"
+    fi
+
     log_success "Secrets exported for Docker"
 }
 
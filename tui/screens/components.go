@@ -6,6 +6,7 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/doom-coding/doom-coding/internal/config"
 )
 
 // Component represents an installable component
@@ -271,6 +272,45 @@ func (s ComponentScreen) IsSelected(id string) bool {
 	return false
 }
 
+// Load selects the enabled components chosen in cfg. Required components
+// stay selected.
+func (s *ComponentScreen) Load(cfg *config.Config) {
+	for i := range s.Components {
+		if s.Components[i].Required || !s.Components[i].Enabled {
+			continue
+		}
+		if selected := componentSelected(cfg, s.Components[i].ID); selected != nil {
+			s.Components[i].Selected = *selected
+		}
+	}
+}
+
+// Apply stores the component selection in cfg
+func (s ComponentScreen) Apply(cfg *config.Config) {
+	for _, comp := range s.Components {
+		if selected := componentSelected(cfg, comp.ID); selected != nil {
+			*selected = comp.Selected
+		}
+	}
+}
+
+// componentSelected returns the config setting for a component ID
+func componentSelected(cfg *config.Config, id string) *bool {
+	switch id {
+	case "docker":
+		return &cfg.Components.Docker
+	case "tailscale":
+		return &cfg.Components.Tailscale
+	case "terminal":
+		return &cfg.Components.TerminalTools
+	case "hardening":
+		return &cfg.Components.SSHHardening
+	case "secrets":
+		return &cfg.Components.SecretsManager
+	}
+	return nil
+}
+
 // UpdateForMode updates component availability based on deployment mode
 func (s *ComponentScreen) UpdateForMode(mode DeploymentMode) {
 	s.Mode = mode
@@ -1,12 +1,12 @@
 package screens
 
 import (
-	"fmt"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/doom-coding/doom-coding/internal/config"
 )
 
 // ConfigField represents a configuration input field
@@ -30,6 +30,10 @@ type ConfigScreen struct {
 	FocusIndex int
 	Errors     map[int]string
 	Mode       DeploymentMode
+	// ConfigErrors holds the problems found by the last validation of the
+	// whole configuration. Problems of a field on this screen are shown
+	// below that field, the others below the form.
+	ConfigErrors config.ValidationErrors
 }
 
 // NewConfigScreen creates a new configuration screen
@@ -164,7 +168,8 @@ func (s ConfigScreen) Update(msg tea.Msg) (ConfigScreen, tea.Cmd, ScreenAction)
 			return s, nil, ActionNone
 		case "esc":
 			return s, nil, ActionBack
-		case "q", "ctrl+c":
+		case "ctrl+c":
+			// Only ctrl+c quits, "q" is a valid character in every field
 			return s, tea.Quit, ActionQuit
 		case "ctrl+v":
 			// Toggle password visibility
@@ -270,7 +275,6 @@ func (s ConfigScreen) View() string {
 	forestGreen := lipgloss.Color("#2E521D")
 	tanBrown := lipgloss.Color("#7C5E46")
 	lightGreen := lipgloss.Color("#4A7C34")
-	white := lipgloss.Color("#FFFFFF")
 	gray := lipgloss.Color("#888888")
 	darkGray := lipgloss.Color("#666666")
 	red := lipgloss.Color("#FF6B6B")
@@ -349,12 +353,23 @@ func (s ConfigScreen) View() string {
 			sb.WriteString("  " + errorStyle.Render("⚠ "+err))
 			sb.WriteString("\n")
 		}
+		for _, problem := range s.ConfigErrors.ForField(configFieldPath(field.Key)) {
+			sb.WriteString("  " + problemStyle(problem).Render("⚠ "+problem.Message))
+			sb.WriteString("\n")
+		}
 
 		// Help
 		sb.WriteString(helpStyle.Render(field.Help))
 		sb.WriteString("\n\n")
 	}
 
+	for _, problem := range s.ConfigErrors {
+		if s.fieldIndex(problem.Field) < 0 {
+			sb.WriteString("  " + problemStyle(problem).Render("⚠ "+problem.Error()))
+			sb.WriteString("\n")
+		}
+	}
+
 	// Notes
 	if s.Mode == ModeDockerTailscale {
 		sb.WriteString(noteStyle.Render("💡 Tip: Press Ctrl+V to toggle password visibility"))
@@ -376,6 +391,94 @@ func (s ConfigScreen) GetValue(key string) string {
 	return ""
 }
 
+// Load fills the fields from cfg
+func (s *ConfigScreen) Load(cfg *config.Config) {
+	for i := range s.Fields {
+		if value := configValue(cfg, s.Fields[i].Key); value != nil {
+			s.Fields[i].Value = *value
+			s.Fields[i].input.SetValue(*value)
+		}
+	}
+}
+
+// Apply stores the values of the enabled fields in cfg
+func (s ConfigScreen) Apply(cfg *config.Config) {
+	for _, field := range s.Fields {
+		if !field.Enabled {
+			continue
+		}
+		if value := configValue(cfg, field.Key); value != nil {
+			*value = field.input.Value()
+		}
+	}
+}
+
+// SetValidation stores the result of config.Validate and moves the focus
+// to the first field with an error
+func (s *ConfigScreen) SetValidation(problems config.ValidationErrors) {
+	s.ConfigErrors = problems
+	for _, problem := range problems.Errors() {
+		if i := s.fieldIndex(problem.Field); i >= 0 {
+			s.Fields[s.FocusIndex].input.Blur()
+			s.FocusIndex = i
+			s.Fields[i].input.Focus()
+			return
+		}
+	}
+}
+
+// fieldIndex returns the index of the enabled field editing the config
+// field path, or -1 when it is not on this screen
+func (s ConfigScreen) fieldIndex(path string) int {
+	for i, field := range s.Fields {
+		if field.Enabled && configFieldPath(field.Key) == path {
+			return i
+		}
+	}
+	return -1
+}
+
+// problemStyle renders errors in red and warnings in amber
+func problemStyle(problem config.ValidationError) lipgloss.Style {
+	if problem.Severity == config.SeverityWarning {
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("#E0A040"))
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("#FF6B6B"))
+}
+
+// configFieldPath returns the config.ValidationError field path of the
+// field with the given key
+func configFieldPath(key string) string {
+	switch key {
+	case "tailscale_key", "code_password", "sudo_password", "anthropic_key":
+		return "credentials." + key
+	case "timezone":
+		return "environment.timezone"
+	case "workspace":
+		return "environment.workspace_path"
+	}
+	return ""
+}
+
+// configValue returns the config setting edited by the field with the given key
+func configValue(cfg *config.Config, key string) *string {
+	switch key {
+	case "tailscale_key":
+		return &cfg.Credentials.TailscaleKey
+	case "code_password":
+		return &cfg.Credentials.CodePassword
+	case "sudo_password":
+		return &cfg.Credentials.SudoPassword
+	case "anthropic_key":
+		return &cfg.Credentials.AnthropicKey
+	case "timezone":
+		return &cfg.Environment.Timezone
+	case "workspace":
+		return &cfg.Environment.WorkspacePath
+	}
+	return nil
+}
+
 // GetAllValues returns all field values
 func (s ConfigScreen) GetAllValues() map[string]string {
 	values := make(map[string]string)
@@ -0,0 +1,98 @@
+package screens
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/doom-coding/doom-coding/internal/config"
+)
+
+func TestConfigScreenLoadApply(t *testing.T) {
+	cfg := config.NewDefaultConfig()
+	cfg.Credentials.CodePassword = "code-secret"
+	cfg.Environment.Timezone = "Asia/Tokyo"
+
+	s := NewConfigScreen(ModeDockerLocal, false)
+	s.Load(cfg)
+	if s.GetValue("code_password") != "code-secret" || s.GetValue("timezone") != "Asia/Tokyo" {
+		t.Fatalf("Load should fill the fields, got %v", s.GetAllValues())
+	}
+
+	s.Fields[2].input.SetValue("sudo-secret")
+	s.Fields[0].input.SetValue("tskey-ignored") // Disabled in local mode
+
+	out := config.NewDefaultConfig()
+	s.Apply(out)
+	if out.Credentials.SudoPassword != "sudo-secret" || out.Credentials.CodePassword != "code-secret" {
+		t.Errorf("Apply should store the field values, got %+v", out.Credentials)
+	}
+	if out.Credentials.TailscaleKey != "" {
+		t.Error("Apply should skip disabled fields")
+	}
+}
+
+func TestConfigScreenValidation(t *testing.T) {
+	cfg := config.NewDefaultConfig()
+	cfg.DeploymentMode = config.ModeLocal
+	cfg.Components.Tailscale = false
+	cfg.Credentials.CodePassword = "Correct-Horse-9"
+	cfg.Credentials.SudoPassword = "changeme"
+	cfg.Environment.Timezone = "Berlin"
+	cfg.Advanced.CodeServerPort = 7681
+
+	s := NewConfigScreen(ModeDockerLocal, false)
+	s.SetValidation(cfg.Validate())
+
+	if s.Fields[s.FocusIndex].Key != "timezone" {
+		t.Errorf("Focus should move to the field with the error, got %s", s.Fields[s.FocusIndex].Key)
+	}
+	view := s.View()
+	for _, want := range []string{
+		"sudo password is weak",
+		`"Berlin" is not an IANA timezone`,
+		"advanced.code_server_port: port 7681 is used by ttyd",
+	} {
+		if !strings.Contains(view, want) {
+			t.Errorf("View should contain %q:\n%s", want, view)
+		}
+	}
+}
+
+func TestComponentScreenApply(t *testing.T) {
+	s := NewComponentScreen(ModeTerminalOnly)
+	s.Cursor = 4
+	s.toggle() // Deselect secrets
+
+	cfg := config.NewDefaultConfig()
+	s.Apply(cfg)
+	want := config.ComponentSelection{TerminalTools: true, SSHHardening: true}
+	if cfg.Components != want {
+		t.Errorf("Expected %+v, got %+v", want, cfg.Components)
+	}
+}
+
+func TestPreviewUsesConfig(t *testing.T) {
+	cfg := config.NewDefaultConfig()
+	cfg.DeploymentMode = config.ModeNativeTailscale
+	cfg.Credentials.CodePassword = "code-secret"
+
+	s := NewPreviewScreen(cfg)
+	if env, _ := cfg.Redacted().GenerateEnvFile(); s.EnvPreview != env {
+		t.Error("Preview should show the .env file generated by the config")
+	}
+	if strings.Contains(s.EnvPreview, "code-secret") || strings.Contains(s.BashCommand, "code-secret") {
+		t.Errorf("Preview should not show credentials:\n%s\n%s", s.EnvPreview, s.BashCommand)
+	}
+	if !strings.Contains(s.BashCommand, "--secrets-file=<CODE_PASSWORD>") {
+		t.Errorf("Command should name the credentials in the secrets file, got %q", s.BashCommand)
+	}
+	if !strings.Contains(s.BashCommand, "--native-tailscale") {
+		t.Errorf("Command should come from the config flags, got %q", s.BashCommand)
+	}
+	if strings.Contains(s.BashCommand, "code-secret") {
+		t.Errorf("Command should mask credentials, got %q", s.BashCommand)
+	}
+	if !strings.Contains(s.View(), "Docker + Host Tailscale") {
+		t.Error("Preview should name the native Tailscale mode")
+	}
+}
@@ -6,32 +6,23 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/doom-coding/doom-coding/internal/config"
 )
 
-// DeploymentMode represents the deployment type
-type DeploymentMode int
+// DeploymentMode represents the deployment type. Its values are the
+// config.Config deployment modes.
+type DeploymentMode string
 
 const (
-	ModeDockerTailscale DeploymentMode = iota
-	ModeDockerLocal
-	ModeNativeTailscale
-	ModeTerminalOnly
+	ModeDockerTailscale DeploymentMode = config.ModeTailscale
+	ModeDockerLocal     DeploymentMode = config.ModeLocal
+	ModeNativeTailscale DeploymentMode = config.ModeNativeTailscale
+	ModeTerminalOnly    DeploymentMode = config.ModeTerminalOnly
 )
 
 // String returns the string representation of the mode
 func (m DeploymentMode) String() string {
-	switch m {
-	case ModeDockerTailscale:
-		return "tailscale"
-	case ModeDockerLocal:
-		return "local"
-	case ModeNativeTailscale:
-		return "native-tailscale"
-	case ModeTerminalOnly:
-		return "terminal-only"
-	default:
-		return "unknown"
-	}
+	return string(m)
 }
 
 // DeploymentOption represents a deployment choice
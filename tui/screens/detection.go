@@ -6,41 +6,30 @@ import (
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/doom-coding/doom-coding/internal/system"
 )
 
-// SystemInfo contains detected system information
-type SystemInfo struct {
-	Hostname     string
-	Username     string
-	OS           string
-	Arch         string
-	Distribution string
-	Version      string
-	IsLXC        bool
-	IsDocker     bool
-	IsWSL        bool
-	HasTUN       bool
-	DockerExists bool
-	DockerRunning bool
-	TailscaleUp  bool
-	TailscaleIP  string
-	LocalIPs     []string
-	DiskFreeGB   float64
-	MemoryGB     float64
-}
-
 // DetectionMsg is sent when detection completes
 type DetectionMsg struct {
-	Info SystemInfo
+	Info *system.SystemInfo
 	Err  error
 }
 
+// Detect returns a command that detects the system and reports the result
+// as a DetectionMsg
+func Detect() tea.Cmd {
+	return func() tea.Msg {
+		info, err := system.DetectSystem()
+		return DetectionMsg{Info: info, Err: err}
+	}
+}
+
 // DetectionScreen shows system detection results
 type DetectionScreen struct {
 	Width     int
 	Height    int
 	Detecting bool
-	Info      SystemInfo
+	Info      *system.SystemInfo
 	Error     error
 	spinner   spinner.Model
 }
@@ -53,6 +42,7 @@ func NewDetectionScreen() DetectionScreen {
 
 	return DetectionScreen{
 		Detecting: true,
+		Info:      &system.SystemInfo{},
 		spinner:   s,
 	}
 }
@@ -91,8 +81,10 @@ func (s DetectionScreen) Update(msg tea.Msg) (DetectionScreen, tea.Cmd, ScreenAc
 
 	case DetectionMsg:
 		s.Detecting = false
-		s.Info = msg.Info
 		s.Error = msg.Err
+		if msg.Info != nil {
+			s.Info = msg.Info
+		}
 	}
 
 	return s, nil, ActionNone
@@ -184,7 +176,7 @@ func (s DetectionScreen) View() string {
 
 		// Docker
 		var dockerStatus string
-		if s.Info.DockerExists {
+		if s.Info.DockerInstalled {
 			if s.Info.DockerRunning {
 				dockerStatus = successStyle.Render("✓ Running")
 			} else {
@@ -196,7 +188,7 @@ func (s DetectionScreen) View() string {
 		lines += fmt.Sprintf("%s%s\n", labelStyle.Render("Docker:"), dockerStatus)
 
 		// Tailscale
-		if s.Info.TailscaleUp {
+		if s.Info.TailscaleRunning {
 			lines += fmt.Sprintf("%s%s\n", labelStyle.Render("Tailscale:"), successStyle.Render("✓ Connected"))
 			if s.Info.TailscaleIP != "" {
 				lines += fmt.Sprintf("%s%s\n", labelStyle.Render("Tailscale IP:"), valueStyle.Render(s.Info.TailscaleIP))
@@ -214,22 +206,22 @@ func (s DetectionScreen) View() string {
 		// Resources
 		if s.Info.DiskFreeGB > 0 {
 			diskStyle := successStyle
-			if s.Info.DiskFreeGB < 10 {
-				diskStyle = warningStyle
-			} else if s.Info.DiskFreeGB < 5 {
+			if s.Info.DiskFreeGB < 5 {
 				diskStyle = errorStyle
+			} else if s.Info.DiskFreeGB < 10 {
+				diskStyle = warningStyle
 			}
 			lines += fmt.Sprintf("%s%s\n", labelStyle.Render("Disk Free:"), diskStyle.Render(fmt.Sprintf("%.1f GB", s.Info.DiskFreeGB)))
 		}
 
-		if s.Info.MemoryGB > 0 {
+		if s.Info.MemoryTotalGB > 0 {
 			memStyle := successStyle
-			if s.Info.MemoryGB < 2 {
-				memStyle = warningStyle
-			} else if s.Info.MemoryGB < 1 {
+			if s.Info.MemoryTotalGB < 1 {
 				memStyle = errorStyle
+			} else if s.Info.MemoryTotalGB < 2 {
+				memStyle = warningStyle
 			}
-			lines += fmt.Sprintf("%s%s\n", labelStyle.Render("Total Memory:"), memStyle.Render(fmt.Sprintf("%.1f GB", s.Info.MemoryGB)))
+			lines += fmt.Sprintf("%s%s\n", labelStyle.Render("Total Memory:"), memStyle.Render(fmt.Sprintf("%.1f GB", s.Info.MemoryTotalGB)))
 		}
 
 		content = lines
@@ -255,7 +247,7 @@ func (s DetectionScreen) View() string {
 }
 
 // SetInfo sets the system info
-func (s *DetectionScreen) SetInfo(info SystemInfo) {
+func (s *DetectionScreen) SetInfo(info *system.SystemInfo) {
 	s.Info = info
 	s.Detecting = false
 }
@@ -0,0 +1,388 @@
+package screens
+
+import (
+	"context"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/doom-coding/doom-coding/internal/config"
+	"github.com/doom-coding/doom-coding/internal/health"
+	"github.com/doom-coding/doom-coding/internal/system"
+)
+
+// The pages below adapt the screens of this package to the Router. Each
+// page builds its screen from the shared State when it is entered and
+// records the choices made on it before the router moves on.
+
+// WelcomePage shows the WelcomeScreen
+type WelcomePage struct {
+	screen WelcomeScreen
+}
+
+// NewWelcomePage creates the welcome page for version
+func NewWelcomePage(version string) *WelcomePage {
+	return &WelcomePage{screen: NewWelcomeScreen(version)}
+}
+
+// Enter implements Page
+func (p *WelcomePage) Enter(state *State) tea.Cmd {
+	return p.screen.Init()
+}
+
+// Update implements Page
+func (p *WelcomePage) Update(msg tea.Msg, state *State) (tea.Cmd, ScreenAction) {
+	var cmd tea.Cmd
+	var action ScreenAction
+	p.screen, cmd, action = p.screen.Update(msg)
+	if action == ActionNext {
+		state.SkipAssessment = p.screen.SkipAssessment
+		if state.SkipAssessment {
+			state.Skill = SkillAdvanced
+			state.UseCase = UseCaseCustom
+		}
+	}
+	return cmd, action
+}
+
+// View implements Page
+func (p *WelcomePage) View() string {
+	return p.screen.View()
+}
+
+// SkillPage shows the SkillScreen
+type SkillPage struct {
+	screen SkillScreen
+}
+
+// Enter implements Page
+func (p *SkillPage) Enter(state *State) tea.Cmd {
+	p.screen = NewSkillScreen()
+	return p.screen.Init()
+}
+
+// Update implements Page
+func (p *SkillPage) Update(msg tea.Msg, state *State) (tea.Cmd, ScreenAction) {
+	var cmd tea.Cmd
+	var action ScreenAction
+	p.screen, cmd, action = p.screen.Update(msg)
+	if action == ActionNext {
+		state.Skill = p.screen.Level()
+		state.WantsMobile = p.screen.WantsMobile()
+	}
+	return cmd, action
+}
+
+// View implements Page
+func (p *SkillPage) View() string {
+	return p.screen.View()
+}
+
+// UseCasePage shows the UseCaseScreen and applies the chosen use case to
+// the configuration
+type UseCasePage struct {
+	screen UseCaseScreen
+}
+
+// Enter implements Page
+func (p *UseCasePage) Enter(state *State) tea.Cmd {
+	p.screen = NewUseCaseScreen(state.Skill, state.WantsMobile)
+	return p.screen.Init()
+}
+
+// Update implements Page
+func (p *UseCasePage) Update(msg tea.Msg, state *State) (tea.Cmd, ScreenAction) {
+	var cmd tea.Cmd
+	var action ScreenAction
+	p.screen, cmd, action = p.screen.Update(msg)
+	if action == ActionNext {
+		state.UseCase = p.screen.Selected
+		ApplyUseCase(state.Config, state.UseCase)
+	}
+	return cmd, action
+}
+
+// View implements Page
+func (p *UseCasePage) View() string {
+	return p.screen.View()
+}
+
+// DetectionPage shows the DetectionScreen. Detection runs the first time
+// the page is entered and again when the user asks for it.
+type DetectionPage struct {
+	screen DetectionScreen
+}
+
+// Enter implements Page
+func (p *DetectionPage) Enter(state *State) tea.Cmd {
+	p.screen = NewDetectionScreen()
+	if state.System != nil {
+		p.screen.SetInfo(state.System)
+		return nil
+	}
+	return tea.Batch(p.screen.Init(), Detect())
+}
+
+// Update implements Page
+func (p *DetectionPage) Update(msg tea.Msg, state *State) (tea.Cmd, ScreenAction) {
+	if msg, ok := msg.(DetectionMsg); ok && msg.Info != nil {
+		state.System = msg.Info
+	}
+
+	var cmd tea.Cmd
+	var action ScreenAction
+	p.screen, cmd, action = p.screen.Update(msg)
+	if action == ActionRefresh {
+		return tea.Batch(cmd, Detect()), ActionNone
+	}
+	return cmd, action
+}
+
+// View implements Page
+func (p *DetectionPage) View() string {
+	return p.screen.View()
+}
+
+// DeploymentPage shows the DeploymentScreen with the options the detected
+// system supports
+type DeploymentPage struct {
+	screen DeploymentScreen
+}
+
+// Enter implements Page
+func (p *DeploymentPage) Enter(state *State) tea.Cmd {
+	info := state.System
+	if info == nil {
+		info = &system.SystemInfo{}
+	}
+	p.screen = NewDeploymentScreen(info.HasTUN, info.IsLXC, info.TailscaleRunning)
+	return p.screen.Init()
+}
+
+// Update implements Page
+func (p *DeploymentPage) Update(msg tea.Msg, state *State) (tea.Cmd, ScreenAction) {
+	var cmd tea.Cmd
+	var action ScreenAction
+	p.screen, cmd, action = p.screen.Update(msg)
+	if action == ActionNext {
+		state.Config.DeploymentMode = string(p.screen.GetSelectedMode())
+	}
+	return cmd, action
+}
+
+// View implements Page
+func (p *DeploymentPage) View() string {
+	return p.screen.View()
+}
+
+// ComponentPage shows the ComponentScreen for the chosen deployment mode
+type ComponentPage struct {
+	screen ComponentScreen
+}
+
+// Enter implements Page
+func (p *ComponentPage) Enter(state *State) tea.Cmd {
+	p.screen = NewComponentScreen(DeploymentMode(state.Config.DeploymentMode))
+	p.screen.Load(state.Config)
+	return p.screen.Init()
+}
+
+// Update implements Page
+func (p *ComponentPage) Update(msg tea.Msg, state *State) (tea.Cmd, ScreenAction) {
+	var cmd tea.Cmd
+	var action ScreenAction
+	p.screen, cmd, action = p.screen.Update(msg)
+	if action == ActionNext {
+		p.screen.Apply(state.Config)
+	}
+	return cmd, action
+}
+
+// View implements Page
+func (p *ComponentPage) View() string {
+	return p.screen.View()
+}
+
+// ConfigPage shows the ConfigScreen. It only moves on once the whole
+// configuration is valid.
+type ConfigPage struct {
+	screen ConfigScreen
+}
+
+// Enter implements Page
+func (p *ConfigPage) Enter(state *State) tea.Cmd {
+	cfg := state.Config
+	p.screen = NewConfigScreen(DeploymentMode(cfg.DeploymentMode), cfg.Components.Tailscale)
+	p.screen.Load(cfg)
+	return p.screen.Init()
+}
+
+// Update implements Page
+func (p *ConfigPage) Update(msg tea.Msg, state *State) (tea.Cmd, ScreenAction) {
+	var cmd tea.Cmd
+	var action ScreenAction
+	p.screen, cmd, action = p.screen.Update(msg)
+	switch action {
+	case ActionBack:
+		// Keep what was typed when going back
+		p.screen.Apply(state.Config)
+	case ActionNext:
+		p.screen.Apply(state.Config)
+		p.screen.SetValidation(state.Config.ValidateIn(state.ProjectRoot))
+		if p.screen.ConfigErrors.HasErrors() {
+			return cmd, ActionNone
+		}
+	}
+	return cmd, action
+}
+
+// View implements Page
+func (p *ConfigPage) View() string {
+	return p.screen.View()
+}
+
+// PreviewPage shows the PreviewScreen for the configuration
+type PreviewPage struct {
+	screen PreviewScreen
+}
+
+// Enter implements Page
+func (p *PreviewPage) Enter(state *State) tea.Cmd {
+	p.screen = NewPreviewScreen(state.Config)
+	return p.screen.Init()
+}
+
+// Update implements Page
+func (p *PreviewPage) Update(msg tea.Msg, state *State) (tea.Cmd, ScreenAction) {
+	var cmd tea.Cmd
+	var action ScreenAction
+	p.screen, cmd, action = p.screen.Update(msg)
+	return cmd, action
+}
+
+// View implements Page
+func (p *PreviewPage) View() string {
+	return p.screen.View()
+}
+
+// ProgressPage shows the ProgressScreen while the installation runs.
+// Start begins the installation for the configuration; the command it
+// returns must eventually deliver an InstallDoneMsg. Cancel, when set, is
+// called when the user cancels the installation.
+type ProgressPage struct {
+	Start  func(cfg *config.Config) tea.Cmd
+	Cancel func()
+
+	screen ProgressScreen
+}
+
+// Enter implements Page
+func (p *ProgressPage) Enter(state *State) tea.Cmd {
+	p.screen = NewProgressScreen()
+	p.screen.Installing = true
+	state.InstallErr = nil
+	return tea.Batch(p.screen.Init(), p.Start(state.Config))
+}
+
+// Update implements Page
+func (p *ProgressPage) Update(msg tea.Msg, state *State) (tea.Cmd, ScreenAction) {
+	if msg, ok := msg.(InstallDoneMsg); ok && !msg.Success {
+		state.InstallErr = msg.Error
+	}
+
+	var cmd tea.Cmd
+	var action ScreenAction
+	p.screen, cmd, action = p.screen.Update(msg)
+	if action == ActionCancel {
+		if !p.screen.Cancelling && p.Cancel != nil {
+			p.screen.Cancelling = true
+			p.Cancel()
+		}
+		return cmd, ActionNone
+	}
+	return cmd, action
+}
+
+// View implements Page
+func (p *ProgressPage) View() string {
+	return p.screen.View()
+}
+
+// HealthCheckMsg carries the health checks run after an installation
+type HealthCheckMsg struct {
+	Checks []HealthResult
+}
+
+// CheckHealth returns a command that runs checker and reports the result
+// as a HealthCheckMsg
+func CheckHealth(checker *health.Checker) tea.Cmd {
+	return func() tea.Msg {
+		return HealthCheckMsg{Checks: HealthResults(checker.Run(context.Background()).Document())}
+	}
+}
+
+// HealthResults converts a versioned health document, from a Checker or
+// decoded from health-check.sh --json, into the checks shown on the results
+// screen. Checks that do not apply are left out.
+func HealthResults(doc *health.Document) []HealthResult {
+	var checks []HealthResult
+	for _, check := range doc.Checks {
+		if check.Status == health.StatusSkip {
+			continue
+		}
+		message := check.Message
+		if check.Error != "" {
+			message = check.Error
+		}
+		checks = append(checks, HealthResult{
+			Name:    check.Name,
+			Status:  check.Status != health.StatusFail,
+			Message: message,
+		})
+	}
+	return checks
+}
+
+// ResultsPage shows the ResultsScreen. After a successful installation
+// it runs Checker, when set, to fill in the health checks.
+type ResultsPage struct {
+	Checker *health.Checker
+
+	screen ResultsScreen
+}
+
+// Enter implements Page
+func (p *ResultsPage) Enter(state *State) tea.Cmd {
+	p.screen = NewResultsScreen(state.InstallErr == nil, state.InstallErr)
+	info := state.System
+	if info == nil {
+		info = &system.SystemInfo{}
+	}
+	p.screen.SetAccessInfo(DeploymentMode(state.Config.DeploymentMode), info.TailscaleIP, info.LocalIPs)
+	if state.InstallErr != nil || p.Checker == nil {
+		return p.screen.Init()
+	}
+	return tea.Batch(p.screen.Init(), CheckHealth(p.Checker))
+}
+
+// Update implements Page
+func (p *ResultsPage) Update(msg tea.Msg, state *State) (tea.Cmd, ScreenAction) {
+	if msg, ok := msg.(HealthCheckMsg); ok {
+		p.screen.SetHealthChecks(msg.Checks)
+		return nil, ActionNone
+	}
+
+	var cmd tea.Cmd
+	var action ScreenAction
+	p.screen, cmd, action = p.screen.Update(msg)
+	if action == ActionRefresh {
+		if p.Checker != nil {
+			cmd = tea.Batch(cmd, CheckHealth(p.Checker))
+		}
+		return cmd, ActionNone
+	}
+	return cmd, action
+}
+
+// View implements Page
+func (p *ResultsPage) View() string {
+	return p.screen.View()
+}
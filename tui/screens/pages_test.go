@@ -0,0 +1,38 @@
+package screens
+
+import (
+	"testing"
+
+	"github.com/doom-coding/doom-coding/internal/health"
+)
+
+func TestHealthResultsFromScriptDocument(t *testing.T) {
+	doc, err := health.Decode([]byte(`{
+		"schema_version": 2,
+		"generated_at": "2026-01-02T03:04:05Z",
+		"source": "health-check.sh",
+		"healthy": false,
+		"passed": 1,
+		"failed": 1,
+		"warnings": 0,
+		"checks": [
+			{"name": "docker", "status": "pass", "message": "Docker running (v24.0.7)"},
+			{"name": "container:doom-tailscale", "status": "skip"},
+			{"name": "code_server", "status": "fail", "message": "Endpoint unreachable", "error": "connection refused"}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checks := HealthResults(doc)
+	if len(checks) != 2 {
+		t.Fatalf("Skipped checks should be left out, got %+v", checks)
+	}
+	if !checks[0].Status || checks[0].Message != "Docker running (v24.0.7)" {
+		t.Errorf("Unexpected docker result %+v", checks[0])
+	}
+	if checks[1].Status || checks[1].Message != "connection refused" {
+		t.Errorf("Failed checks should show their error, got %+v", checks[1])
+	}
+}
@@ -6,25 +6,28 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/doom-coding/doom-coding/internal/config"
 )
 
 // PreviewScreen shows a summary before installation
 type PreviewScreen struct {
-	Width        int
-	Height       int
-	Mode         DeploymentMode
-	Components   []string
-	Config       map[string]string
-	EnvPreview   string
-	BashCommand  string
+	Width       int
+	Height      int
+	Config      *config.Config
+	EnvPreview  string
+	BashCommand string
 }
 
-// NewPreviewScreen creates a new preview screen
-func NewPreviewScreen(mode DeploymentMode, components []string, config map[string]string) PreviewScreen {
+// NewPreviewScreen creates a new preview screen for cfg
+func NewPreviewScreen(cfg *config.Config) PreviewScreen {
+	env, err := cfg.Redacted().GenerateEnvFile()
+	if err != nil {
+		env = "# Cannot write .env: " + err.Error()
+	}
 	return PreviewScreen{
-		Mode:       mode,
-		Components: components,
-		Config:     config,
+		Config:      cfg,
+		EnvPreview:  env,
+		BashCommand: previewCommand(cfg),
 	}
 }
 
@@ -115,18 +118,21 @@ func (s PreviewScreen) View() string {
 
 	// Components
 	sb.WriteString("  Components:\n")
-	allComponents := []string{"docker", "tailscale", "terminal", "hardening", "secrets"}
-	componentNames := map[string]string{
-		"docker":    "Docker",
-		"tailscale": "Tailscale",
-		"terminal":  "Terminal Tools",
-		"hardening": "SSH Hardening",
-		"secrets":   "Secrets Management",
+	selection := s.Config.Components
+	allComponents := []struct {
+		name     string
+		selected bool
+	}{
+		{"Docker", selection.Docker},
+		{"Tailscale", selection.Tailscale},
+		{"Terminal Tools", selection.TerminalTools},
+		{"SSH Hardening", selection.SSHHardening},
+		{"Secrets Management", selection.SecretsManager},
 	}
 
 	for _, comp := range allComponents {
-		name := componentNames[comp]
-		if s.hasComponent(comp) {
+		name := comp.name
+		if comp.selected {
 			sb.WriteString(fmt.Sprintf("    %s %s\n", checkStyle.Render("✓ Install"), valueStyle.Render(name)))
 		} else {
 			sb.WriteString(fmt.Sprintf("    %s %s\n", skipStyle.Render("○ Skip"), skipStyle.Render(name)))
@@ -136,21 +142,21 @@ func (s PreviewScreen) View() string {
 	sb.WriteString("\n  Configuration:\n")
 
 	// Show non-sensitive config
-	if tz, ok := s.Config["timezone"]; ok && tz != "" {
+	if tz := s.Config.Environment.Timezone; tz != "" {
 		sb.WriteString(fmt.Sprintf("    %s%s\n", labelStyle.Render("Timezone:"), valueStyle.Render(tz)))
 	}
-	if ws, ok := s.Config["workspace"]; ok && ws != "" {
+	if ws := s.Config.Environment.WorkspacePath; ws != "" {
 		sb.WriteString(fmt.Sprintf("    %s%s\n", labelStyle.Render("Workspace:"), valueStyle.Render(ws)))
 	}
 
 	// Show configured credentials (masked)
-	if _, ok := s.Config["tailscale_key"]; ok && s.Config["tailscale_key"] != "" {
+	if s.Config.Credentials.TailscaleKey != "" {
 		sb.WriteString(fmt.Sprintf("    %s%s\n", labelStyle.Render("Tailscale Key:"), checkStyle.Render("✓ configured")))
 	}
-	if _, ok := s.Config["code_password"]; ok && s.Config["code_password"] != "" {
+	if s.Config.Credentials.CodePassword != "" {
 		sb.WriteString(fmt.Sprintf("    %s%s\n", labelStyle.Render("code-server:"), checkStyle.Render("✓ configured")))
 	}
-	if _, ok := s.Config["anthropic_key"]; ok && s.Config["anthropic_key"] != "" {
+	if s.Config.Credentials.AnthropicKey != "" {
 		sb.WriteString(fmt.Sprintf("    %s%s\n", labelStyle.Render("Anthropic API:"), checkStyle.Render("✓ configured")))
 	}
 
@@ -158,7 +164,7 @@ func (s PreviewScreen) View() string {
 	box := boxStyle.Render(content)
 
 	// Command preview
-	cmdPreview := helpStyle.Render(fmt.Sprintf("Command: scripts/install.sh --unattended %s", s.getBashFlags()))
+	cmdPreview := helpStyle.Render(fmt.Sprintf("Command: %s", s.BashCommand))
 
 	help := helpStyle.Render("[Enter/i] Install  [e] Export Config  [s] Show Command  [Esc] Back  [q] Quit")
 
@@ -172,9 +178,11 @@ func (s PreviewScreen) View() string {
 }
 
 func (s PreviewScreen) getModeString() string {
-	switch s.Mode {
+	switch DeploymentMode(s.Config.DeploymentMode) {
 	case ModeDockerTailscale:
 		return "Docker + Tailscale (VPN access)"
+	case ModeNativeTailscale:
+		return "Docker + Host Tailscale"
 	case ModeDockerLocal:
 		return "Docker + Local Network"
 	case ModeTerminalOnly:
@@ -184,35 +192,14 @@ func (s PreviewScreen) getModeString() string {
 	}
 }
 
-func (s PreviewScreen) hasComponent(id string) bool {
-	for _, comp := range s.Components {
-		if comp == id {
-			return true
-		}
-	}
-	return false
-}
-
-func (s PreviewScreen) getBashFlags() string {
-	var flags []string
-
-	if !s.hasComponent("docker") {
-		flags = append(flags, "--skip-docker")
+// previewCommand returns the install.sh command for cfg. The credentials
+// are passed in a file, so only their names are shown.
+func previewCommand(cfg *config.Config) string {
+	flags := config.RedactFlags(cfg.GenerateBashFlags())
+	if names := cfg.InstallSecrets().Names(); len(names) > 0 {
+		flags = append(flags, config.SecretsFileFlag+"=<"+strings.Join(names, ",")+">")
 	}
-	if !s.hasComponent("tailscale") || s.Mode == ModeDockerLocal {
-		flags = append(flags, "--skip-tailscale")
-	}
-	if !s.hasComponent("terminal") {
-		flags = append(flags, "--skip-terminal")
-	}
-	if !s.hasComponent("hardening") {
-		flags = append(flags, "--skip-hardening")
-	}
-	if !s.hasComponent("secrets") {
-		flags = append(flags, "--skip-secrets")
-	}
-
-	return strings.Join(flags, " ")
+	return "scripts/install.sh " + strings.Join(flags, " ")
 }
 
 // SetEnvPreview sets the .env file preview
@@ -227,35 +214,5 @@ func (s *PreviewScreen) SetBashCommand(cmd string) {
 
 // GetBashFlags returns the bash flags for install.sh
 func (s PreviewScreen) GetBashFlags() []string {
-	var flags []string
-
-	flags = append(flags, "--unattended")
-
-	if !s.hasComponent("docker") {
-		flags = append(flags, "--skip-docker")
-	}
-	if !s.hasComponent("tailscale") || s.Mode == ModeDockerLocal {
-		flags = append(flags, "--skip-tailscale")
-	}
-	if !s.hasComponent("terminal") {
-		flags = append(flags, "--skip-terminal")
-	}
-	if !s.hasComponent("hardening") {
-		flags = append(flags, "--skip-hardening")
-	}
-	if !s.hasComponent("secrets") {
-		flags = append(flags, "--skip-secrets")
-	}
-
-	if key := s.Config["tailscale_key"]; key != "" {
-		flags = append(flags, fmt.Sprintf("--tailscale-key=%s", key))
-	}
-	if pwd := s.Config["code_password"]; pwd != "" {
-		flags = append(flags, fmt.Sprintf("--code-password=%s", pwd))
-	}
-	if key := s.Config["anthropic_key"]; key != "" {
-		flags = append(flags, fmt.Sprintf("--anthropic-key=%s", key))
-	}
-
-	return flags
+	return s.Config.GenerateBashFlags()
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/doom-coding/doom-coding/internal/executor"
 )
 
 // InstallStep represents an installation step
@@ -37,6 +38,19 @@ type InstallProgressMsg struct {
 	Output string
 }
 
+// InstallEventMsg carries an event from the executor's event stream
+type InstallEventMsg struct {
+	Event executor.Event
+}
+
+// elapsedTickMsg re-renders the elapsed time of the running step
+type elapsedTickMsg time.Time
+
+// elapsedTick returns the command that delivers the next elapsedTickMsg
+func elapsedTick() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg { return elapsedTickMsg(t) })
+}
+
 // InstallDoneMsg is sent when installation completes
 type InstallDoneMsg struct {
 	Success bool
@@ -52,6 +66,7 @@ type ProgressScreen struct {
 	LogLines    []string
 	MaxLogLines int
 	Installing  bool
+	Cancelling  bool // Cancel was requested and the installer is stopping
 	Complete    bool
 	Error       error
 
@@ -87,9 +102,10 @@ func NewProgressScreen() ProgressScreen {
 	}
 }
 
-// Init initializes the screen
+// Init initializes the screen. While Installing is set, the screen ticks
+// every second to update the elapsed time of the running step.
 func (s ProgressScreen) Init() tea.Cmd {
-	return s.spinner.Tick
+	return tea.Batch(s.spinner.Tick, elapsedTick())
 }
 
 // Update handles messages
@@ -104,6 +120,11 @@ func (s ProgressScreen) Update(msg tea.Msg) (ProgressScreen, tea.Cmd, ScreenActi
 			case "q", "ctrl+c":
 				return s, tea.Quit, ActionQuit
 			}
+		} else if s.Installing {
+			switch msg.String() {
+			case "c", "ctrl+c":
+				return s, nil, ActionCancel
+			}
 		}
 
 	case tea.WindowSizeMsg:
@@ -115,28 +136,19 @@ func (s ProgressScreen) Update(msg tea.Msg) (ProgressScreen, tea.Cmd, ScreenActi
 		s.spinner, cmd = s.spinner.Update(msg)
 		return s, cmd, ActionNone
 
+	case elapsedTickMsg:
+		if s.Installing {
+			return s, elapsedTick(), ActionNone
+		}
+
 	case InstallProgressMsg:
 		s.updateProgress(msg.Step, msg.Output)
 
+	case InstallEventMsg:
+		s.applyEvent(msg.Event)
+
 	case InstallDoneMsg:
-		s.Installing = false
-		s.Complete = true
-		if !msg.Success {
-			s.Error = msg.Error
-			if s.CurrentStep < len(s.Steps) {
-				s.Steps[s.CurrentStep].Status = StatusFailed
-				s.Steps[s.CurrentStep].Error = msg.Error
-				s.Steps[s.CurrentStep].EndTime = time.Now()
-			}
-		} else {
-			// Mark remaining steps as complete
-			for i := s.CurrentStep; i < len(s.Steps); i++ {
-				if s.Steps[i].Status == StatusRunning || s.Steps[i].Status == StatusPending {
-					s.Steps[i].Status = StatusComplete
-					s.Steps[i].EndTime = time.Now()
-				}
-			}
-		}
+		s.Finish(msg.Success, msg.Error)
 	}
 
 	return s, nil, ActionNone
@@ -171,15 +183,61 @@ func (s *ProgressScreen) updateProgress(step int, output string) {
 	}
 }
 
+// applyEvent updates step states from an executor event. Steps are matched
+// by position, so the executor has to run the steps shown on this screen.
+func (s *ProgressScreen) applyEvent(ev executor.Event) {
+	switch ev := ev.(type) {
+	case executor.StepStarted:
+		if step := s.stepAt(ev.Index); step != nil {
+			s.Installing = true
+			s.CurrentStep = ev.Index
+			step.Status = StatusRunning
+			step.StartTime = ev.Time
+		}
+	case executor.StepOutput:
+		s.AddLogLine(ev.Line)
+	case executor.StepRetried:
+		s.AddLogLine(fmt.Sprintf("Retrying %s (attempt %d/%d): %v", ev.Step.Name, ev.Attempt+1, ev.MaxAttempts, ev.Err))
+	case executor.StepFinished:
+		step := s.stepAt(ev.Index)
+		if step == nil {
+			return
+		}
+		step.EndTime = ev.Time
+		if step.StartTime.IsZero() {
+			step.StartTime = ev.Time
+		}
+		switch ev.Result.Status {
+		case executor.StepSucceeded, executor.StepResumed:
+			step.Status = StatusComplete
+		case executor.StepFailed, executor.StepCancelled:
+			step.Status = StatusFailed
+			step.Error = ev.Result.Error
+		default:
+			step.Status = StatusSkipped
+		}
+	case executor.RunFinished:
+		s.Installing = false
+		s.Complete = true
+		s.Error = ev.Err
+	}
+}
+
+// stepAt returns the step at a 1-based index, or nil
+func (s *ProgressScreen) stepAt(index int) *InstallStep {
+	if index < 1 || index > len(s.Steps) {
+		return nil
+	}
+	return &s.Steps[index-1]
+}
+
 // View renders the screen
 func (s ProgressScreen) View() string {
 	forestGreen := lipgloss.Color("#2E521D")
-	tanBrown := lipgloss.Color("#7C5E46")
 	lightGreen := lipgloss.Color("#4A7C34")
 	white := lipgloss.Color("#FFFFFF")
 	gray := lipgloss.Color("#888888")
 	darkGray := lipgloss.Color("#666666")
-	green := lipgloss.Color("#69DB7C")
 	red := lipgloss.Color("#FF6B6B")
 
 	titleStyle := lipgloss.NewStyle().
@@ -189,22 +247,12 @@ func (s ProgressScreen) View() string {
 	stepStyle := lipgloss.NewStyle().
 		Foreground(white)
 
-	runningStyle := lipgloss.NewStyle().
-		Foreground(lightGreen).
-		Bold(true)
-
-	completeStyle := lipgloss.NewStyle().
-		Foreground(green)
-
 	failedStyle := lipgloss.NewStyle().
 		Foreground(red)
 
 	pendingStyle := lipgloss.NewStyle().
 		Foreground(darkGray)
 
-	skippedStyle := lipgloss.NewStyle().
-		Foreground(gray)
-
 	logStyle := lipgloss.NewStyle().
 		Foreground(gray)
 
@@ -220,6 +268,9 @@ func (s ProgressScreen) View() string {
 	sb.WriteString("\n")
 
 	title := "Installing..."
+	if s.Cancelling {
+		title = "Cancelling..."
+	}
 	if s.Complete {
 		if s.Error != nil {
 			title = "Installation Failed"
@@ -260,10 +311,12 @@ func (s ProgressScreen) View() string {
 
 		sb.WriteString(fmt.Sprintf("  %s %s", icon, style.Render(stepText)))
 
-		// Duration for completed steps
+		// Duration for finished steps, elapsed time for the running one
 		if (step.Status == StatusComplete || step.Status == StatusFailed) && !step.EndTime.IsZero() {
 			duration := step.EndTime.Sub(step.StartTime)
 			sb.WriteString(pendingStyle.Render(fmt.Sprintf(" (%.1fs)", duration.Seconds())))
+		} else if step.Status == StatusRunning && !step.StartTime.IsZero() {
+			sb.WriteString(pendingStyle.Render(fmt.Sprintf(" (%.0fs)", time.Since(step.StartTime).Seconds())))
 		}
 
 		// Error message for failed steps
@@ -296,8 +349,10 @@ func (s ProgressScreen) View() string {
 	sb.WriteString("\n")
 	if s.Complete {
 		sb.WriteString(helpStyle.Render("[Enter] Continue  [q] Quit"))
+	} else if s.Cancelling {
+		sb.WriteString(helpStyle.Render("Stopping the installer and cleaning up..."))
 	} else {
-		sb.WriteString(helpStyle.Render("Installation in progress... Please wait."))
+		sb.WriteString(helpStyle.Render("Installation in progress... [c] Cancel"))
 	}
 
 	return sb.String()
@@ -401,8 +456,9 @@ func (s *ProgressScreen) AddLogLine(line string) {
 	}
 }
 
-// Complete marks installation as complete
-func (s *ProgressScreen) Complete(success bool, err error) {
+// Finish marks installation as complete. Steps still running when the
+// installation failed are marked as failed.
+func (s *ProgressScreen) Finish(success bool, err error) {
 	s.Installing = false
 	s.Complete = true
 	s.Error = err
@@ -416,7 +472,7 @@ func (s *ProgressScreen) Complete(success bool, err error) {
 				}
 			}
 		}
-	} else if s.CurrentStep > 0 && s.CurrentStep <= len(s.Steps) {
+	} else if s.CurrentStep > 0 && s.CurrentStep <= len(s.Steps) && s.Steps[s.CurrentStep-1].Status == StatusRunning {
 		s.Steps[s.CurrentStep-1].Status = StatusFailed
 		s.Steps[s.CurrentStep-1].Error = err
 		s.Steps[s.CurrentStep-1].EndTime = time.Now()
@@ -0,0 +1,79 @@
+package screens
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/doom-coding/doom-coding/internal/executor"
+)
+
+func TestProgressInstallEvents(t *testing.T) {
+	s := NewProgressScreen()
+	now := time.Now()
+	step := &executor.Step{Name: "system_check"}
+	failure := errors.New("exit status 1")
+
+	events := []executor.Event{
+		executor.StepStarted{Step: step, Index: 1, Total: 10, Time: now},
+		executor.StepOutput{Step: step, Index: 1, Total: 10, Line: "Pre-flight checks passed"},
+		executor.StepFinished{Step: step, Index: 1, Total: 10, Time: now.Add(time.Second),
+			Result: executor.StepResult{Status: executor.StepSucceeded}},
+		executor.StepFinished{Step: &executor.Step{Name: "ssh_hardening"}, Index: 6, Total: 10,
+			Result: executor.StepResult{Status: executor.StepSkipped}},
+		executor.StepFinished{Step: &executor.Step{Name: "docker_install"}, Index: 3, Total: 10,
+			Result: executor.StepResult{Status: executor.StepFailed, Error: failure}},
+		executor.RunFinished{Err: failure},
+	}
+	for _, ev := range events {
+		s, _, _ = s.Update(InstallEventMsg{Event: ev})
+	}
+
+	if s.Steps[0].Status != StatusComplete {
+		t.Errorf("Expected step 1 complete, got %d", s.Steps[0].Status)
+	}
+	if s.Steps[5].Status != StatusSkipped {
+		t.Errorf("Expected step 6 skipped, got %d", s.Steps[5].Status)
+	}
+	if s.Steps[2].Status != StatusFailed || s.Steps[2].Error != failure {
+		t.Errorf("Expected step 3 failed with the result error, got %d %v", s.Steps[2].Status, s.Steps[2].Error)
+	}
+	if len(s.LogLines) != 1 || s.LogLines[0] != "Pre-flight checks passed" {
+		t.Errorf("Expected output in the log, got %v", s.LogLines)
+	}
+	if !s.Complete || s.Installing || s.Error != failure {
+		t.Error("RunFinished should complete the screen with the run error")
+	}
+}
+
+func TestProgressIgnoresUnknownIndex(t *testing.T) {
+	s := NewProgressScreen()
+	s, _, _ = s.Update(InstallEventMsg{Event: executor.StepStarted{Step: &executor.Step{Name: "extra"}, Index: 42}})
+
+	for i, step := range s.Steps {
+		if step.Status != StatusPending {
+			t.Errorf("Step %d should still be pending, got %d", i+1, step.Status)
+		}
+	}
+}
+
+func TestProgressElapsedTime(t *testing.T) {
+	s := NewProgressScreen()
+	s.Installing = true
+	step := &executor.Step{Name: "docker_install"}
+	s, _, _ = s.Update(InstallEventMsg{Event: executor.StepStarted{Step: step, Index: 3, Total: 10, Time: time.Now().Add(-42 * time.Second)}})
+
+	if view := s.View(); !strings.Contains(view, "(42s)") {
+		t.Errorf("The running step should show its elapsed time:\n%s", view)
+	}
+
+	s, cmd, _ := s.Update(elapsedTickMsg(time.Now()))
+	if cmd == nil {
+		t.Error("The elapsed time should keep ticking while installing")
+	}
+	s, _, _ = s.Update(InstallEventMsg{Event: executor.RunFinished{}})
+	if _, cmd, _ := s.Update(elapsedTickMsg(time.Now())); cmd != nil {
+		t.Error("The elapsed time should stop ticking once the installation finished")
+	}
+}
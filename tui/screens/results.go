@@ -6,6 +6,7 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/doom-coding/doom-coding/internal/qr"
 )
 
 // HealthResult represents a health check result
@@ -146,7 +147,7 @@ func (s ResultsScreen) View() string {
 			}
 			sb.WriteString(fmt.Sprintf("  %s %s", icon, valueStyle.Render(check.Name)))
 			if check.Message != "" {
-				sb.WriteString(fmt.Sprintf(" - %s", gray.Render(check.Message)))
+				sb.WriteString(fmt.Sprintf(" - %s", lipgloss.NewStyle().Foreground(gray).Render(check.Message)))
 			}
 			sb.WriteString("\n")
 		}
@@ -157,7 +158,7 @@ func (s ResultsScreen) View() string {
 		sb.WriteString("\n")
 
 		switch s.Mode {
-		case ModeDockerTailscale:
+		case ModeDockerTailscale, ModeNativeTailscale:
 			if s.TailscaleIP != "" {
 				sb.WriteString(fmt.Sprintf("  code-server: %s\n", linkStyle.Render(fmt.Sprintf("https://%s:8443", s.TailscaleIP))))
 			} else {
@@ -177,6 +178,9 @@ func (s ResultsScreen) View() string {
 			sb.WriteString(valueStyle.Render("  • Start a new shell or run 'source ~/.zshrc'"))
 			sb.WriteString("\n")
 		}
+		if url := s.accessURL(); url != "" {
+			sb.WriteString(qr.FormatWithLabel(qr.NewDefaultGenerator().GenerateCompact(url), "Scan to open code-server on your phone"))
+		}
 		sb.WriteString("\n")
 
 		// Next steps
@@ -184,7 +188,7 @@ func (s ResultsScreen) View() string {
 		sb.WriteString("\n")
 
 		switch s.Mode {
-		case ModeDockerTailscale, ModeDockerLocal:
+		case ModeDockerTailscale, ModeNativeTailscale, ModeDockerLocal:
 			sb.WriteString(valueStyle.Render("  1. Open code-server in your browser"))
 			sb.WriteString("\n")
 			sb.WriteString(valueStyle.Render("  2. Enter your code-server password"))
@@ -233,6 +237,22 @@ func (s ResultsScreen) View() string {
 	)
 }
 
+// accessURL returns the code-server URL to show as a QR code, or "" when
+// the address is not known
+func (s ResultsScreen) accessURL() string {
+	switch s.Mode {
+	case ModeDockerTailscale, ModeNativeTailscale:
+		if s.TailscaleIP != "" {
+			return fmt.Sprintf("https://%s:8443", s.TailscaleIP)
+		}
+	case ModeDockerLocal:
+		if len(s.LocalIPs) > 0 {
+			return fmt.Sprintf("https://%s:8443", s.LocalIPs[0])
+		}
+	}
+	return ""
+}
+
 func (s ResultsScreen) getWarnings() []string {
 	var warnings []string
 
@@ -0,0 +1,132 @@
+package screens
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/doom-coding/doom-coding/internal/config"
+	"github.com/doom-coding/doom-coding/internal/system"
+)
+
+// State is shared by the pages of a Router. Pages read the choices made on
+// earlier screens from it and record their own.
+type State struct {
+	Config      *config.Config
+	System      *system.SystemInfo // Nil until detection has finished
+	ProjectRoot string             // Relative workspace paths are checked against it
+
+	Skill          SkillLevel
+	WantsMobile    bool // Answered that mobile access is wanted
+	UseCase        UseCase
+	SkipAssessment bool // Skill questions and use case were skipped
+
+	InstallErr error // Result of the installation once it has finished
+}
+
+// NewState returns the state for a new session editing cfg
+func NewState(cfg *config.Config) *State {
+	return &State{Config: cfg, UseCase: UseCaseCustom}
+}
+
+// Page is a screen shown by a Router
+type Page interface {
+	// Enter is called each time the page becomes the active page, so the
+	// page can build its screen from the current state
+	Enter(state *State) tea.Cmd
+	// Update handles a message and returns the navigation action to take
+	Update(msg tea.Msg, state *State) (tea.Cmd, ScreenAction)
+	View() string
+}
+
+// Route is an entry in the page flow of a Router
+type Route struct {
+	Name string
+	Page Page
+	// Skip leaves the page out of the flow for the current state. Pages
+	// without Skip are always shown.
+	Skip func(state *State) bool
+}
+
+// Router shows one page at a time and moves between pages on the actions
+// they return. ActionNext and ActionSubmit go to the next route that is
+// not skipped, ActionBack returns to the previously shown page and
+// ActionQuit quits the program.
+type Router struct {
+	State *State
+
+	routes  []Route
+	current int
+	history []int              // Indexes of the pages shown before the current one
+	size    *tea.WindowSizeMsg // Last window size, passed to pages on enter
+}
+
+// NewRouter creates a router that starts at the first route
+func NewRouter(state *State, routes ...Route) *Router {
+	return &Router{State: state, routes: routes}
+}
+
+// Init enters the first page
+func (r *Router) Init() tea.Cmd {
+	return r.enter(r.current)
+}
+
+// Update passes msg to the current page and follows the action it returns
+func (r *Router) Update(msg tea.Msg) tea.Cmd {
+	if size, ok := msg.(tea.WindowSizeMsg); ok {
+		r.size = &size
+	}
+
+	cmd, action := r.routes[r.current].Page.Update(msg, r.State)
+	switch action {
+	case ActionNext, ActionSubmit:
+		return tea.Batch(cmd, r.Next())
+	case ActionBack:
+		return tea.Batch(cmd, r.Back())
+	case ActionQuit:
+		return tea.Quit
+	}
+	return cmd
+}
+
+// View renders the current page
+func (r *Router) View() string {
+	return r.routes[r.current].Page.View()
+}
+
+// Current returns the name of the current route
+func (r *Router) Current() string {
+	return r.routes[r.current].Name
+}
+
+// Next moves to the next route that is not skipped. It stays on the
+// current page when there is none.
+func (r *Router) Next() tea.Cmd {
+	for i := r.current + 1; i < len(r.routes); i++ {
+		if skip := r.routes[i].Skip; skip != nil && skip(r.State) {
+			continue
+		}
+		r.history = append(r.history, r.current)
+		return r.enter(i)
+	}
+	return nil
+}
+
+// Back returns to the page shown before the current one
+func (r *Router) Back() tea.Cmd {
+	if len(r.history) == 0 {
+		return nil
+	}
+	prev := r.history[len(r.history)-1]
+	r.history = r.history[:len(r.history)-1]
+	return r.enter(prev)
+}
+
+func (r *Router) enter(index int) tea.Cmd {
+	r.current = index
+	page := r.routes[index].Page
+	cmd := page.Enter(r.State)
+	if r.size != nil {
+		// Pages rebuild their screen on enter, so pass the size again
+		sizeCmd, _ := page.Update(*r.size, r.State)
+		cmd = tea.Batch(cmd, sizeCmd)
+	}
+	return cmd
+}
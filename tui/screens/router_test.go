@@ -0,0 +1,113 @@
+package screens
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/doom-coding/doom-coding/internal/config"
+)
+
+// actionMsg makes a fakePage return its action
+type actionMsg ScreenAction
+
+// fakePage returns the action carried by an actionMsg and counts enters
+type fakePage struct {
+	name    string
+	entered int
+	width   int
+}
+
+func (p *fakePage) Enter(state *State) tea.Cmd {
+	p.entered++
+	return nil
+}
+
+func (p *fakePage) Update(msg tea.Msg, state *State) (tea.Cmd, ScreenAction) {
+	switch msg := msg.(type) {
+	case actionMsg:
+		return nil, ScreenAction(msg)
+	case tea.WindowSizeMsg:
+		p.width = msg.Width
+	}
+	return nil, ActionNone
+}
+
+func (p *fakePage) View() string {
+	return p.name
+}
+
+func newTestRouter(skipB bool) (*Router, map[string]*fakePage) {
+	pages := map[string]*fakePage{}
+	var routes []Route
+	for _, name := range []string{"a", "b", "c"} {
+		pages[name] = &fakePage{name: name}
+		routes = append(routes, Route{Name: name, Page: pages[name]})
+	}
+	routes[1].Skip = func(state *State) bool { return skipB }
+
+	r := NewRouter(NewState(config.NewDefaultConfig()), routes...)
+	r.Init()
+	return r, pages
+}
+
+func TestRouterNextAndBack(t *testing.T) {
+	r, pages := newTestRouter(false)
+	if r.Current() != "a" || pages["a"].entered != 1 {
+		t.Fatal("Init should enter the first page")
+	}
+
+	r.Update(actionMsg(ActionNext))
+	r.Update(actionMsg(ActionSubmit))
+	if r.Current() != "c" || r.View() != "c" {
+		t.Fatalf("Expected page c, got %s", r.Current())
+	}
+
+	r.Update(actionMsg(ActionBack))
+	if r.Current() != "b" || pages["b"].entered != 2 {
+		t.Errorf("Back should enter page b again, got %s", r.Current())
+	}
+	r.Update(actionMsg(ActionBack))
+	r.Update(actionMsg(ActionBack))
+	if r.Current() != "a" {
+		t.Errorf("Back on the first page should stay there, got %s", r.Current())
+	}
+}
+
+func TestRouterSkipsRoutes(t *testing.T) {
+	r, pages := newTestRouter(true)
+
+	r.Update(actionMsg(ActionNext))
+	if r.Current() != "c" || pages["b"].entered != 0 {
+		t.Fatalf("Skipped page should not be entered, got %s", r.Current())
+	}
+	r.Update(actionMsg(ActionNext))
+	if r.Current() != "c" {
+		t.Error("Next on the last page should stay there")
+	}
+
+	r.Update(actionMsg(ActionBack))
+	if r.Current() != "a" {
+		t.Errorf("Back should return to the page shown before, got %s", r.Current())
+	}
+}
+
+func TestRouterPassesSizeOnEnter(t *testing.T) {
+	r, pages := newTestRouter(false)
+	r.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	r.Update(actionMsg(ActionNext))
+
+	if pages["b"].width != 120 {
+		t.Errorf("Entered page should get the window size, got %d", pages["b"].width)
+	}
+}
+
+func TestRouterQuit(t *testing.T) {
+	r, _ := newTestRouter(false)
+	cmd := r.Update(actionMsg(ActionQuit))
+	if cmd == nil {
+		t.Fatal("Quit should return a command")
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Error("Quit should return tea.Quit")
+	}
+}
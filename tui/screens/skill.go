@@ -0,0 +1,197 @@
+package screens
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// SkillLevel represents the user's technical expertise
+type SkillLevel int
+
+const (
+	SkillBeginner SkillLevel = iota
+	SkillIntermediate
+	SkillAdvanced
+)
+
+// SkillQuestion is a skill assessment question. Options are ordered from
+// the beginner answer to the advanced one.
+type SkillQuestion struct {
+	Question string
+	Options  []string
+}
+
+// SkillScreen asks a few questions to tailor the setup to the user
+type SkillScreen struct {
+	Width     int
+	Height    int
+	Questions []SkillQuestion
+	Answers   []int
+	Index     int // Current question
+	Cursor    int
+}
+
+// NewSkillScreen creates a new skill assessment screen
+func NewSkillScreen() SkillScreen {
+	questions := []SkillQuestion{
+		{
+			Question: "How comfortable are you with Docker and containers?",
+			Options:  []string{"Never used them", "Some experience", "Very comfortable"},
+		},
+		{
+			Question: "Do you want to access this from mobile devices?",
+			Options:  []string{"Yes, definitely", "Maybe later", "No, desktop only"},
+		},
+		{
+			Question: "Preferred setup style?",
+			Options:  []string{"Quick & simple", "Guided with explanations", "Full control"},
+		},
+	}
+
+	return SkillScreen{
+		Questions: questions,
+		Answers:   make([]int, len(questions)),
+	}
+}
+
+// Init initializes the screen
+func (s SkillScreen) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles input
+func (s SkillScreen) Update(msg tea.Msg) (SkillScreen, tea.Cmd, ScreenAction) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			if s.Cursor > 0 {
+				s.Cursor--
+			}
+		case "down", "j":
+			if s.Cursor < len(s.Questions[s.Index].Options)-1 {
+				s.Cursor++
+			}
+		case "enter", " ":
+			s.Answers[s.Index] = s.Cursor
+			if s.Index == len(s.Questions)-1 {
+				return s, nil, ActionNext
+			}
+			s.Index++
+			s.Cursor = s.Answers[s.Index]
+		case "esc":
+			if s.Index == 0 {
+				return s, nil, ActionBack
+			}
+			s.Index--
+			s.Cursor = s.Answers[s.Index]
+		case "q", "ctrl+c":
+			return s, tea.Quit, ActionQuit
+		}
+	case tea.WindowSizeMsg:
+		s.Width = msg.Width
+		s.Height = msg.Height
+	}
+	return s, nil, ActionNone
+}
+
+// Level returns the skill level for the given answers
+func (s SkillScreen) Level() SkillLevel {
+	// Simple scoring: sum of answers (0=beginner, 1=intermediate, 2=advanced)
+	total := 0
+	for _, answer := range s.Answers {
+		total += answer
+	}
+
+	switch {
+	case total <= 2:
+		return SkillBeginner
+	case total <= 4:
+		return SkillIntermediate
+	default:
+		return SkillAdvanced
+	}
+}
+
+// WantsMobile reports whether mobile access was requested
+func (s SkillScreen) WantsMobile() bool {
+	return s.Answers[1] == 0
+}
+
+// View renders the screen
+func (s SkillScreen) View() string {
+	forestGreen := lipgloss.Color("#2E521D")
+	tanBrown := lipgloss.Color("#7C5E46")
+	lightGreen := lipgloss.Color("#4A7C34")
+	white := lipgloss.Color("#FFFFFF")
+	gray := lipgloss.Color("#888888")
+	darkGray := lipgloss.Color("#666666")
+	green := lipgloss.Color("#69DB7C")
+
+	titleStyle := lipgloss.NewStyle().
+		Foreground(forestGreen).
+		Bold(true)
+
+	subtitleStyle := lipgloss.NewStyle().
+		Foreground(tanBrown)
+
+	selectedStyle := lipgloss.NewStyle().
+		Foreground(lightGreen).
+		Bold(true)
+
+	normalStyle := lipgloss.NewStyle().
+		Foreground(white)
+
+	doneStyle := lipgloss.NewStyle().
+		Foreground(green)
+
+	pendingStyle := lipgloss.NewStyle().
+		Foreground(darkGray)
+
+	helpStyle := lipgloss.NewStyle().
+		Foreground(gray).
+		MarginTop(1)
+
+	q := s.Questions[s.Index]
+
+	// Progress indicator
+	var dots strings.Builder
+	for i := range s.Questions {
+		switch {
+		case i < s.Index:
+			dots.WriteString(doneStyle.Render("● "))
+		case i == s.Index:
+			dots.WriteString(selectedStyle.Render("○ "))
+		default:
+			dots.WriteString(pendingStyle.Render("○ "))
+		}
+	}
+
+	var options strings.Builder
+	for i, opt := range q.Options {
+		cursor := "  "
+		style := normalStyle
+		if i == s.Cursor {
+			cursor = selectedStyle.Render("▸ ")
+			style = selectedStyle
+		}
+		options.WriteString(fmt.Sprintf("%s%s\n", cursor, style.Render(opt)))
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		"",
+		titleStyle.Render("Quick Setup Questions"),
+		subtitleStyle.Render(fmt.Sprintf("Question %d of %d", s.Index+1, len(s.Questions))),
+		"",
+		dots.String(),
+		"",
+		normalStyle.Render(q.Question),
+		"",
+		options.String(),
+		"",
+		helpStyle.Render("[↑/↓] Navigate  [Enter] Select  [Esc] Back  [q] Quit"),
+	)
+}
@@ -0,0 +1,208 @@
+package screens
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/doom-coding/doom-coding/internal/config"
+)
+
+// UseCase represents a predefined installation scenario
+type UseCase int
+
+const (
+	UseCaseCodeAnywhere UseCase = iota
+	UseCaseHomeLab
+	UseCaseAITerminal
+	UseCaseCustom
+)
+
+// UseCaseOption represents a use case choice
+type UseCaseOption struct {
+	UseCase     UseCase
+	Icon        string
+	Name        string
+	Description string
+}
+
+// UseCaseScreen asks what the installation is for
+type UseCaseScreen struct {
+	Width       int
+	Height      int
+	Options     []UseCaseOption
+	Cursor      int
+	Selected    UseCase
+	Skill       SkillLevel
+	WantsMobile bool // Recommend remote access
+}
+
+// NewUseCaseScreen creates a new use case screen
+func NewUseCaseScreen(skill SkillLevel, wantsMobile bool) UseCaseScreen {
+	return UseCaseScreen{
+		Options: []UseCaseOption{
+			{
+				UseCase:     UseCaseCodeAnywhere,
+				Icon:        "🌐",
+				Name:        "Code Anywhere",
+				Description: "Access VS Code from phone, laptop, anywhere with VPN",
+			},
+			{
+				UseCase:     UseCaseHomeLab,
+				Icon:        "🏠",
+				Name:        "Home Lab",
+				Description: "VS Code accessible from devices on your network",
+			},
+			{
+				UseCase:     UseCaseAITerminal,
+				Icon:        "🤖",
+				Name:        "AI Terminal",
+				Description: "Lightweight setup with Claude AI assistance",
+			},
+			{
+				UseCase:     UseCaseCustom,
+				Icon:        "⚙️",
+				Name:        "Custom Setup",
+				Description: "Full control over all configuration options",
+			},
+		},
+		Skill:       skill,
+		WantsMobile: wantsMobile,
+	}
+}
+
+// Init initializes the screen
+func (s UseCaseScreen) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles input
+func (s UseCaseScreen) Update(msg tea.Msg) (UseCaseScreen, tea.Cmd, ScreenAction) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			if s.Cursor > 0 {
+				s.Cursor--
+			}
+		case "down", "j":
+			if s.Cursor < len(s.Options)-1 {
+				s.Cursor++
+			}
+		case "enter", " ":
+			s.Selected = s.Options[s.Cursor].UseCase
+			return s, nil, ActionNext
+		case "esc":
+			return s, nil, ActionBack
+		case "q", "ctrl+c":
+			return s, tea.Quit, ActionQuit
+		}
+	case tea.WindowSizeMsg:
+		s.Width = msg.Width
+		s.Height = msg.Height
+	}
+	return s, nil, ActionNone
+}
+
+// View renders the screen
+func (s UseCaseScreen) View() string {
+	forestGreen := lipgloss.Color("#2E521D")
+	lightGreen := lipgloss.Color("#4A7C34")
+	white := lipgloss.Color("#FFFFFF")
+	gray := lipgloss.Color("#888888")
+	darkGray := lipgloss.Color("#666666")
+	green := lipgloss.Color("#69DB7C")
+
+	titleStyle := lipgloss.NewStyle().
+		Foreground(forestGreen).
+		Bold(true)
+
+	selectedStyle := lipgloss.NewStyle().
+		Foreground(lightGreen).
+		Bold(true)
+
+	normalStyle := lipgloss.NewStyle().
+		Foreground(white)
+
+	descStyle := lipgloss.NewStyle().
+		Foreground(darkGray)
+
+	recommendedStyle := lipgloss.NewStyle().
+		Foreground(green)
+
+	helpStyle := lipgloss.NewStyle().
+		Foreground(gray).
+		MarginTop(1)
+
+	var badge string
+	switch s.Skill {
+	case SkillBeginner:
+		badge = "Setup optimized for: Beginners"
+	case SkillIntermediate:
+		badge = "Setup optimized for: Intermediate users"
+	case SkillAdvanced:
+		badge = "Setup optimized for: Advanced users"
+	}
+
+	var options strings.Builder
+	for i, opt := range s.Options {
+		cursor := "  "
+		style := normalStyle
+		if i == s.Cursor {
+			cursor = selectedStyle.Render("▸ ")
+			style = selectedStyle
+		}
+
+		recommended := ""
+		if s.WantsMobile && opt.UseCase == UseCaseCodeAnywhere {
+			recommended = recommendedStyle.Render(" (Recommended)")
+		}
+
+		options.WriteString(fmt.Sprintf("%s%s %s%s\n", cursor, opt.Icon, style.Render(opt.Name), recommended))
+		options.WriteString(fmt.Sprintf("     %s\n\n", descStyle.Render(opt.Description)))
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		"",
+		titleStyle.Render("What do you want to do?"),
+		helpStyle.Render(badge),
+		"",
+		options.String(),
+		helpStyle.Render("[↑/↓] Navigate  [Enter] Select  [Esc] Back  [q] Quit"),
+	)
+}
+
+// ApplyUseCase sets the deployment mode and components of a predefined
+// use case in cfg. UseCaseCustom leaves cfg unchanged.
+func ApplyUseCase(cfg *config.Config, useCase UseCase) {
+	switch useCase {
+	case UseCaseCodeAnywhere:
+		// Full deployment with Tailscale VPN
+		cfg.DeploymentMode = config.ModeTailscale
+		cfg.Components = config.ComponentSelection{
+			Docker:         true,
+			Tailscale:      true,
+			TerminalTools:  true,
+			SSHHardening:   true,
+			SecretsManager: true,
+		}
+
+	case UseCaseHomeLab:
+		// Local network only
+		cfg.DeploymentMode = config.ModeLocal
+		cfg.Components = config.ComponentSelection{
+			Docker:         true,
+			TerminalTools:  true,
+			SSHHardening:   true,
+			SecretsManager: false, // Optional for home lab
+		}
+
+	case UseCaseAITerminal:
+		// Minimal with Claude focus
+		cfg.DeploymentMode = config.ModeTerminalOnly
+		cfg.Components = config.ComponentSelection{
+			TerminalTools: true,
+		}
+	}
+}
@@ -12,6 +12,8 @@ type WelcomeScreen struct {
 	Width   int
 	Height  int
 	Version string
+
+	SkipAssessment bool // Chose to skip straight to the advanced setup
 }
 
 // NewWelcomeScreen creates a new welcome screen
@@ -32,6 +34,10 @@ func (s WelcomeScreen) Update(msg tea.Msg) (WelcomeScreen, tea.Cmd, ScreenAction
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "enter", " ":
+			s.SkipAssessment = false
+			return s, nil, ActionNext
+		case "s":
+			s.SkipAssessment = true
 			return s, nil, ActionNext
 		case "h", "?":
 			return s, nil, ActionHelp
@@ -103,7 +109,7 @@ development environment with:
 		subtitleStyle.Render(fmt.Sprintf("Version %s", s.Version)),
 		"",
 		descStyle.Render(description),
-		helpStyle.Render("[Enter] Continue  [h] Help  [q] Quit"),
+		helpStyle.Render("[Enter] Continue  [s] Skip to Advanced  [h] Help  [q] Quit"),
 	)
 
 	return lipgloss.Place(s.Width, s.Height, lipgloss.Center, lipgloss.Center, content)
@@ -120,4 +126,5 @@ const (
 	ActionHelp
 	ActionSubmit
 	ActionRefresh
+	ActionCancel
 )